@@ -0,0 +1,544 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"order-service/internal/app"
+	"order-service/internal/cache"
+	"order-service/internal/codec"
+	"order-service/internal/export"
+	"order-service/internal/fixtures"
+	"order-service/internal/importer"
+	"order-service/internal/models"
+	"order-service/internal/service"
+)
+
+// get - заказ по order_uid
+func (c *cli) get(ctx context.Context, args []string) (interface{}, error) {
+	flags := flag.NewFlagSet("get", flag.ExitOnError)
+	withIngestion := flags.Bool("ingestion", false, "показать сведения о сообщении, из которого получен заказ")
+	if len(args) < 1 {
+		return nil, errUsage
+	}
+	uid := args[0]
+	flags.Parse(args[1:])
+
+	repo, err := c.repo()
+	if err != nil {
+		return nil, err
+	}
+
+	order, err := repo.GetOrder(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	view := orderView{Order: order}
+	if *withIngestion {
+		if view.Ingestion, err = repo.GetOrderIngestion(ctx, uid); err != nil {
+			return nil, err
+		}
+	}
+	return view, nil
+}
+
+// list - последние заказы или поиск по условиям (search)
+func (c *cli) list(ctx context.Context, args []string, search bool) (interface{}, error) {
+	flags := flag.NewFlagSet("list", flag.ExitOnError)
+	var filter models.OrderFilter
+	flags.IntVar(&filter.Limit, "limit", 20, "максимальное количество заказов")
+	flags.IntVar(&filter.Offset, "offset", 0, "пропустить первые N заказов")
+	flags.StringVar(&filter.Status, "status", "", "статус заказа")
+
+	var period timeRange
+	if search {
+		searchFlags(flags, &filter, &period)
+	}
+	flags.Parse(args)
+
+	if err := period.apply(&filter); err != nil {
+		return nil, err
+	}
+	if search && filter.Query == "" && filter.CustomerID == "" && filter.TrackNumber == "" &&
+		filter.Status == "" && filter.From == nil && filter.To == nil {
+		return nil, fmt.Errorf("search needs at least one of -q, -customer, -track, -status, -from, -to")
+	}
+
+	repo, err := c.repo()
+	if err != nil {
+		return nil, err
+	}
+
+	orders, err := repo.ListOrders(ctx, filter)
+	return orderList(orders), err
+}
+
+// export - потоковая выгрузка заказов в файл или stdout
+func (c *cli) export(ctx context.Context, args []string) (interface{}, error) {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	format := flags.String("format", export.FormatNDJSON, "формат: csv, ndjson или parquet")
+	out := flags.String("out", "", "файл для выгрузки (по умолчанию stdout)")
+	var filter models.OrderFilter
+	var period timeRange
+	flags.IntVar(&filter.Limit, "limit", 0, "максимальное количество заказов (0 - все)")
+	flags.StringVar(&filter.Status, "status", "", "статус заказа")
+	searchFlags(flags, &filter, &period)
+	flags.Parse(args)
+
+	if err := period.apply(&filter); err != nil {
+		return nil, err
+	}
+	if _, err := export.ParseFormat(*format); err != nil {
+		return nil, err
+	}
+
+	repo, err := c.repo()
+	if err != nil {
+		return nil, err
+	}
+
+	w := os.Stdout
+	if *out != "" {
+		if w, err = os.Create(*out); err != nil {
+			return nil, err
+		}
+		defer w.Close()
+	}
+
+	buffered := bufio.NewWriterSize(w, 1<<20)
+	count, err := export.New(repo).Export(ctx, filter, *format, buffered)
+	if err == nil {
+		err = buffered.Flush()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("export failed after %d orders: %w", count, err)
+	}
+
+	// Данные выгрузки могут идти в stdout, поэтому итог печатается в stderr
+	fmt.Fprintf(os.Stderr, "Exported %d orders as %s\n", count, *format)
+	return nil, nil
+}
+
+// importOrders - загрузка заказов из архива CSV или NDJSON. Отметка о прогрессе пишется
+// после каждой пачки, повторный запуск продолжает импорт с нее
+func (c *cli) importOrders(ctx context.Context, args []string) (interface{}, error) {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	format := flags.String("format", "", "формат архива: csv или ndjson (по умолчанию по расширению файла)")
+	columns := flags.String("columns", "", "переименование колонок CSV: колонка_архива=колонка_выгрузки,...")
+	batchSize := flags.Int("batch", 500, "количество записей в пачке")
+	rejectsPath := flags.String("rejects", "", "файл отклоненных записей (по умолчанию <архив>.rejects.ndjson)")
+	checkpointPath := flags.String("checkpoint", "", "файл отметки (по умолчанию <архив>.checkpoint.json)")
+	restart := flags.Bool("restart", false, "начать импорт заново, не продолжая с отметки")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		return nil, errUsage
+	}
+
+	path, err := filepath.Abs(flags.Arg(0))
+	if err != nil {
+		return nil, err
+	}
+	name := strings.TrimSuffix(path, ".gz")
+	if *format == "" && strings.EqualFold(filepath.Ext(name), ".csv") {
+		*format = importer.FormatCSV
+	}
+	if *format, err = importer.ParseFormat(*format); err != nil {
+		return nil, err
+	}
+	mapping, err := importer.ParseColumns(*columns)
+	if err != nil {
+		return nil, err
+	}
+	if *rejectsPath == "" {
+		*rejectsPath = name + ".rejects.ndjson"
+	}
+	if *checkpointPath == "" {
+		*checkpointPath = name + ".checkpoint.json"
+	}
+
+	opts := importer.Options{Format: *format, Columns: mapping, BatchSize: *batchSize, Source: filepath.Base(path)}
+	checkpoint, err := importer.LoadCheckpoint(*checkpointPath)
+	if err != nil {
+		return nil, err
+	}
+	resume := checkpoint != nil && !*restart
+	if resume {
+		if checkpoint.Source != path || checkpoint.Format != *format {
+			return nil, fmt.Errorf("checkpoint %s belongs to %s (%s), use -restart or another -checkpoint",
+				*checkpointPath, checkpoint.Source, checkpoint.Format)
+		}
+		opts.Resume = checkpoint.Report
+		fmt.Fprintf(os.Stderr, "Resuming import after record %d\n", checkpoint.Position)
+	}
+
+	input, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer input.Close()
+
+	var r io.Reader = bufio.NewReaderSize(input, 1<<20)
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	repo, err := c.repo()
+	if err != nil {
+		return nil, err
+	}
+	// Кеш и уведомления партнеров принадлежат сервису: заказы попадут в его кеш при первом обращении
+	svc := service.NewOrderService(repo, cache.NewMemoryCache(), codec.New(nil))
+
+	handler, err := importer.NewFileHandler(*rejectsPath,
+		*checkpointPath, importer.Checkpoint{Source: path, Format: *format}, resume)
+	if err != nil {
+		return nil, err
+	}
+	defer handler.Close()
+
+	report, err := importer.New(svc).Import(ctx, r, opts, handler)
+	if err != nil {
+		return nil, fmt.Errorf("import stopped after record %d (run the command again to resume): %w",
+			report.Position, err)
+	}
+
+	// Импорт завершен: следующий запуск начнется с начала архива
+	if err := os.Remove(*checkpointPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	return importSummary{Report: report, RejectsFile: *rejectsPath}, nil
+}
+
+// integrity - проверка целостности данных заказов и перенос нарушений в карантин
+func (c *cli) integrity(ctx context.Context, args []string) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, errUsage
+	}
+
+	repo, err := c.repo()
+	if err != nil {
+		return nil, err
+	}
+
+	switch args[0] {
+	case "check":
+		report, err := repo.CheckIntegrity(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return integrityReport(*report), nil
+	case "quarantine":
+		report, err := repo.Quarantine(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return quarantineReport(*report), nil
+	default:
+		return nil, errUsage
+	}
+}
+
+// seed - загрузка наборов тестовых заказов. Заказы проходят обычный путь сохранения:
+// декодирование, валидацию и CreateOrder, уже загруженные заказы пропускаются
+func (c *cli) seed(ctx context.Context, args []string) (interface{}, error) {
+	flags := flag.NewFlagSet("seed", flag.ExitOnError)
+	list := flags.Bool("list", false, "показать доступные наборы")
+	flags.Parse(args)
+
+	if *list {
+		return fixtureSets(fixtures.Names()), nil
+	}
+
+	cfg := c.config()
+	if !cfg.IsDevelopment() {
+		return nil, fmt.Errorf("seed is allowed only with APP_ENV=dev or APP_ENV=test, current profile is %q", cfg.Env)
+	}
+
+	names := flags.Args()
+	if len(names) == 0 {
+		names = []string{"default"}
+	}
+
+	// Наборы проверяются до записи, чтобы опечатка в имени не оставила часть наборов загруженной
+	sets := make([][]json.RawMessage, len(names))
+	for i, name := range names {
+		orders, err := fixtures.Load(name)
+		if err != nil {
+			return nil, err
+		}
+		sets[i] = orders
+	}
+
+	repo, err := c.repo()
+	if err != nil {
+		return nil, err
+	}
+	svc := service.NewOrderService(repo, cache.NewMemoryCache(), codec.New(nil))
+
+	results := make(seedResults, 0, len(names))
+	for i, name := range names {
+		result := seedResult{Set: name}
+		for _, order := range sets[i] {
+			created, err := svc.ReplayOrder(ctx, models.OrderPayload{Data: order})
+			if err != nil {
+				return nil, fmt.Errorf("failed to seed fixture set %q: %w", name, err)
+			}
+			if created {
+				result.Created++
+			} else {
+				result.Existing++
+			}
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// cache - состояние, очистка и сверка кеша сервиса с БД
+func (c *cli) cache(ctx context.Context, args []string) (interface{}, error) {
+	if len(args) < 1 {
+		return nil, errUsage
+	}
+
+	if args[0] == "reconcile" {
+		flags := flag.NewFlagSet("cache reconcile", flag.ExitOnError)
+		repair := flags.Bool("repair", false, "исправить расхождения")
+		last := flags.Bool("last", false, "показать отчет последней сверки, не запуская новую")
+		flags.Parse(args[1:])
+
+		if *last {
+			report, err := c.admin().LastReconcile(ctx)
+			return reconcileReport{report}, err
+		}
+		report, err := c.admin().Reconcile(ctx, *repair)
+		return reconcileReport{report}, err
+	}
+	if len(args) != 1 {
+		return nil, errUsage
+	}
+
+	switch args[0] {
+	case "stats":
+		stats, err := c.admin().CacheStats(ctx)
+		return cacheStats(stats), err
+	case "flush":
+		flushed, err := c.admin().FlushCache(ctx)
+		return counter{name: "Flushed", Count: flushed}, err
+	default:
+		return nil, errUsage
+	}
+}
+
+// dlq - просмотр и повторная отправка сообщений из DLQ
+func (c *cli) dlq(ctx context.Context, args []string) (interface{}, error) {
+	if len(args) < 1 {
+		return nil, errUsage
+	}
+	flags := flag.NewFlagSet("dlq "+args[0], flag.ExitOnError)
+	limit := flags.Int("limit", 100, "максимальное количество сообщений")
+	topic := flags.String("topic", "", "DLQ-топик (по умолчанию все DLQ)")
+	flags.Parse(args[1:])
+
+	switch args[0] {
+	case "list":
+		letters, err := c.admin().ListDLQ(ctx, *topic, *limit)
+		return deadLetters(letters), err
+	case "redrive":
+		redriven, err := c.admin().RedriveDLQ(ctx, *topic, *limit)
+		return counter{name: "Redriven", Count: redriven}, err
+	default:
+		return nil, errUsage
+	}
+}
+
+// consumer - состояние и управление consumer'ом сервиса: пауза, перемещение и повторная обработка
+func (c *cli) consumer(ctx context.Context, args []string) (interface{}, error) {
+	if len(args) < 1 {
+		return nil, errUsage
+	}
+
+	switch args[0] {
+	case "status", "pause", "resume":
+		if len(args) != 1 {
+			return nil, errUsage
+		}
+		var status models.ConsumerStatus
+		var err error
+		switch args[0] {
+		case "status":
+			status, err = c.admin().ConsumerStatus(ctx)
+		case "pause":
+			status, err = c.admin().PauseConsumer(ctx)
+		default:
+			status, err = c.admin().ResumeConsumer(ctx)
+		}
+		return consumerStatus(status), err
+	case "seek":
+		positions, err := parsePositions(args[1:])
+		if err != nil {
+			return nil, err
+		}
+		status, err := c.admin().Seek(ctx, positions)
+		return consumerStatus(status), err
+	case "replay":
+		job, err := c.replay(ctx, args[1:])
+		return replayJob(job), err
+	case "replay-status":
+		if len(args) != 2 {
+			return nil, errUsage
+		}
+		job, err := c.admin().ReplayJob(ctx, args[1])
+		return replayJob(job), err
+	default:
+		return nil, errUsage
+	}
+}
+
+// replay запускает повторную обработку и, если указан -wait, дожидается ее завершения
+func (c *cli) replay(ctx context.Context, args []string) (models.ReplayJob, error) {
+	flags := flag.NewFlagSet("consumer replay", flag.ExitOnError)
+	topic := flags.String("topic", "", "топик (по умолчанию основной топик заказов)")
+	partitions := flags.String("partitions", "", "партиции через запятую (по умолчанию все)")
+	fromOffset := flags.Int64("from-offset", -1, "начальный офсет")
+	toOffset := flags.Int64("to-offset", -1, "конечный офсет (не включительно)")
+	from := flags.String("from", "", "начало диапазона (RFC3339)")
+	to := flags.String("to", "", "конец диапазона (RFC3339)")
+	wait := flags.Bool("wait", false, "дождаться завершения")
+	flags.Parse(args)
+
+	req := models.ReplayRequest{Topic: *topic}
+	for _, p := range strings.Split(*partitions, ",") {
+		if p == "" {
+			continue
+		}
+		id, err := strconv.Atoi(p)
+		if err != nil {
+			return models.ReplayJob{}, fmt.Errorf("invalid partition %q", p)
+		}
+		req.Partitions = append(req.Partitions, id)
+	}
+	if *fromOffset >= 0 {
+		req.FromOffset = fromOffset
+	}
+	if *toOffset >= 0 {
+		req.ToOffset = toOffset
+	}
+	var err error
+	if req.From, err = parseTime("-from", *from); err != nil {
+		return models.ReplayJob{}, err
+	}
+	if req.To, err = parseTime("-to", *to); err != nil {
+		return models.ReplayJob{}, err
+	}
+
+	job, err := c.admin().StartReplay(ctx, req)
+	for err == nil && *wait && job.Status == models.ReplayRunning {
+		select {
+		case <-ctx.Done():
+			return job, ctx.Err()
+		case <-time.After(time.Second):
+		}
+		job, err = c.admin().ReplayJob(ctx, job.ID)
+	}
+	return job, err
+}
+
+// parsePositions разбирает позиции вида [topic:]partition=offset или [topic:]partition=RFC3339
+func parsePositions(args []string) ([]models.SeekPosition, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("at least one position is required")
+	}
+
+	positions := make([]models.SeekPosition, 0, len(args))
+	for _, arg := range args {
+		target, value, found := strings.Cut(arg, "=")
+		var topic string
+		if i := strings.LastIndex(target, ":"); i >= 0 {
+			topic, target = target[:i], target[i+1:]
+		}
+		partition, err := strconv.Atoi(target)
+		if !found || err != nil {
+			return nil, fmt.Errorf("invalid position %q, expected [topic:]partition=offset", arg)
+		}
+
+		pos := models.SeekPosition{Topic: topic, Partition: partition}
+		if offset, err := strconv.ParseInt(value, 10, 64); err == nil {
+			pos.Offset = &offset
+		} else if t, err := time.Parse(time.RFC3339, value); err == nil {
+			pos.Timestamp = &t
+		} else {
+			return nil, fmt.Errorf("invalid position %q: value must be an offset or RFC3339 time", arg)
+		}
+		positions = append(positions, pos)
+	}
+	return positions, nil
+}
+
+// migrate - применение и откат миграций схемы БД
+func (c *cli) migrate(args []string) (interface{}, error) {
+	cmd, err := app.ParseMigrationCommand(args)
+	if errors.Is(err, app.ErrMigrationUsage) {
+		return nil, errUsage
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := c.db()
+	if err != nil {
+		return nil, err
+	}
+	status, err := app.Migrate(context.Background(), conn, c.config().Migrations, cmd)
+	return migrationStatus(status), err
+}
+
+// searchFlags добавляет флаги условий поиска заказов
+func searchFlags(flags *flag.FlagSet, filter *models.OrderFilter, period *timeRange) {
+	flags.StringVar(&filter.Query, "q", "", "подстрока order_uid, трек-номера, customer_id, имени или email")
+	flags.StringVar(&filter.CustomerID, "customer", "", "customer_id")
+	flags.StringVar(&filter.TrackNumber, "track", "", "трек-номер")
+	flags.StringVar(&period.from, "from", "", "созданные не раньше (RFC3339)")
+	flags.StringVar(&period.to, "to", "", "созданные раньше (RFC3339)")
+}
+
+// timeRange - значения флагов -from и -to
+type timeRange struct {
+	from, to string
+}
+
+func (p timeRange) apply(filter *models.OrderFilter) error {
+	var err error
+	if filter.From, err = parseTime("-from", p.from); err != nil {
+		return err
+	}
+	filter.To, err = parseTime("-to", p.to)
+	return err
+}
+
+func parseTime(name, value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", name, err)
+	}
+	return &t, nil
+}
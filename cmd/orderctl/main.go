@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/joho/godotenv"
+	_ "github.com/lib/pq"
+
+	"order-service/internal/adminclient"
+	"order-service/internal/app"
+	"order-service/internal/config"
+	"order-service/internal/repository"
+)
+
+const usage = `Использование: orderctl [-addr URL] [-o table|json|yaml] <команда> [аргументы]
+
+Заказы (напрямую из БД):
+  get <order_uid> [-ingestion]        заказ целиком, -ingestion - со сведениями о сообщении
+  list [-limit N] [-offset N] [-status S]
+                                      последние заказы
+  search [-q текст] [-customer ID] [-track T] [-status S] [-from RFC3339] [-to RFC3339] [-limit N] [-offset N]
+                                      поиск заказов; -q ищет по order_uid, трек-номеру,
+                                      customer_id, имени и email получателя
+
+Выгрузка и загрузка (напрямую в БД):
+  export [-format csv|ndjson|parquet] [-out файл] [-q ...] [-customer ID] [-track T] [-status S] [-from RFC3339] [-to RFC3339] [-limit N]
+                                      CSV и Parquet - одна строка на товар, NDJSON - заказ на строку
+  import [-format csv|ndjson] [-columns old=new,...] [-batch N] [-rejects файл] [-checkpoint файл] [-restart] <архив>
+                                      загрузка заказов из архива (.csv, .ndjson, .gz); отклоненные записи
+                                      с причинами пишутся в -rejects, повторный запуск продолжает с отметки
+
+Тестовые данные (напрямую в БД, только при APP_ENV=dev или test):
+  seed [-list] [набор...]             загрузить наборы тестовых заказов (по умолчанию default),
+                                      уже загруженные заказы пропускаются; -list - доступные наборы
+
+Целостность данных (напрямую в БД):
+  integrity check                     заказы без доставки, платежа или товаров, строки без заказа,
+                                      повторные доставки и платежи
+  integrity quarantine                перенести найденные нарушения в таблицу quarantine
+
+Кеш сервиса (через административный API):
+  cache stats                         размер кеша и статистика обращений
+  cache flush                         очистить кеш
+  cache reconcile [-repair] [-last]   сверить кеш с БД, -repair - исправить расхождения,
+                                      -last - отчет последней сверки
+
+Consumer сервиса (через административный API):
+  consumer status                     состояние consumer'а: обработано, ошибки, отставание по партициям
+  consumer pause                      приостановить чтение из Kafka
+  consumer resume                     возобновить чтение из Kafka
+  consumer seek [topic:]<partition>=<offset|RFC3339>...
+                                      переместить consumer (например, 0=42 payments:1=2024-01-15T10:00:00Z)
+  consumer replay [-topic T] [-partitions 0,1] [-from-offset N] [-to-offset N] [-from RFC3339] [-to RFC3339] [-wait]
+                                      повторно обработать диапазон сообщений, -wait - дождаться завершения
+  consumer replay-status <id>         состояние повторной обработки
+
+Dead-letter queue (через административный API):
+  dlq list [-topic T] [-limit N]      сообщения из DLQ (по умолчанию из всех DLQ)
+  dlq redrive [-topic T] [-limit N]   вернуть сообщения из DLQ в исходные топики
+
+Миграции (напрямую в БД):
+  migrate up                          применить все миграции
+  migrate down [N]                    откатить N миграций (по умолчанию одну)
+  migrate goto <версия>               перейти к версии (от 1), применив или откатив миграции;
+                                      откатить все миграции - migrate down <количество>
+  migrate force <версия>              записать версию без выполнения миграций (после сбоя)
+  migrate version                     текущая версия схемы
+
+Подключение к БД и токен административного API (ADMIN_TOKEN) берутся из переменных
+окружения или .env файла.
+`
+
+// errUsage - неверные аргументы команды, печатается справка
+var errUsage = errors.New("invalid usage")
+
+// cli - общие для команд подключения. БД и клиент API создаются при первом обращении
+type cli struct {
+	addr   string
+	cfg    *config.Config
+	conn   *sqlx.DB
+	client *adminclient.Client
+}
+
+func main() {
+	addr := flag.String("addr", envOr("ADMIN_ADDR", "http://localhost:8081"), "адрес административного API")
+	output := flag.String("o", formatTable, "формат вывода: table, json или yaml")
+	flag.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	flag.Parse()
+
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	switch *output {
+	case formatTable, formatJSON, formatYAML:
+	default:
+		log.Fatalf("Error: unknown output format %q, expected table, json or yaml", *output)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	c := &cli{addr: *addr}
+	defer c.close()
+
+	var (
+		result interface{}
+		err    error
+	)
+	args := flag.Args()[1:]
+
+	switch flag.Arg(0) {
+	case "get":
+		result, err = c.get(ctx, args)
+	case "list":
+		result, err = c.list(ctx, args, false)
+	case "search":
+		result, err = c.list(ctx, args, true)
+	case "export":
+		result, err = c.export(ctx, args)
+	case "import":
+		result, err = c.importOrders(ctx, args)
+	case "seed":
+		result, err = c.seed(ctx, args)
+	case "integrity":
+		result, err = c.integrity(ctx, args)
+	case "cache":
+		result, err = c.cache(ctx, args)
+	case "consumer":
+		result, err = c.consumer(ctx, args)
+	case "dlq":
+		result, err = c.dlq(ctx, args)
+	case "migrate":
+		result, err = c.migrate(args)
+	default:
+		err = errUsage
+	}
+
+	if errors.Is(err, errUsage) {
+		flag.Usage()
+		c.close()
+		os.Exit(2)
+	}
+	if err != nil {
+		c.close()
+		log.Fatalf("Error: %v", err)
+	}
+
+	if result == nil {
+		return
+	}
+	if err := render(os.Stdout, *output, result); err != nil {
+		c.close()
+		log.Fatalf("Error: %v", err)
+	}
+}
+
+// config загружает конфигурацию сервиса
+func (c *cli) config() *config.Config {
+	if c.cfg == nil {
+		// Загружаем .env файл, если он есть
+		_ = godotenv.Load()
+		c.cfg = config.Load()
+	}
+	return c.cfg
+}
+
+// db подключается к базе данных сервиса
+func (c *cli) db() (*sqlx.DB, error) {
+	if c.conn == nil {
+		conn, err := app.ConnectDatabase(c.config().Database)
+		if err != nil {
+			return nil, err
+		}
+		c.conn = conn
+	}
+	return c.conn, nil
+}
+
+func (c *cli) repo() (*repository.OrderRepository, error) {
+	conn, err := c.db()
+	if err != nil {
+		return nil, err
+	}
+	return repository.NewOrderRepository(conn, c.config().Database.QueryTimeout), nil
+}
+
+func (c *cli) admin() *adminclient.Client {
+	if c.client == nil {
+		c.client = adminclient.New(c.addr, c.config().Server.AdminToken)
+	}
+	return c.client
+}
+
+func (c *cli) close() {
+	if c.conn != nil {
+		c.conn.Close()
+		c.conn = nil
+	}
+}
+
+func envOr(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
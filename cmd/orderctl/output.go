@@ -0,0 +1,315 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"order-service/internal/importer"
+	"order-service/internal/models"
+)
+
+// Форматы вывода
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+	formatYAML  = "yaml"
+)
+
+// tabular - результат, который выводится таблицей. Результаты без табличного
+// представления в формате table выводятся как JSON
+type tabular interface {
+	table(w io.Writer)
+}
+
+func render(w io.Writer, format string, v interface{}) error {
+	switch format {
+	case formatTable:
+		if t, ok := v.(tabular); ok {
+			tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+			t.table(tw)
+			return tw.Flush()
+		}
+		return render(w, formatJSON, v)
+
+	case formatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(v)
+
+	case formatYAML:
+		return writeYAML(w, v)
+
+	default:
+		return fmt.Errorf("unknown output format %q, expected table, json or yaml", format)
+	}
+}
+
+// writeYAML выводит значение в YAML с теми же именами полей и в том же порядке, что и JSON:
+// JSON разбирается как YAML в дерево узлов, которое затем печатается в блочном стиле
+func writeYAML(w io.Writer, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return err
+	}
+	blockStyle(&node)
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&node); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+func blockStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
+
+// orderList - список заказов
+type orderList []models.OrderSummary
+
+func (l orderList) table(w io.Writer) {
+	fmt.Fprintln(w, "ORDER_UID\tTRACK\tCUSTOMER\tSTATUS\tCREATED\tAMOUNT\tITEMS")
+	for _, o := range l {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d %s\t%d\n", o.OrderUID, o.TrackNumber, o.CustomerID,
+			o.Status, o.DateCreated.UTC().Format(time.DateTime), o.Amount, o.Currency, o.Items)
+	}
+}
+
+// orderView - заказ со сведениями о сообщении, из которого он получен
+type orderView struct {
+	*models.Order
+	Ingestion *models.OrderIngestion `json:"ingestion,omitempty"`
+}
+
+func (v orderView) table(w io.Writer) {
+	o := v.Order
+	fields := [][2]string{
+		{"Order UID", o.OrderUID},
+		{"Track number", o.TrackNumber},
+		{"Status", o.Status},
+		{"Created", o.DateCreated.UTC().Format(time.DateTime)},
+		{"Customer", o.CustomerID},
+		{"Locale", o.Locale},
+		{"Delivery", fmt.Sprintf("%s, %s, %s, %s %s (%s)", o.Delivery.Name, o.Delivery.Phone,
+			o.Delivery.Email, o.Delivery.City, o.Delivery.Address, o.DeliveryService)},
+		{"Payment", fmt.Sprintf("%d %s via %s/%s, goods %d, delivery %d, fee %d, status %q",
+			o.Payment.Amount, o.Payment.Currency, o.Payment.Provider, o.Payment.Bank,
+			o.Payment.GoodsTotal, o.Payment.DeliveryCost, o.Payment.CustomFee, o.Payment.Status)},
+	}
+	if in := v.Ingestion; in != nil {
+		fields = append(fields, [2]string{"Source", fmt.Sprintf("%s[%d]@%d key=%s content-type=%s trace=%s",
+			in.Topic, in.Partition, in.Offset, in.Key, in.ContentType, in.TraceID)})
+	}
+	for _, f := range fields {
+		fmt.Fprintf(w, "%s:\t%s\n", f[0], f[1])
+	}
+
+	fmt.Fprintln(w, "\nCHRT_ID\tNAME\tBRAND\tSIZE\tPRICE\tSALE\tTOTAL\tSTATUS")
+	for _, item := range o.Items {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%d\t%d%%\t%d\t%d\n", item.ChrtID, item.Name, item.Brand,
+			item.Size, item.Price, item.Sale, item.TotalPrice, item.Status)
+	}
+}
+
+// cacheStats - состояние кеша сервиса
+type cacheStats models.CacheStats
+
+func (s cacheStats) table(w io.Writer) {
+	fmt.Fprintf(w, "Size:\t%d\nHits:\t%d\nMisses:\t%d\nHit ratio:\t%.1f%%\n",
+		s.Size, s.Hits, s.Misses, s.HitRatio*100)
+}
+
+// reconcileReport - результат сверки кеша с БД
+type reconcileReport struct {
+	*models.ReconcileReport
+}
+
+func (r reconcileReport) table(w io.Writer) {
+	fmt.Fprintf(w, "Started:\t%s\nDuration:\t%s\nOrders in DB:\t%d\nOrders in cache:\t%d\n",
+		r.StartedAt.Format(time.DateTime), r.FinishedAt.Sub(r.StartedAt).Round(time.Millisecond),
+		r.DBOrders, r.CacheOrders)
+	if r.Error != "" {
+		fmt.Fprintf(w, "Error:\t%s\n", r.Error)
+	}
+
+	for _, d := range []struct {
+		name  string
+		count int
+		uids  []string
+	}{{"Missing", r.Missing, r.MissingUIDs}, {"Extra", r.Extra, r.ExtraUIDs}, {"Stale", r.Stale, r.StaleUIDs}} {
+		fmt.Fprintf(w, "%s:\t%d", d.name, d.count)
+		if len(d.uids) > 0 {
+			fmt.Fprintf(w, "\t%s", strings.Join(d.uids, " "))
+			if d.count > len(d.uids) {
+				fmt.Fprintf(w, " ...")
+			}
+		}
+		fmt.Fprintln(w)
+	}
+
+	if r.Repair {
+		fmt.Fprintf(w, "Repaired:\t%d\nRepair failed:\t%d\n", r.Repaired, r.RepairFailed)
+	}
+}
+
+// integrityReport - результат проверки целостности
+type integrityReport models.IntegrityReport
+
+func (r integrityReport) table(w io.Writer) {
+	fmt.Fprintf(w, "Orders checked:\t%d\n", r.Orders)
+	if len(r.Issues) == 0 {
+		fmt.Fprintln(w, "No issues found")
+		return
+	}
+	writeIssues(w, r.Issues)
+}
+
+// quarantineReport - строки, перенесенные в карантин
+type quarantineReport models.QuarantineReport
+
+func (r quarantineReport) table(w io.Writer) {
+	fmt.Fprintf(w, "Orders removed:\t%d\n", r.Orders)
+	if len(r.Issues) > 0 {
+		writeIssues(w, r.Issues)
+	}
+}
+
+func writeIssues(w io.Writer, issues []models.IntegrityIssue) {
+	fmt.Fprintln(w, "\nKIND\tTABLE\tCOUNT\tEXAMPLES")
+	for _, issue := range issues {
+		examples := strings.Join(issue.Samples, " ")
+		if issue.Count > len(issue.Samples) && len(issue.Samples) > 0 {
+			examples += " ..."
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\n", issue.Kind, issue.Table, issue.Count, examples)
+	}
+}
+
+// deadLetters - сообщения DLQ
+type deadLetters []models.DeadLetter
+
+func (l deadLetters) table(w io.Writer) {
+	fmt.Fprintln(w, "POSITION\tKEY\tSTAGE\tATTEMPTS\tSOURCE\tERROR")
+	for _, d := range l {
+		fmt.Fprintf(w, "%s[%d]@%d\t%s\t%s\t%d\t%s[%d]@%d\t%s\n", d.Topic, d.Partition, d.Offset, d.Key, d.Stage,
+			d.Attempts, d.SourceTopic, d.SourcePartition, d.SourceOffset, oneLine(d.Error))
+	}
+}
+
+// consumerStatus - состояние consumer'а
+type consumerStatus models.ConsumerStatus
+
+func (s consumerStatus) table(w io.Writer) {
+	state := "running"
+	if s.Paused {
+		state = "paused"
+	}
+	fmt.Fprintf(w, "Group:\t%s\nTopics:\t%s\nState:\t%s\nStarted:\t%s\n", s.GroupID,
+		strings.Join(s.Topics, " "), state, s.StartedAt.UTC().Format(time.DateTime))
+	fmt.Fprintf(w, "Processed:\t%d\nFailed:\t%d\nRate:\t%.1f msg/s\nAvg latency:\t%.1f ms\nTotal lag:\t%d\n",
+		s.Processed, s.Failed, s.MessagesPerSec, s.AvgLatencyMs, s.TotalLag)
+
+	if len(s.Partitions) > 0 {
+		fmt.Fprintln(w, "\nTOPIC\tPARTITION\tOFFSET\tHIGH WATER MARK\tLAG")
+		for _, p := range s.Partitions {
+			fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\n", p.Topic, p.Partition, p.Offset, p.HighWaterMark, p.Lag)
+		}
+	}
+}
+
+// replayJob - повторная обработка диапазона сообщений
+type replayJob models.ReplayJob
+
+func (j replayJob) table(w io.Writer) {
+	topic := j.Request.Topic
+	if topic == "" {
+		topic = "(main topic)"
+	}
+	fmt.Fprintf(w, "ID:\t%s\nStatus:\t%s\nTopic:\t%s\nStarted:\t%s\n", j.ID, j.Status, topic,
+		j.StartedAt.UTC().Format(time.DateTime))
+	if j.FinishedAt != nil {
+		fmt.Fprintf(w, "Duration:\t%s\n", j.FinishedAt.Sub(j.StartedAt).Round(time.Millisecond))
+	}
+	fmt.Fprintf(w, "Processed:\t%d\nSkipped:\t%d\nFailed:\t%d\n", j.Processed, j.Skipped, j.Failed)
+	if j.Error != "" {
+		fmt.Fprintf(w, "Error:\t%s\n", oneLine(j.Error))
+	}
+}
+
+// counter - результат команды, которая возвращает одно число (очистка кеша, redrive)
+type counter struct {
+	name  string
+	Count int `json:"count"`
+}
+
+func (c counter) table(w io.Writer) {
+	fmt.Fprintf(w, "%s:\t%d\n", c.name, c.Count)
+}
+
+// importSummary - итог импорта архива
+type importSummary struct {
+	importer.Report
+	RejectsFile string `json:"rejects_file"`
+}
+
+func (s importSummary) table(w io.Writer) {
+	fmt.Fprintf(w, "Records:\t%d\nImported:\t%d\nAlready existed:\t%d\nRejected:\t%d\n",
+		s.Position, s.Imported, s.Existing, s.Rejected)
+	if s.Rejected > 0 {
+		fmt.Fprintf(w, "Rejects:\t%s\n", s.RejectsFile)
+	}
+}
+
+// fixtureSets - имена наборов тестовых заказов
+type fixtureSets []string
+
+func (s fixtureSets) table(w io.Writer) {
+	for _, name := range s {
+		fmt.Fprintln(w, name)
+	}
+}
+
+// seedResult - итог загрузки набора тестовых заказов
+type seedResult struct {
+	Set      string `json:"set"`
+	Created  int    `json:"created"`
+	Existing int    `json:"existing"`
+}
+
+type seedResults []seedResult
+
+func (r seedResults) table(w io.Writer) {
+	fmt.Fprintln(w, "SET\tCREATED\tALREADY EXISTED")
+	for _, s := range r {
+		fmt.Fprintf(w, "%s\t%d\t%d\n", s.Set, s.Created, s.Existing)
+	}
+}
+
+// migrationStatus - версия схемы БД
+type migrationStatus struct {
+	Version uint `json:"version"`
+	Dirty   bool `json:"dirty"`
+}
+
+func (s migrationStatus) table(w io.Writer) {
+	fmt.Fprintf(w, "Version:\t%d\nDirty:\t%t\n", s.Version, s.Dirty)
+}
+
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"order-service/internal/models"
+)
+
+// Виды некорректных сообщений, которые сервис должен отклонить
+const (
+	invalidMalformed  = "malformed_json"
+	invalidNoOrderUID = "missing_order_uid"
+	invalidNoTrack    = "missing_track_number"
+	invalidNoItems    = "no_items"
+)
+
+var invalidKinds = []string{invalidMalformed, invalidNoOrderUID, invalidNoTrack, invalidNoItems}
+
+// market - согласованные между собой локаль, валюта и адреса покупателей
+type market struct {
+	locale    string
+	currency  string
+	phoneCode string
+	cities    []city
+	names     []string
+	streets   []string
+	banks     []string
+	// Порядок цен в валюте рынка: цена товара выбирается из [minPrice, maxPrice)
+	minPrice, maxPrice int
+}
+
+type city struct {
+	name, region, zipPrefix string
+}
+
+var markets = []market{
+	{
+		locale: "ru", currency: "RUB", phoneCode: "+7",
+		cities: []city{
+			{"Москва", "Московская область", "101"},
+			{"Санкт-Петербург", "Ленинградская область", "190"},
+			{"Казань", "Республика Татарстан", "420"},
+			{"Новосибирск", "Новосибирская область", "630"},
+		},
+		names:    []string{"Иван Петров", "Анна Смирнова", "Сергей Кузнецов", "Ольга Иванова"},
+		streets:  []string{"ул. Ленина", "пр. Мира", "ул. Гагарина", "Садовая ул."},
+		banks:    []string{"sber", "tinkoff", "alpha", "vtb"},
+		minPrice: 150, maxPrice: 25000,
+	},
+	{
+		locale: "en", currency: "USD", phoneCode: "+1",
+		cities: []city{
+			{"New York", "NY", "10"},
+			{"Austin", "TX", "78"},
+			{"Seattle", "WA", "98"},
+		},
+		names:    []string{"John Smith", "Emily Johnson", "Michael Brown", "Sarah Davis"},
+		streets:  []string{"Main St", "Oak Ave", "Maple Dr", "Broadway"},
+		banks:    []string{"chase", "citi", "wells_fargo"},
+		minPrice: 5, maxPrice: 500,
+	},
+	{
+		locale: "de", currency: "EUR", phoneCode: "+49",
+		cities: []city{
+			{"Berlin", "Berlin", "10"},
+			{"München", "Bayern", "80"},
+			{"Hamburg", "Hamburg", "20"},
+		},
+		names:    []string{"Lukas Müller", "Anna Schmidt", "Jonas Weber", "Lea Fischer"},
+		streets:  []string{"Hauptstraße", "Bahnhofstraße", "Gartenweg"},
+		banks:    []string{"deutsche_bank", "commerzbank", "sparkasse"},
+		minPrice: 5, maxPrice: 450,
+	},
+	{
+		locale: "kk", currency: "KZT", phoneCode: "+7",
+		cities: []city{
+			{"Алматы", "Алматы", "050"},
+			{"Астана", "Астана", "010"},
+		},
+		names:    []string{"Айдар Нурланов", "Дана Серикова", "Ержан Абенов"},
+		streets:  []string{"пр. Абая", "ул. Сатпаева", "пр. Назарбаева"},
+		banks:    []string{"kaspi", "halyk", "jusan"},
+		minPrice: 900, maxPrice: 150000,
+	},
+}
+
+var (
+	providers        = []string{"wbpay", "applepay", "googlepay", "card"}
+	deliveryServices = []string{"meest", "cdek", "dhl", "boxberry", "ups"}
+	entries          = []string{"WBIL", "WBMP", "WBAPP"}
+	sizes            = []string{"0", "XS", "S", "M", "L", "XL"}
+	products         = []struct{ name, brand string }{
+		{"Mascaras", "Vivienne Sabo"},
+		{"Кроссовки", "Nike"},
+		{"Футболка", "Uniqlo"},
+		{"Рюкзак", "Xiaomi"},
+		{"Наушники", "Sony"},
+		{"Термокружка", "Stanley"},
+		{"Зарядное устройство", "Anker"},
+		{"Книга", "Эксмо"},
+	}
+)
+
+// generator создает случайные, но правдоподобные заказы. Суммы согласованы:
+// total_price = price - sale%, goods_total = сумма total_price, amount = goods_total + delivery_cost + custom_fee.
+// Не потокобезопасен
+type generator struct {
+	rnd *rand.Rand
+}
+
+func newGenerator(seed uint64) *generator {
+	return &generator{rnd: rand.New(rand.NewPCG(seed, seed^0x9e3779b97f4a7c15))}
+}
+
+func (g *generator) order(now time.Time) models.Order {
+	m := markets[g.rnd.IntN(len(markets))]
+	c := pick(g.rnd, m.cities)
+
+	uid := g.hex(16) + "test"
+	track := "WB" + strings.ToUpper(g.hex(10))
+
+	items := make([]models.Item, 1+g.rnd.IntN(5))
+	goodsTotal := 0
+	for i := range items {
+		p := pick(g.rnd, products)
+		price := m.minPrice + g.rnd.IntN(m.maxPrice-m.minPrice)
+		sale := []int{0, 0, 5, 10, 15, 30, 50}[g.rnd.IntN(7)]
+		total := price * (100 - sale) / 100
+		goodsTotal += total
+
+		items[i] = models.Item{
+			ChrtID:      1000000 + g.rnd.IntN(9000000),
+			TrackNumber: track,
+			Price:       price,
+			Rid:         g.hex(16) + "test",
+			Name:        p.name,
+			Sale:        sale,
+			Size:        pick(g.rnd, sizes),
+			TotalPrice:  total,
+			NmID:        100000 + g.rnd.IntN(9000000),
+			Brand:       p.brand,
+			Status:      202,
+		}
+	}
+
+	deliveryCost := 0
+	if g.rnd.IntN(3) > 0 {
+		deliveryCost = m.minPrice * (1 + g.rnd.IntN(5))
+	}
+	customFee := 0
+	if g.rnd.IntN(10) == 0 {
+		customFee = goodsTotal / 100
+	}
+
+	return models.Order{
+		OrderUID:    uid,
+		TrackNumber: track,
+		Entry:       pick(g.rnd, entries),
+		Delivery: models.Delivery{
+			Name:    pick(g.rnd, m.names),
+			Phone:   fmt.Sprintf("%s%010d", m.phoneCode, g.rnd.Int64N(1e10)),
+			Zip:     fmt.Sprintf("%s%03d", c.zipPrefix, g.rnd.IntN(1000)),
+			City:    c.name,
+			Address: fmt.Sprintf("%s %d", pick(g.rnd, m.streets), 1+g.rnd.IntN(200)),
+			Region:  c.region,
+			Email:   fmt.Sprintf("user%d@example.com", g.rnd.IntN(1e6)),
+		},
+		Payment: models.Payment{
+			Transaction:  uid,
+			Currency:     m.currency,
+			Provider:     pick(g.rnd, providers),
+			Amount:       goodsTotal + deliveryCost + customFee,
+			PaymentDt:    now.Unix(),
+			Bank:         pick(g.rnd, m.banks),
+			DeliveryCost: deliveryCost,
+			GoodsTotal:   goodsTotal,
+			CustomFee:    customFee,
+		},
+		Items:           items,
+		Locale:          m.locale,
+		CustomerID:      fmt.Sprintf("customer_%d", g.rnd.IntN(100000)),
+		DeliveryService: pick(g.rnd, deliveryServices),
+		Shardkey:        fmt.Sprint(g.rnd.IntN(10)),
+		SmID:            g.rnd.IntN(100),
+		DateCreated:     now.UTC().Truncate(time.Second),
+		OofShard:        fmt.Sprint(1 + g.rnd.IntN(2)),
+	}
+}
+
+// invalid возвращает сообщение, которое сервис должен отклонить, ключ и вид ошибки
+func (g *generator) invalid(now time.Time) ([]byte, string, string) {
+	order := g.order(now)
+	kind := pick(g.rnd, invalidKinds)
+
+	switch kind {
+	case invalidNoOrderUID:
+		order.OrderUID = ""
+	case invalidNoTrack:
+		order.TrackNumber = ""
+	case invalidNoItems:
+		order.Items = nil
+	}
+
+	data, _ := json.Marshal(order)
+	if kind == invalidMalformed {
+		data = data[:len(data)/2]
+	}
+	return data, order.Payment.Transaction, kind
+}
+
+func (g *generator) hex(n int) string {
+	const digits = "0123456789abcdef"
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = digits[g.rnd.IntN(len(digits))]
+	}
+	return string(b)
+}
+
+func pick[T any](rnd *rand.Rand, values []T) T {
+	return values[rnd.IntN(len(values))]
+}
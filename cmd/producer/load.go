@@ -0,0 +1,305 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"order-service/internal/interfaces"
+	"order-service/internal/models"
+)
+
+// Сколько последних заказов хранится для отправки дубликатов
+const duplicateWindow = 1000
+
+// Виды отправленных сообщений в отчете
+const (
+	kindValid     = "valid"
+	kindDuplicate = "duplicate"
+	kindInvalid   = "invalid"
+)
+
+type options struct {
+	rate        float64
+	duration    time.Duration
+	count       int
+	concurrency int
+	invalid     float64
+	duplicates  float64
+	seed        uint64
+	progress    time.Duration
+}
+
+// job - сообщение для отправки и его вид
+type job struct {
+	msg     models.Message
+	kind    string
+	invalid string // вид ошибки для некорректных сообщений
+}
+
+// loadMain - режим генерации нагрузки
+func loadMain(args []string) {
+	flags := flag.NewFlagSet("producer", flag.ExitOnError)
+	var conn connection
+	conn.register(flags, 5*time.Millisecond)
+
+	var opts options
+	flags.Float64Var(&opts.rate, "rate", 10, "сообщений в секунду (0 - без ограничения)")
+	flags.DurationVar(&opts.duration, "duration", 10*time.Second, "длительность нагрузки (0 - до -count или Ctrl+C)")
+	flags.IntVar(&opts.count, "count", 0, "сколько сообщений отправить (0 - без ограничения)")
+	flags.IntVar(&opts.concurrency, "concurrency", 4, "количество параллельных отправителей")
+	flags.Float64Var(&opts.invalid, "invalid", 0, "доля некорректных сообщений, от 0 до 1")
+	flags.Float64Var(&opts.duplicates, "duplicates", 0, "доля повторно отправленных заказов, от 0 до 1")
+	flags.Uint64Var(&opts.seed, "seed", uint64(time.Now().UnixNano()), "seed генератора заказов")
+	flags.DurationVar(&opts.progress, "progress", 5*time.Second, "интервал вывода прогресса (0 - не выводить)")
+	flags.Usage = usageFunc(flags)
+	flags.Parse(args)
+
+	if err := opts.validate(); err != nil {
+		log.Fatalf("Invalid flags: %v", err)
+	}
+
+	cfg, b, producer := conn.open()
+	defer b.Close()
+	defer producer.Close()
+
+	ctx, cancel := signalContext()
+	defer cancel()
+	if opts.duration > 0 {
+		ctx, cancel = context.WithTimeout(ctx, opts.duration)
+		defer cancel()
+	}
+
+	fmt.Printf("Sending orders to %s (%s): rate=%s, concurrency=%d, invalid=%.0f%%, duplicates=%.0f%%, seed=%d\n",
+		cfg.Kafka.Topic, cfg.Broker.Type, formatRate(opts.rate), opts.concurrency,
+		opts.invalid*100, opts.duplicates*100, opts.seed)
+
+	st := run(ctx, producer, opts)
+	st.print(os.Stdout, opts.rate)
+
+	if st.lastUID != "" {
+		fmt.Printf("Test with: curl http://localhost:8081/order/%s\n", st.lastUID)
+	}
+	if st.sent == 0 {
+		os.Exit(1)
+	}
+}
+
+func (o options) validate() error {
+	switch {
+	case o.rate < 0:
+		return fmt.Errorf("-rate must not be negative")
+	case o.count < 0:
+		return fmt.Errorf("-count must not be negative")
+	case o.concurrency < 1:
+		return fmt.Errorf("-concurrency must be at least 1")
+	case o.invalid < 0 || o.duplicates < 0 || o.invalid+o.duplicates > 1:
+		return fmt.Errorf("-invalid and -duplicates must be fractions with a sum not above 1")
+	case o.duration == 0 && o.count == 0:
+		return fmt.Errorf("either -duration or -count is required")
+	}
+	return nil
+}
+
+// run генерирует сообщения с заданной скоростью и отправляет их параллельно,
+// пока не истечет время, не будет отправлено -count сообщений или не придет сигнал
+func run(ctx context.Context, producer interfaces.MessageSink, opts options) *stats {
+	st := newStats()
+	jobs := make(chan job, opts.concurrency)
+
+	var wg sync.WaitGroup
+	for range opts.concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				// Начатая отправка завершается даже после остановки
+				pubCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), publishTimeout)
+				started := time.Now()
+				err := producer.Publish(pubCtx, j.msg)
+				cancel()
+				st.record(j, time.Since(started), err)
+			}
+		}()
+	}
+
+	if opts.progress > 0 {
+		go st.report(ctx, opts.progress)
+	}
+
+	gen := newGenerator(opts.seed)
+	var recent []job // последние корректные заказы для дубликатов
+
+	var interval time.Duration
+	if opts.rate > 0 {
+		interval = time.Duration(float64(time.Second) / opts.rate)
+	}
+
+generate:
+	for i := 0; opts.count == 0 || i < opts.count; i++ {
+		// Время отправки отсчитывается от начала, чтобы задержки не накапливались
+		if interval > 0 {
+			if wait := time.Until(st.started.Add(time.Duration(i) * interval)); wait > 0 {
+				select {
+				case <-ctx.Done():
+					break generate
+				case <-time.After(wait):
+				}
+			}
+		}
+
+		var j job
+		now := time.Now()
+		roll := gen.rnd.Float64()
+		switch {
+		case roll < opts.duplicates && len(recent) > 0:
+			j = recent[gen.rnd.IntN(len(recent))]
+			j.kind = kindDuplicate
+		case roll >= opts.duplicates && roll < opts.duplicates+opts.invalid:
+			value, key, kind := gen.invalid(now)
+			j = job{msg: newMessage(key, value), kind: kindInvalid, invalid: kind}
+		default:
+			// Сюда же попадают дубликаты, пока не отправлено ни одного заказа
+			order := gen.order(now)
+			value, err := json.Marshal(order)
+			if err != nil {
+				log.Fatalf("Failed to marshal order: %v", err)
+			}
+			j = job{msg: newMessage(order.OrderUID, value), kind: kindValid}
+			if len(recent) < duplicateWindow {
+				recent = append(recent, j)
+			} else {
+				recent[i%duplicateWindow] = j
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			break generate
+		case jobs <- j:
+		}
+	}
+
+	close(jobs)
+	wg.Wait()
+	st.finish()
+	return st
+}
+
+func formatRate(rate float64) string {
+	if rate == 0 {
+		return "unlimited"
+	}
+	return fmt.Sprintf("%g/s", rate)
+}
+
+// stats - результаты отправки
+type stats struct {
+	mu        sync.Mutex
+	started   time.Time
+	elapsed   time.Duration
+	sent      int
+	failed    int
+	byKind    map[string]int
+	invalid   map[string]int
+	latencies []time.Duration
+	lastErr   error
+	lastUID   string // последний отправленный корректный заказ
+}
+
+func newStats() *stats {
+	return &stats{
+		started: time.Now(),
+		byKind:  make(map[string]int),
+		invalid: make(map[string]int),
+	}
+}
+
+func (s *stats) record(j job, latency time.Duration, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err != nil {
+		s.failed++
+		s.lastErr = err
+		return
+	}
+	s.sent++
+	s.byKind[j.kind]++
+	if j.invalid != "" {
+		s.invalid[j.invalid]++
+	}
+	if j.kind == kindValid {
+		s.lastUID = string(j.msg.Key)
+	}
+	s.latencies = append(s.latencies, latency)
+}
+
+func (s *stats) finish() {
+	s.mu.Lock()
+	s.elapsed = time.Since(s.started)
+	s.mu.Unlock()
+}
+
+// report периодически печатает количество отправленных сообщений и текущую скорость
+func (s *stats) report(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	prev := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.mu.Lock()
+			sent, failed := s.sent, s.failed
+			s.mu.Unlock()
+
+			fmt.Printf("  %s: sent=%d failed=%d rate=%.1f/s\n",
+				time.Since(s.started).Truncate(time.Second), sent, failed,
+				float64(sent-prev)/interval.Seconds())
+			prev = sent
+		}
+	}
+}
+
+func (s *stats) print(w io.Writer, target float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fmt.Fprintf(w, "\nSent %d messages in %s, failed %d\n", s.sent, s.elapsed.Truncate(time.Millisecond), s.failed)
+	fmt.Fprintf(w, "  valid: %d, duplicates: %d, invalid: %d\n",
+		s.byKind[kindValid], s.byKind[kindDuplicate], s.byKind[kindInvalid])
+	for _, kind := range invalidKinds {
+		if n := s.invalid[kind]; n > 0 {
+			fmt.Fprintf(w, "    %s: %d\n", kind, n)
+		}
+	}
+
+	if s.elapsed > 0 {
+		fmt.Fprintf(w, "  throughput: %.1f msg/s (target %s)\n", float64(s.sent)/s.elapsed.Seconds(), formatRate(target))
+	}
+	if len(s.latencies) > 0 {
+		slices.Sort(s.latencies)
+		fmt.Fprintf(w, "  publish latency: p50=%s p95=%s p99=%s max=%s\n",
+			percentile(s.latencies, 0.50), percentile(s.latencies, 0.95),
+			percentile(s.latencies, 0.99), s.latencies[len(s.latencies)-1])
+	}
+	if s.lastErr != nil {
+		fmt.Fprintf(w, "  last error: %v\n", s.lastErr)
+	}
+}
+
+// percentile возвращает перцентиль отсортированных задержек
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	i := int(float64(len(sorted)-1) * p)
+	return sorted[i].Round(10 * time.Microsecond)
+}
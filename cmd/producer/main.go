@@ -1,150 +1,110 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
+	"flag"
 	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 	"time"
 
-	"github.com/IBM/sarama"
+	"github.com/joho/godotenv"
+
+	"order-service/internal/config"
+	"order-service/internal/interfaces"
+	"order-service/internal/models"
+	"order-service/internal/transport/broker"
+	"order-service/internal/transport/kafka"
 )
 
-type Order struct {
-	OrderUID        string    `json:"order_uid"`
-	TrackNumber     string    `json:"track_number"`
-	Entry           string    `json:"entry"`
-	Delivery        Delivery  `json:"delivery"`
-	Payment         Payment   `json:"payment"`
-	Items           []Item    `json:"items"`
-	Locale          string    `json:"locale"`
-	InternalSig     string    `json:"internal_signature"`
-	CustomerID      string    `json:"customer_id"`
-	DeliveryService string    `json:"delivery_service"`
-	ShardKey        string    `json:"shardkey"`
-	SMID            int       `json:"sm_id"`
-	DateCreated     time.Time `json:"date_created"`
-	OofShard        string    `json:"oof_shard"`
-}
+const usage = `Использование:
+  producer [флаги]                  генерация нагрузки: случайные заказы с заданной скоростью
+                                    и отчет о достигнутой пропускной способности
+  producer replay [флаги] [файл]    повторная отправка заказов из NDJSON файла
+                                    (без файла или "-" - из stdin)
+
+Флаги:
+`
 
-type Delivery struct {
-	Name    string `json:"name"`
-	Phone   string `json:"phone"`
-	Zip     string `json:"zip"`
-	City    string `json:"city"`
-	Address string `json:"address"`
-	Region  string `json:"region"`
-	Email   string `json:"email"`
+// Таймаут отправки одного сообщения
+const publishTimeout = 10 * time.Second
+
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == "replay" {
+		replayMain(os.Args[2:])
+		return
+	}
+	loadMain(os.Args[1:])
 }
 
-type Payment struct {
-	Transaction  string `json:"transaction"`
-	RequestID    string `json:"request_id"`
-	Currency     string `json:"currency"`
-	Provider     string `json:"provider"`
-	Amount       int    `json:"amount"`
-	PaymentDT    int64  `json:"payment_dt"`
-	Bank         string `json:"bank"`
-	DeliveryCost int    `json:"delivery_cost"`
-	GoodsTotal   int    `json:"goods_total"`
-	CustomFee    int    `json:"custom_fee"`
+// connection - общие для всех режимов флаги подключения к брокеру
+type connection struct {
+	brokers string
+	topic   string
+	linger  time.Duration
 }
 
-type Item struct {
-	ChrtID      int    `json:"chrt_id"`
-	TrackNumber string `json:"track_number"`
-	Price       int    `json:"price"`
-	RID         string `json:"rid"`
-	Name        string `json:"name"`
-	Sale        int    `json:"sale"`
-	Size        string `json:"size"`
-	TotalPrice  int    `json:"total_price"`
-	NMID        int    `json:"nm_id"`
-	Brand       string `json:"brand"`
-	Status      int    `json:"status"`
+func (c *connection) register(flags *flag.FlagSet, linger time.Duration) {
+	flags.StringVar(&c.brokers, "brokers", "", "адреса брокеров через запятую (по умолчанию из конфигурации)")
+	flags.StringVar(&c.topic, "topic", "", "топик для заказов (по умолчанию KAFKA_TOPIC)")
+	flags.DurationVar(&c.linger, "linger", linger, "сколько Kafka writer ждет накопления пачки")
 }
 
-func main() {
-	// Конфигурация Kafka
-	config := sarama.NewConfig()
-	config.Producer.Return.Successes = true
-	config.Producer.RequiredAcks = sarama.WaitForAll
+// open загружает конфигурацию, применяет к ней флаги, подключается к брокеру
+// и создает отправителя в топик заказов
+func (c *connection) open() (*config.Config, interfaces.MessageBroker, interfaces.MessageSink) {
+	// Загружаем .env файл, если он есть
+	_ = godotenv.Load()
+	cfg := config.Load()
 
-	// Создание продюсера
-	producer, err := sarama.NewSyncProducer([]string{"localhost:9092"}, config)
-	if err != nil {
-		log.Fatalf("Failed to create producer: %v", err)
+	if cfg.Broker.Type == broker.TypeMemory {
+		log.Fatal("Memory broker works only inside one process, use kafka or nats")
 	}
-	defer producer.Close()
-
-	// Тестовый заказ
-	order := Order{
-		OrderUID:    fmt.Sprintf("test_order_%d", time.Now().Unix()),
-		TrackNumber: "TEST_TRACK_001",
-		Entry:       "TEST",
-		Delivery: Delivery{
-			Name:    "Test User",
-			Phone:   "+1234567890",
-			Zip:     "12345",
-			City:    "Test City",
-			Address: "Test Address 123",
-			Region:  "Test Region",
-			Email:   "test@example.com",
-		},
-		Payment: Payment{
-			Transaction:  fmt.Sprintf("test_tx_%d", time.Now().Unix()),
-			Currency:     "USD",
-			Provider:     "test_provider",
-			Amount:       1000,
-			PaymentDT:    time.Now().Unix(),
-			Bank:         "test_bank",
-			DeliveryCost: 100,
-			GoodsTotal:   900,
-			CustomFee:    0,
-		},
-		Items: []Item{
-			{
-				ChrtID:      12345,
-				TrackNumber: "TEST_TRACK_001",
-				Price:       500,
-				RID:         "test_rid_001",
-				Name:        "Test Product",
-				Sale:        10,
-				Size:        "M",
-				TotalPrice:  450,
-				NMID:        67890,
-				Brand:       "Test Brand",
-				Status:      200,
-			},
-		},
-		Locale:          "en",
-		InternalSig:     "test_sig",
-		CustomerID:      "test_customer",
-		DeliveryService: "test_delivery",
-		ShardKey:        "1",
-		SMID:            1,
-		DateCreated:     time.Now(),
-		OofShard:        "1",
+	if c.brokers != "" {
+		cfg.Kafka.Brokers = strings.Split(c.brokers, ",")
+		cfg.Broker.NATS.URL = c.brokers
 	}
+	if c.topic != "" {
+		cfg.Kafka.Topic = c.topic
+	}
+	cfg.Kafka.Linger = c.linger
 
-	// Конвертация в JSON
-	orderJSON, err := json.Marshal(order)
+	// Подключение к брокеру сообщений
+	b, err := broker.New(cfg)
 	if err != nil {
-		log.Fatalf("Failed to marshal order: %v", err)
+		log.Fatalf("Failed to connect to message broker: %v", err)
 	}
 
-	// Отправка сообщения
-	message := &sarama.ProducerMessage{
-		Topic: "orders",
-		Value: sarama.StringEncoder(orderJSON),
+	producer, err := b.Sink(cfg.Kafka.Topic)
+	if err != nil {
+		b.Close()
+		log.Fatalf("Failed to create producer: %v", err)
 	}
 
-	partition, offset, err := producer.SendMessage(message)
-	if err != nil {
-		log.Fatalf("Failed to send message: %v", err)
+	return cfg, b, producer
+}
+
+func usageFunc(flags *flag.FlagSet) func() {
+	return func() {
+		fmt.Fprint(os.Stderr, usage)
+		flags.PrintDefaults()
 	}
+}
+
+func signalContext() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+}
 
-	fmt.Printf("Message sent successfully!\n")
-	fmt.Printf("Order ID: %s\n", order.OrderUID)
-	fmt.Printf("Partition: %d, Offset: %d\n", partition, offset)
-	fmt.Printf("Test with: curl http://localhost:8081/order/%s\n", order.OrderUID)
+func newMessage(key string, value []byte) models.Message {
+	return models.Message{
+		Key:   []byte(key),
+		Value: value,
+		Headers: []models.MessageHeader{
+			{Key: kafka.HeaderContentType, Value: []byte("application/json")},
+			{Key: kafka.HeaderProducerID, Value: []byte("order-producer")},
+		},
+	}
 }
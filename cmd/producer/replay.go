@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"order-service/internal/interfaces"
+	"order-service/internal/models"
+)
+
+// Максимальный размер строки NDJSON файла
+const maxLineSize = 16 << 20
+
+type replayOptions struct {
+	speed       float64
+	maxGap      time.Duration
+	rewriteUIDs bool
+	uidSuffix   string
+}
+
+// replayResult - итоги повторной отправки
+type replayResult struct {
+	sent    int
+	failed  int
+	skipped int
+	elapsed time.Duration
+}
+
+// replayMain - режим повторной отправки заказов из NDJSON файла
+func replayMain(args []string) {
+	flags := flag.NewFlagSet("replay", flag.ExitOnError)
+	var conn connection
+	conn.register(flags, 0)
+
+	var opts replayOptions
+	flags.Float64Var(&opts.speed, "speed", 1, "множитель скорости: 1 - исходные интервалы между заказами, 2 - вдвое быстрее, 0 - без пауз")
+	flags.DurationVar(&opts.maxGap, "max-gap", 0, "максимальная пауза между заказами до применения -speed (0 - без ограничения)")
+	flags.BoolVar(&opts.rewriteUIDs, "rewrite-uids", false, "добавить суффикс к order_uid, чтобы не пересекаться с уже сохраненными заказами")
+	flags.StringVar(&opts.uidSuffix, "uid-suffix", "", "суффикс для -rewrite-uids (по умолчанию случайный для каждого запуска)")
+	flags.Usage = usageFunc(flags)
+	flags.Parse(args)
+
+	if opts.speed < 0 {
+		log.Fatal("Invalid flags: -speed must not be negative")
+	}
+	if flags.NArg() > 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	if opts.rewriteUIDs && opts.uidSuffix == "" {
+		b := make([]byte, 4)
+		if _, err := rand.Read(b); err != nil {
+			log.Fatalf("Failed to generate order_uid suffix: %v", err)
+		}
+		opts.uidSuffix = "_r" + hex.EncodeToString(b)
+	}
+
+	input := os.Stdin
+	if name := flags.Arg(0); name != "" && name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			log.Fatalf("Failed to open %s: %v", name, err)
+		}
+		defer f.Close()
+		input = f
+	}
+
+	cfg, b, producer := conn.open()
+	defer b.Close()
+	defer producer.Close()
+
+	ctx, cancel := signalContext()
+	defer cancel()
+
+	fmt.Printf("Replaying orders to %s (%s): speed=%s", cfg.Kafka.Topic, cfg.Broker.Type, formatSpeed(opts.speed))
+	if opts.rewriteUIDs {
+		fmt.Printf(", order_uid suffix %q", opts.uidSuffix)
+	}
+	fmt.Println()
+
+	res, err := replay(ctx, input, producer, opts, os.Stdout)
+	fmt.Printf("\nReplayed %d orders in %s, failed %d, skipped %d lines\n",
+		res.sent, res.elapsed.Truncate(time.Millisecond), res.failed, res.skipped)
+	if err != nil {
+		log.Fatalf("Replay stopped: %v", err)
+	}
+	if res.failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// replay читает заказы построчно и отправляет их по одному, сохраняя интервалы между
+// date_created соседних заказов (с учетом -speed и -max-gap). Для каждого заказа в out
+// печатается партиция и офсет. Строки, которые не удалось разобрать, пропускаются
+func replay(ctx context.Context, input io.Reader, producer interfaces.MessageSink, opts replayOptions, out io.Writer) (replayResult, error) {
+	var res replayResult
+	started := time.Now()
+
+	scanner := bufio.NewScanner(input)
+	scanner.Buffer(make([]byte, 64<<10), maxLineSize)
+
+	var (
+		prev    time.Time     // date_created предыдущего заказа
+		elapsed time.Duration // сколько должно пройти от начала до отправки текущего заказа
+	)
+
+	for line := 1; scanner.Scan(); line++ {
+		data := scanner.Bytes()
+		if len(data) == 0 {
+			continue
+		}
+
+		var order models.Order
+		if err := json.Unmarshal(data, &order); err != nil {
+			log.Printf("Line %d skipped: %v", line, err)
+			res.skipped++
+			continue
+		}
+
+		if opts.speed > 0 && !order.DateCreated.IsZero() {
+			if !prev.IsZero() {
+				gap := max(order.DateCreated.Sub(prev), 0)
+				if opts.maxGap > 0 {
+					gap = min(gap, opts.maxGap)
+				}
+				elapsed += time.Duration(float64(gap) / opts.speed)
+			}
+			prev = order.DateCreated
+
+			if wait := time.Until(started.Add(elapsed)); wait > 0 {
+				select {
+				case <-ctx.Done():
+					res.elapsed = time.Since(started)
+					return res, ctx.Err()
+				case <-time.After(wait):
+				}
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			res.elapsed = time.Since(started)
+			return res, err
+		}
+
+		if opts.rewriteUIDs {
+			rewriteUID(&order, opts.uidSuffix)
+		}
+
+		value, err := json.Marshal(order)
+		if err != nil {
+			log.Printf("Line %d skipped: %v", line, err)
+			res.skipped++
+			continue
+		}
+
+		sendCtx, cancel := context.WithTimeout(ctx, publishTimeout)
+		msg, err := producer.Send(sendCtx, newMessage(order.OrderUID, value))
+		cancel()
+		if err != nil {
+			log.Printf("Line %d: failed to send order %s: %v", line, order.OrderUID, err)
+			res.failed++
+			continue
+		}
+
+		res.sent++
+		fmt.Fprintf(out, "line %d: %s -> %s partition %d offset %d\n",
+			line, order.OrderUID, msg.Topic, msg.Partition, msg.Offset)
+	}
+
+	res.elapsed = time.Since(started)
+	if err := scanner.Err(); err != nil {
+		return res, fmt.Errorf("failed to read orders: %w", err)
+	}
+	return res, nil
+}
+
+// rewriteUID добавляет суффикс к order_uid. Транзакция оплаты, совпадающая с order_uid,
+// переименовывается вместе с ним. Повторы одного заказа в файле остаются повторами
+func rewriteUID(order *models.Order, suffix string) {
+	if order.Payment.Transaction == order.OrderUID {
+		order.Payment.Transaction += suffix
+	}
+	order.OrderUID += suffix
+}
+
+func formatSpeed(speed float64) string {
+	if speed == 0 {
+		return "no delays"
+	}
+	return fmt.Sprintf("x%g", speed)
+}
@@ -1,13 +1,28 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log"
+	"os"
 
 	"order-service/internal/app"
+	"order-service/internal/config"
 
 	"github.com/joho/godotenv"
 )
 
+const migrateUsage = `Использование: server migrate <команда>
+
+  up               применить все миграции
+  down [N]         откатить N миграций (по умолчанию одну)
+  goto <версия>    перейти к версии (от 1), применив или откатив миграции;
+                   откатить все миграции - down <количество>
+  force <версия>   записать версию без выполнения миграций (после сбоя миграции)
+  version          текущая версия схемы
+`
+
 func main() {
 
 	// Загружаем .env файл
@@ -16,6 +31,13 @@ func main() {
 	} else {
 		log.Println(".env file loaded successfully")
 	}
+
+	// server migrate ... - только миграции, без запуска сервиса
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrate(os.Args[2:])
+		return
+	}
+
 	// Создаем приложение
 	application := app.New()
 
@@ -34,3 +56,29 @@ func main() {
 		log.Fatalf("Failed to shutdown application: %v", err)
 	}
 }
+
+// runMigrate выполняет команду миграций и печатает версию схемы
+func runMigrate(args []string) {
+	cmd, err := app.ParseMigrationCommand(args)
+	if errors.Is(err, app.ErrMigrationUsage) {
+		fmt.Fprint(os.Stderr, migrateUsage)
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+
+	cfg := config.Load()
+	db, err := app.ConnectDatabase(cfg.Database)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	status, err := app.Migrate(context.Background(), db, cfg.Migrations, cmd)
+	if err != nil {
+		db.Close()
+		log.Fatalf("Error: %v", err)
+	}
+	fmt.Printf("Migration version: %d (dirty: %t)\n", status.Version, status.Dirty)
+}
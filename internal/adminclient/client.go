@@ -0,0 +1,164 @@
+package adminclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"order-service/internal/models"
+)
+
+// Client - HTTP клиент административного API сервиса заказов
+type Client struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+// New создает клиент. token - токен административного API (ADMIN_TOKEN сервиса)
+func New(baseURL, token string) *Client {
+	return &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		token:   token,
+		http:    &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+func (c *Client) ConsumerStatus(ctx context.Context) (models.ConsumerStatus, error) {
+	var status models.ConsumerStatus
+	err := c.do(ctx, http.MethodGet, "/admin/consumer", nil, &status)
+	return status, err
+}
+
+func (c *Client) PauseConsumer(ctx context.Context) (models.ConsumerStatus, error) {
+	var status models.ConsumerStatus
+	err := c.do(ctx, http.MethodPost, "/admin/consumer/pause", nil, &status)
+	return status, err
+}
+
+func (c *Client) ResumeConsumer(ctx context.Context) (models.ConsumerStatus, error) {
+	var status models.ConsumerStatus
+	err := c.do(ctx, http.MethodPost, "/admin/consumer/resume", nil, &status)
+	return status, err
+}
+
+func (c *Client) Seek(ctx context.Context, positions []models.SeekPosition) (models.ConsumerStatus, error) {
+	var status models.ConsumerStatus
+	err := c.do(ctx, http.MethodPost, "/admin/consumer/seek", positions, &status)
+	return status, err
+}
+
+func (c *Client) StartReplay(ctx context.Context, req models.ReplayRequest) (models.ReplayJob, error) {
+	var job models.ReplayJob
+	err := c.do(ctx, http.MethodPost, "/admin/consumer/replay", req, &job)
+	return job, err
+}
+
+func (c *Client) ReplayJob(ctx context.Context, id string) (models.ReplayJob, error) {
+	var job models.ReplayJob
+	err := c.do(ctx, http.MethodGet, "/admin/consumer/replay/"+url.PathEscape(id), nil, &job)
+	return job, err
+}
+
+// ListDLQ возвращает сообщения DLQ-топика topic (пустой - всех DLQ)
+func (c *Client) ListDLQ(ctx context.Context, topic string, limit int) ([]models.DeadLetter, error) {
+	var response struct {
+		Messages []models.DeadLetter `json:"messages"`
+	}
+	err := c.do(ctx, http.MethodGet, "/admin/dlq?"+dlqQuery(topic, limit), nil, &response)
+	return response.Messages, err
+}
+
+// RedriveDLQ возвращает сообщения DLQ-топика topic (пустой - всех DLQ) в исходные топики
+func (c *Client) RedriveDLQ(ctx context.Context, topic string, limit int) (int, error) {
+	var response struct {
+		Redriven int `json:"redriven"`
+	}
+	err := c.do(ctx, http.MethodPost, "/admin/dlq/redrive?"+dlqQuery(topic, limit), nil, &response)
+	return response.Redriven, err
+}
+
+func dlqQuery(topic string, limit int) string {
+	query := url.Values{"limit": {strconv.Itoa(limit)}}
+	if topic != "" {
+		query.Set("topic", topic)
+	}
+	return query.Encode()
+}
+
+func (c *Client) CacheStats(ctx context.Context) (models.CacheStats, error) {
+	var stats models.CacheStats
+	err := c.do(ctx, http.MethodGet, "/admin/cache", nil, &stats)
+	return stats, err
+}
+
+func (c *Client) FlushCache(ctx context.Context) (int, error) {
+	var response struct {
+		Flushed int `json:"flushed"`
+	}
+	err := c.do(ctx, http.MethodPost, "/admin/cache/flush", nil, &response)
+	return response.Flushed, err
+}
+
+// Reconcile сверяет кеш сервиса с БД, при repair - исправляет расхождения
+func (c *Client) Reconcile(ctx context.Context, repair bool) (*models.ReconcileReport, error) {
+	var report models.ReconcileReport
+	err := c.do(ctx, http.MethodPost, "/admin/cache/reconcile?repair="+strconv.FormatBool(repair), nil, &report)
+	return &report, err
+}
+
+// LastReconcile возвращает отчет последней сверки кеша
+func (c *Client) LastReconcile(ctx context.Context) (*models.ReconcileReport, error) {
+	var report models.ReconcileReport
+	err := c.do(ctx, http.MethodGet, "/admin/cache/reconcile", nil, &report)
+	return &report, err
+}
+
+// do выполняет запрос и декодирует JSON ответ в out
+func (c *Client) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("request to %s failed: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		json.NewDecoder(resp.Body).Decode(&apiErr)
+		return fmt.Errorf("%s %s: %s (%d)", method, path, apiErr.Error, resp.StatusCode)
+	}
+
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
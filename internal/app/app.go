@@ -2,6 +2,7 @@ package app
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"os"
 	"os/signal"
@@ -11,13 +12,20 @@ import (
 	"github.com/jmoiron/sqlx"
 
 	"order-service/internal/cache"
+	"order-service/internal/codec"
 	"order-service/internal/config"
+	"order-service/internal/export"
+	"order-service/internal/importer"
 	"order-service/internal/interfaces"
+	"order-service/internal/outbox"
+	"order-service/internal/reconcile"
 	"order-service/internal/repository"
 	"order-service/internal/service"
+	"order-service/internal/transport/broker"
 	"order-service/internal/transport/http"
 	"order-service/internal/transport/http/handlers"
 	"order-service/internal/transport/kafka"
+	"order-service/internal/webhook"
 )
 
 // App представляет основное приложение
@@ -27,7 +35,15 @@ type App struct {
 	cache         interfaces.Cache
 	service       interfaces.OrderService
 	httpServer    *http.Server
+	broker        interfaces.MessageBroker
 	kafkaConsumer *kafka.Consumer
+	dlq           *kafka.DeadLetterQueue
+	outboxSink    interfaces.MessageSink
+	outboxRelay   *outbox.Relay
+	webhooks      *webhook.Service
+	exporter      *export.Exporter
+	importer      *importer.Importer
+	reconciler    *reconcile.Reconciler
 }
 
 // New создает новый экземпляр приложения
@@ -54,26 +70,42 @@ func (a *App) Initialize() error {
 	log.Println("Database connected successfully")
 
 	// 3. Запускаем миграции
-	log.Println("Running database migrations...")
-	if err := RunMigrations(a.db); err != nil {
-		log.Printf("Warning: Failed to run migrations: %v", err)
+	if a.config.Migrations.OnStartup {
+		log.Println("Running database migrations...")
+		if err := RunMigrations(a.db, a.config.Migrations); err != nil {
+			if a.config.Migrations.Required {
+				return fmt.Errorf("failed to run migrations: %w", err)
+			}
+			log.Printf("Warning: Failed to run migrations: %v", err)
+		}
 	}
 
 	// 4. Создаем слои приложения
 	a.cache = cache.NewMemoryCache()
-	repo := repository.NewOrderRepository(a.db)
-	a.service = service.NewOrderService(repo, a.cache)
+	repo := repository.NewOrderRepository(a.db, a.config.Database.QueryTimeout)
+	a.webhooks = webhook.NewService(repository.NewWebhookRepository(a.db), a.config.Webhook, nil)
+	a.service = service.NewOrderService(repo, a.cache, codec.New(newSchemaRegistry(a.config.SchemaRegistry)))
+	a.exporter = export.New(repo)
+	a.importer = importer.New(a.service)
+	a.reconciler = reconcile.New(repo, a.cache, a.config.Reconcile)
 
 	// 5. Загружаем кеш из БД
 	if err := a.loadCache(); err != nil {
 		log.Printf("Warning: Failed to load cache: %v", err)
 	}
 
-	// 6. Инициализируем HTTP сервер
-	a.initHTTPServer()
+	// 6. Инициализируем Kafka consumer
+	if err := a.initKafkaConsumer(); err != nil {
+		return fmt.Errorf("failed to init kafka consumer: %w", err)
+	}
 
-	// 7. Инициализируем Kafka consumer
-	a.initKafkaConsumer()
+	// 7. Инициализируем отправку событий из outbox
+	if err := a.initOutboxRelay(); err != nil {
+		return fmt.Errorf("failed to init outbox relay: %w", err)
+	}
+
+	// 8. Инициализируем HTTP сервер
+	a.initHTTPServer()
 
 	log.Println("Application initialized successfully")
 	return nil
@@ -88,13 +120,42 @@ func (a *App) Run() error {
 	defer cancel()
 
 	// Запускаем Kafka consumer
+	consumerDone := make(chan struct{})
 	go func() {
+		defer close(consumerDone)
 		log.Println("Starting Kafka consumer...")
 		if err := a.kafkaConsumer.Start(ctx); err != nil {
 			log.Printf("Kafka consumer error: %v", err)
 		}
 	}()
 
+	// Запускаем отправку событий из outbox
+	relayDone := make(chan struct{})
+	go func() {
+		defer close(relayDone)
+		if a.outboxRelay != nil {
+			a.outboxRelay.Run(ctx)
+		}
+	}()
+
+	// Запускаем доставку webhook
+	webhooksDone := make(chan struct{})
+	go func() {
+		defer close(webhooksDone)
+		if a.config.Webhook.Enabled {
+			a.webhooks.Run(ctx)
+		}
+	}()
+
+	// Запускаем сверку кеша с БД по расписанию
+	reconcileDone := make(chan struct{})
+	go func() {
+		defer close(reconcileDone)
+		if a.config.Reconcile.Enabled {
+			a.reconciler.Run(ctx)
+		}
+	}()
+
 	// Запускаем HTTP сервер
 	go func() {
 		log.Printf("HTTP server starting on port %s", a.config.Server.Port)
@@ -107,14 +168,33 @@ func (a *App) Run() error {
 		}
 	}()
 
-	// Ожидаем сигнал для завершения
-	return a.waitForShutdown(ctx, cancel)
+	// Ожидаем сигнал для завершения, затем даем consumer'у зафиксировать офсеты
+	err := a.waitForShutdown(ctx, cancel)
+	<-consumerDone
+	<-relayDone
+	<-webhooksDone
+	<-reconcileDone
+	return err
 }
 
 // Shutdown корректно завершает работу приложения
 func (a *App) Shutdown() error {
 	log.Println("Shutting down application...")
 
+	if a.dlq != nil {
+		a.dlq.Close()
+	}
+
+	if a.outboxSink != nil {
+		a.outboxSink.Close()
+	}
+
+	if a.broker != nil {
+		if err := a.broker.Close(); err != nil {
+			log.Printf("Error closing message broker: %v", err)
+		}
+	}
+
 	if a.db != nil {
 		a.db.Close()
 		log.Println("Database connection closed")
@@ -126,7 +206,7 @@ func (a *App) Shutdown() error {
 
 // loadCache загружает кеш из базы данных
 func (a *App) loadCache() error {
-	if err := a.service.LoadCacheFromDB(); err != nil {
+	if err := a.service.LoadCacheFromDB(context.Background()); err != nil {
 		return err
 	}
 
@@ -137,18 +217,79 @@ func (a *App) loadCache() error {
 
 // initHTTPServer инициализирует HTTP сервер
 func (a *App) initHTTPServer() {
+	// Просмотр и повторная отправка DLQ доступны только для Kafka
+	var dlq interfaces.DeadLetterQueue
+	if conn, ok := a.broker.(*kafka.Connection); ok {
+		a.dlq = kafka.NewDeadLetterQueue(
+			conn,
+			a.config.Kafka.Topics,
+			a.config.Kafka.GroupID+"-dlq-redrive",
+		)
+		dlq = a.dlq
+	}
+
 	orderHandler := handlers.NewOrderHandler(a.service)
-	a.httpServer = http.NewServer(a.config.Server.Port, orderHandler)
+	adminHandler := handlers.NewAdminHandler(dlq, a.kafkaConsumer)
+	webhookHandler := handlers.NewWebhookHandler(a.webhooks)
+	exportHandler := handlers.NewExportHandler(a.exporter)
+	importHandler := handlers.NewImportHandler(a.importer)
+	reconcileHandler := handlers.NewReconcileHandler(a.reconciler)
+	integrityHandler := handlers.NewIntegrityHandler(
+		repository.NewOrderRepository(a.db, a.config.Database.QueryTimeout))
+	if a.config.Server.AdminToken == "" {
+		log.Println("Warning: ADMIN_TOKEN is not set, admin API is disabled")
+	}
+	a.httpServer = http.NewServer(a.config.Server.Port, a.config.Server.AdminToken, orderHandler, adminHandler, webhookHandler,
+		exportHandler, importHandler, reconcileHandler, integrityHandler)
+}
+
+// newSchemaRegistry выбирает реестр схем Avro: HTTP-реестр, каталог со схемами или никакой
+func newSchemaRegistry(cfg config.SchemaRegistryConfig) interfaces.SchemaRegistry {
+	switch {
+	case cfg.URL != "":
+		log.Printf("Using schema registry %s", cfg.URL)
+		return codec.NewRegistryClient(cfg.URL)
+	case cfg.Dir != "":
+		log.Printf("Using schema directory %s", cfg.Dir)
+		return codec.NewFileRegistry(cfg.Dir)
+	default:
+		return nil
+	}
+}
+
+// initKafkaConsumer подключается к брокеру сообщений и инициализирует consumer
+func (a *App) initKafkaConsumer() error {
+	b, err := broker.New(a.config)
+	if err != nil {
+		return err
+	}
+
+	consumer, err := kafka.NewConsumer(b, a.config.Kafka, a.service, kafka.NewOrderRegistry(a.service))
+	if err != nil {
+		b.Close()
+		return err
+	}
+
+	a.broker = b
+	a.kafkaConsumer = consumer
+	return nil
 }
 
-// initKafkaConsumer инициализирует Kafka consumer
-func (a *App) initKafkaConsumer() {
-	a.kafkaConsumer = kafka.NewConsumer(
-		a.config.Kafka.Brokers,
-		a.config.Kafka.Topic,
-		a.config.Kafka.GroupID,
-		a.service,
-	)
+// initOutboxRelay создает отправителя событий заказов из outbox, если он включен
+func (a *App) initOutboxRelay() error {
+	if !a.config.Outbox.Enabled {
+		log.Println("Outbox relay is disabled")
+		return nil
+	}
+
+	sink, err := a.broker.Sink(a.config.Outbox.Topic)
+	if err != nil {
+		return err
+	}
+
+	a.outboxSink = sink
+	a.outboxRelay = outbox.NewRelay(repository.NewOutboxRepository(a.db), sink, a.config.Outbox)
+	return nil
 }
 
 // waitForShutdown ожидает сигнал для завершения работы
@@ -1,56 +1,189 @@
 package app
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"strconv"
+	"time"
 
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
 	"github.com/jmoiron/sqlx"
+
+	"order-service/internal/config"
+	"order-service/internal/migrations"
 )
 
-// RunMigrations выполняет миграции базы данных
-func RunMigrations(db *sqlx.DB) error {
-	// Получаем *sql.DB из *sqlx.DB
-	sqlDB := db.DB
+// migrationLockID - ключ advisory-блокировки, под которой выполняются команды миграций
+const migrationLockID int64 = 0x6f72646572 // "order"
+
+// ErrMigrationUsage - неверная команда миграций
+var ErrMigrationUsage = errors.New("usage: migrate up | down [N] | goto V | force V | version")
+
+// MigrationCommand - команда миграций: up, down (откатить N миграций), goto (перейти
+// к версии N не меньше 1), force (записать версию N без выполнения миграций) или version
+type MigrationCommand struct {
+	Name string
+	N    int
+}
+
+// MigrationStatus - версия схемы БД. Version 0 - миграции не применялись
+type MigrationStatus struct {
+	Version uint `json:"version"`
+	Dirty   bool `json:"dirty"`
+}
+
+// ParseMigrationCommand разбирает аргументы команды migrate
+func ParseMigrationCommand(args []string) (MigrationCommand, error) {
+	if len(args) == 0 {
+		return MigrationCommand{}, ErrMigrationUsage
+	}
 
-	driver, err := postgres.WithInstance(sqlDB, &postgres.Config{})
+	cmd := MigrationCommand{Name: args[0]}
+	switch {
+	case (cmd.Name == "up" || cmd.Name == "version") && len(args) == 1:
+		return cmd, nil
+	case cmd.Name == "down" && len(args) == 1:
+		cmd.N = 1
+		return cmd, nil
+	case (cmd.Name == "down" || cmd.Name == "goto" || cmd.Name == "force") && len(args) == 2:
+		n, err := strconv.Atoi(args[1])
+		// Версии 0 нет: golang-migrate ищет файл миграции 0 и падает, все миграции откатывает down
+		if err == nil && cmd.Name == "goto" && n < 1 {
+			return cmd, ErrMigrationUsage
+		}
+		// force -1 - схема без примененных миграций
+		if err != nil || n < -1 || (n == -1 && cmd.Name != "force") || (cmd.Name == "down" && n == 0) {
+			return cmd, fmt.Errorf("invalid %s argument %q", cmd.Name, args[1])
+		}
+		cmd.N = n
+		return cmd, nil
+	default:
+		return cmd, ErrMigrationUsage
+	}
+}
+
+// NewMigrator создает экземпляр golang-migrate для базы данных сервиса. Миграции
+// читаются из встроенного в бинарник каталога, драйвер работает на отдельном
+// соединении пула, которое возвращается в пул при Close
+func NewMigrator(ctx context.Context, db *sqlx.DB) (*migrate.Migrate, error) {
+	conn, err := db.Conn(ctx)
 	if err != nil {
-		return fmt.Errorf("could not create postgres driver: %w", err)
+		return nil, fmt.Errorf("could not get database connection: %w", err)
 	}
 
-	// Путь к миграциям
-	migrationsPath := "file://internal/migrations"
+	driver, err := postgres.WithConnection(ctx, conn, &postgres.Config{})
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("could not create postgres driver: %w", err)
+	}
 
-	m, err := migrate.NewWithDatabaseInstance(
-		migrationsPath,
-		"postgres",
-		driver,
-	)
+	source, err := iofs.New(migrations.FS, ".")
 	if err != nil {
-		return fmt.Errorf("could not create migrate instance: %w", err)
+		driver.Close()
+		return nil, fmt.Errorf("could not read embedded migrations: %w", err)
 	}
 
-	// Применяем миграции
-	err = m.Up()
-	if err != nil && err != migrate.ErrNoChange {
-		return fmt.Errorf("could not run migrations: %w", err)
+	m, err := migrate.NewWithInstance("iofs", source, "postgres", driver)
+	if err != nil {
+		source.Close()
+		driver.Close()
+		return nil, fmt.Errorf("could not create migrate instance: %w", err)
 	}
+	return m, nil
+}
 
-	if err == migrate.ErrNoChange {
-		log.Println("📋 No migrations to apply")
-	} else {
-		log.Println("✅ Migrations applied successfully")
+// Migrate выполняет команду миграций под advisory-блокировкой, чтобы реплики,
+// запущенные одновременно, не применяли миграции параллельно
+func Migrate(ctx context.Context, db *sqlx.DB, cfg config.MigrationsConfig, cmd MigrationCommand) (MigrationStatus, error) {
+	unlock, err := lockMigrations(ctx, db, cfg.LockTimeout)
+	if err != nil {
+		return MigrationStatus{}, err
+	}
+	defer unlock()
+
+	m, err := NewMigrator(ctx, db)
+	if err != nil {
+		return MigrationStatus{}, err
+	}
+	defer m.Close()
+
+	switch cmd.Name {
+	case "up":
+		err = m.Up()
+	case "down":
+		err = m.Steps(-cmd.N)
+	case "goto":
+		if cmd.N < 1 {
+			return MigrationStatus{}, ErrMigrationUsage
+		}
+		err = m.Migrate(uint(cmd.N))
+	case "force":
+		err = m.Force(cmd.N)
+	case "version":
+	default:
+		return MigrationStatus{}, ErrMigrationUsage
+	}
+	if err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return MigrationStatus{}, fmt.Errorf("migrate %s failed: %w", cmd.Name, err)
 	}
 
-	// Получаем текущую версию
 	version, dirty, err := m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return MigrationStatus{}, nil
+	}
+	if err != nil {
+		return MigrationStatus{}, fmt.Errorf("could not get migration version: %w", err)
+	}
+	return MigrationStatus{Version: version, Dirty: dirty}, nil
+}
+
+// lockMigrations берет advisory-блокировку миграций на отдельном соединении.
+// Если ее держит другая реплика, ждет не дольше timeout
+func lockMigrations(ctx context.Context, db *sqlx.DB, timeout time.Duration) (func(), error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not get database connection: %w", err)
+	}
+
+	var locked bool
+	if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, migrationLockID).Scan(&locked); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("could not acquire migration lock: %w", err)
+	}
+
+	if !locked {
+		log.Printf("Waiting up to %s for migrations running on another instance...", timeout)
+		lockCtx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		if _, err := conn.ExecContext(lockCtx, `SELECT pg_advisory_lock($1)`, migrationLockID); err != nil {
+			conn.Close()
+			if lockCtx.Err() != nil {
+				return nil, fmt.Errorf("migration lock not acquired within %s", timeout)
+			}
+			return nil, fmt.Errorf("could not acquire migration lock: %w", err)
+		}
+	}
+
+	return func() {
+		if _, err := conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationLockID); err != nil {
+			log.Printf("Warning: failed to release migration lock: %v", err)
+		}
+		conn.Close()
+	}, nil
+}
+
+// RunMigrations применяет все миграции базы данных
+func RunMigrations(db *sqlx.DB, cfg config.MigrationsConfig) error {
+	status, err := Migrate(context.Background(), db, cfg, MigrationCommand{Name: "up"})
 	if err != nil {
-		log.Printf("⚠️  Warning: Could not get migration version: %v", err)
-	} else {
-		log.Printf("📋 Current migration version: %d (dirty: %t)", version, dirty)
+		return err
 	}
 
+	log.Printf("📋 Current migration version: %d (dirty: %t)", status.Version, status.Dirty)
 	return nil
 }
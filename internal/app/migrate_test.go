@@ -0,0 +1,237 @@
+package app_test
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+
+	"order-service/internal/app"
+	"order-service/internal/config"
+	"order-service/internal/migrations"
+	"order-service/internal/testdb"
+)
+
+// Заказ, который создает миграция 000001 и удаляет 000008
+const seedOrderUID = "b563feb7b2b84b6test"
+
+var migrationsConfig = config.MigrationsConfig{LockTimeout: time.Minute}
+
+func TestMigrationsUpDown(t *testing.T) {
+	db := testdb.Open(t, "migrate_updown_test")
+	latest := latestVersion(t)
+
+	migrateTo(t, db, app.MigrationCommand{Name: "up"}, latest)
+	if seedExists(t, db) {
+		t.Error("seed order exists after all migrations")
+	}
+
+	// Откат 000008 возвращает тестовый заказ со всеми строками
+	migrateTo(t, db, app.MigrationCommand{Name: "goto", N: 7}, 7)
+	if !seedExists(t, db) {
+		t.Error("seed order not restored by 000008 down")
+	}
+	migrateTo(t, db, app.MigrationCommand{Name: "up"}, latest)
+
+	// Откат всех миграций оставляет только таблицу версий
+	migrateTo(t, db, app.MigrationCommand{Name: "down", N: int(latest)}, 0)
+	var tables []string
+	err := db.Select(&tables, `
+        SELECT table_name FROM information_schema.tables
+        WHERE table_schema = current_schema() AND table_name <> 'schema_migrations'
+    `)
+	if err != nil {
+		t.Fatalf("list tables: %v", err)
+	}
+	if len(tables) != 0 {
+		t.Errorf("tables left after rolling back all migrations: %v", tables)
+	}
+
+	migrateTo(t, db, app.MigrationCommand{Name: "up"}, latest)
+}
+
+// Миграция 000007 переносит нарушающие ограничения строки в карантин,
+// после нее такие строки не вставляются
+func TestMigrationConstraints(t *testing.T) {
+	db := testdb.Open(t, "migrate_constraints_test")
+
+	migrateTo(t, db, app.MigrationCommand{Name: "goto", N: 6}, 6)
+	mustExec(t, db, `
+        INSERT INTO orders (order_uid, track_number, entry) VALUES ('legacy', 'TRACK', 'WBIL');
+        INSERT INTO payments (order_uid, transaction, amount) VALUES ('legacy', 'first', -100);
+        INSERT INTO payments (order_uid, transaction, amount) VALUES ('legacy', 'second', 100);
+        INSERT INTO items (order_uid, name, price) VALUES (NULL, 'orphaned', 10);
+        UPDATE orders SET locale = NULL WHERE order_uid = 'legacy';
+    `)
+
+	migrateTo(t, db, app.MigrationCommand{Name: "goto", N: 7}, 7)
+
+	var quarantined []string
+	err := db.Select(&quarantined, `
+        SELECT source_table || ':' || reason FROM quarantine ORDER BY source_table, reason
+    `)
+	if err != nil {
+		t.Fatalf("read quarantine: %v", err)
+	}
+	want := []string{"items:orphaned", "payments:duplicate", "payments:negative_amount"}
+	if strings.Join(quarantined, ",") != strings.Join(want, ",") {
+		t.Errorf("quarantined %v, want %v", quarantined, want)
+	}
+
+	var transaction string
+	var amount int
+	if err := db.QueryRow(`SELECT transaction, amount FROM payments WHERE order_uid = 'legacy'`).Scan(&transaction, &amount); err != nil {
+		t.Fatalf("read payment: %v", err)
+	}
+	if transaction != "first" || amount != 0 {
+		t.Errorf("payment %s with amount %d kept, want first with amount 0", transaction, amount)
+	}
+
+	var locale string
+	if err := db.QueryRow(`SELECT locale FROM orders WHERE order_uid = 'legacy'`).Scan(&locale); err != nil {
+		t.Errorf("NULL locale not replaced with default: %v", err)
+	}
+
+	mustExec(t, db, `
+        INSERT INTO orders (order_uid, track_number, entry) VALUES ('bare', 'TRACK', 'WBIL');
+        INSERT INTO deliveries (order_uid) VALUES ('legacy');
+    `)
+
+	tests := []struct {
+		name  string
+		query string
+		code  pq.ErrorCode
+	}{
+		{"negative payment amount", `INSERT INTO payments (order_uid, amount) VALUES ('bare', -1)`, "23514"},
+		{"negative delivery cost", `INSERT INTO payments (order_uid, delivery_cost) VALUES ('bare', -1)`, "23514"},
+		{"negative item price", `INSERT INTO items (order_uid, price) VALUES ('bare', -1)`, "23514"},
+		{"second payment", `INSERT INTO payments (order_uid) VALUES ('legacy')`, "23505"},
+		{"second delivery", `INSERT INTO deliveries (order_uid) VALUES ('legacy')`, "23505"},
+		{"item without order", `INSERT INTO items (order_uid, name) VALUES (NULL, 'orphaned')`, "23502"},
+		{"payment without order", `INSERT INTO payments (order_uid) VALUES (NULL)`, "23502"},
+		{"NULL amount", `INSERT INTO payments (order_uid, amount) VALUES ('bare', NULL)`, "23502"},
+		{"NULL order field", `UPDATE orders SET customer_id = NULL WHERE order_uid = 'legacy'`, "23502"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := db.Exec(tt.query)
+			var pqErr *pq.Error
+			if !errors.As(err, &pqErr) || pqErr.Code != tt.code {
+				t.Errorf("expected error %s, got %v", tt.code, err)
+			}
+		})
+	}
+}
+
+func TestParseMigrationCommand(t *testing.T) {
+	tests := []struct {
+		args    string
+		want    app.MigrationCommand
+		wantErr error
+	}{
+		{"up", app.MigrationCommand{Name: "up"}, nil},
+		{"version", app.MigrationCommand{Name: "version"}, nil},
+		{"down", app.MigrationCommand{Name: "down", N: 1}, nil},
+		{"down 3", app.MigrationCommand{Name: "down", N: 3}, nil},
+		{"goto 1", app.MigrationCommand{Name: "goto", N: 1}, nil},
+		{"force -1", app.MigrationCommand{Name: "force", N: -1}, nil},
+		{"force 0", app.MigrationCommand{Name: "force", N: 0}, nil},
+		{"goto 0", app.MigrationCommand{}, app.ErrMigrationUsage},
+		{"goto -1", app.MigrationCommand{}, app.ErrMigrationUsage},
+		{"goto", app.MigrationCommand{}, app.ErrMigrationUsage},
+		{"up 1", app.MigrationCommand{}, app.ErrMigrationUsage},
+		{"", app.MigrationCommand{}, app.ErrMigrationUsage},
+		{"sideways", app.MigrationCommand{}, app.ErrMigrationUsage},
+		{"down 0", app.MigrationCommand{}, errInvalidArgument},
+		{"goto x", app.MigrationCommand{}, errInvalidArgument},
+		{"force -2", app.MigrationCommand{}, errInvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.args, func(t *testing.T) {
+			cmd, err := app.ParseMigrationCommand(strings.Fields(tt.args))
+			switch {
+			case tt.wantErr == errInvalidArgument:
+				if err == nil || errors.Is(err, app.ErrMigrationUsage) {
+					t.Errorf("expected invalid argument error, got %v", err)
+				}
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("expected %v, got %v", tt.wantErr, err)
+				}
+			case err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case cmd != tt.want:
+				t.Errorf("got %+v, want %+v", cmd, tt.want)
+			}
+		})
+	}
+}
+
+// errInvalidArgument - в TestParseMigrationCommand: ожидается ошибка аргумента, а не справка
+var errInvalidArgument = errors.New("invalid argument")
+
+// migrateTo выполняет команду миграций и проверяет версию схемы после нее
+func migrateTo(t *testing.T, db *sqlx.DB, cmd app.MigrationCommand, version uint) {
+	t.Helper()
+
+	status, err := app.Migrate(context.Background(), db, migrationsConfig, cmd)
+	if err != nil {
+		t.Fatalf("migrate %s %d: %v", cmd.Name, cmd.N, err)
+	}
+	if status.Version != version || status.Dirty {
+		t.Fatalf("migrate %s %d: version %d (dirty: %t), want %d",
+			cmd.Name, cmd.N, status.Version, status.Dirty, version)
+	}
+}
+
+// latestVersion возвращает номер последней встроенной миграции
+func latestVersion(t *testing.T) uint {
+	t.Helper()
+
+	files, err := fs.Glob(migrations.FS, "*.up.sql")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no embedded migrations: %v", err)
+	}
+
+	var latest uint
+	for _, file := range files {
+		prefix, _, _ := strings.Cut(file, "_")
+		version, err := strconv.ParseUint(prefix, 10, 64)
+		if err != nil {
+			t.Fatalf("invalid migration file name %s", file)
+		}
+		latest = max(latest, uint(version))
+	}
+	return latest
+}
+
+// seedExists сообщает, что тестовый заказ есть вместе с доставкой, платежом и товаром
+func seedExists(t *testing.T, db *sqlx.DB) bool {
+	t.Helper()
+
+	var rows int
+	err := db.Get(&rows, `
+        SELECT (SELECT count(*) FROM orders WHERE order_uid = $1)
+             + (SELECT count(*) FROM deliveries WHERE order_uid = $1)
+             + (SELECT count(*) FROM payments WHERE order_uid = $1)
+             + (SELECT count(*) FROM items WHERE order_uid = $1)
+    `, seedOrderUID)
+	if err != nil {
+		t.Fatalf("read seed order: %v", err)
+	}
+	return rows == 4
+}
+
+func mustExec(t *testing.T, db *sqlx.DB, query string) {
+	t.Helper()
+
+	if _, err := db.Exec(query); err != nil {
+		t.Fatalf("exec: %v", err)
+	}
+}
@@ -62,6 +62,40 @@ func (c *MemoryCache) Size() int {
 	return len(c.orders)
 }
 
+// Clear удаляет все заказы из кеша и возвращает их количество
+func (c *MemoryCache) Clear() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	n := len(c.orders)
+	c.orders = make(map[string]*models.Order)
+	return n
+}
+
+// Delete удаляет заказ из кеша. Возвращает false, если заказа в кеше не было
+func (c *MemoryCache) Delete(orderUID string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	_, exists := c.orders[orderUID]
+	delete(c.orders, orderUID)
+	return exists
+}
+
+// Snapshot возвращает копию содержимого кеша без учета в метриках обращений.
+// Заказы в кеше не изменяются, а заменяются целиком, поэтому их можно читать без блокировки;
+// изменять их нельзя
+func (c *MemoryCache) Snapshot() map[string]*models.Order {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	snapshot := make(map[string]*models.Order, len(c.orders))
+	for uid, order := range c.orders {
+		snapshot[uid] = order
+	}
+	return snapshot
+}
+
 func (c *MemoryCache) GetMetrics() interfaces.CacheMetrics {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
@@ -0,0 +1,233 @@
+package codec
+
+import (
+	"context"
+	_ "embed"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hamba/avro/v2"
+
+	"order-service/internal/interfaces"
+	"order-service/internal/models"
+)
+
+// Заголовок Confluent Schema Registry: нулевой байт и id схемы (big-endian)
+const (
+	registryMagicByte  = 0
+	registryHeaderSize = 5
+)
+
+// Таймаут запроса схемы в реестре
+const registryTimeout = 5 * time.Second
+
+//go:embed order.avsc
+var orderSchemaJSON string
+
+// Схема заказа, которой читаются все сообщения Avro
+var orderSchema = avro.MustParse(orderSchemaJSON)
+
+// Структуры для Avro повторяют models.Order: теги avro не хочется тащить в модели
+type avroOrder struct {
+	OrderUID          string       `avro:"order_uid"`
+	TrackNumber       string       `avro:"track_number"`
+	Entry             string       `avro:"entry"`
+	Delivery          avroDelivery `avro:"delivery"`
+	Payment           avroPayment  `avro:"payment"`
+	Items             []avroItem   `avro:"items"`
+	Locale            string       `avro:"locale"`
+	InternalSignature string       `avro:"internal_signature"`
+	CustomerID        string       `avro:"customer_id"`
+	DeliveryService   string       `avro:"delivery_service"`
+	Shardkey          string       `avro:"shardkey"`
+	SmID              int          `avro:"sm_id"`
+	DateCreated       time.Time    `avro:"date_created"`
+	OofShard          string       `avro:"oof_shard"`
+}
+
+type avroDelivery struct {
+	Name    string `avro:"name"`
+	Phone   string `avro:"phone"`
+	Zip     string `avro:"zip"`
+	City    string `avro:"city"`
+	Address string `avro:"address"`
+	Region  string `avro:"region"`
+	Email   string `avro:"email"`
+}
+
+type avroPayment struct {
+	Transaction  string `avro:"transaction"`
+	RequestID    string `avro:"request_id"`
+	Currency     string `avro:"currency"`
+	Provider     string `avro:"provider"`
+	Amount       int    `avro:"amount"`
+	PaymentDt    int64  `avro:"payment_dt"`
+	Bank         string `avro:"bank"`
+	DeliveryCost int    `avro:"delivery_cost"`
+	GoodsTotal   int    `avro:"goods_total"`
+	CustomFee    int    `avro:"custom_fee"`
+}
+
+type avroItem struct {
+	ChrtID      int    `avro:"chrt_id"`
+	TrackNumber string `avro:"track_number"`
+	Price       int    `avro:"price"`
+	Rid         string `avro:"rid"`
+	Name        string `avro:"name"`
+	Sale        int    `avro:"sale"`
+	Size        string `avro:"size"`
+	TotalPrice  int    `avro:"total_price"`
+	NmID        int    `avro:"nm_id"`
+	Brand       string `avro:"brand"`
+	Status      int    `avro:"status"`
+}
+
+// avroCodec декодирует заказы Avro. Сообщение с заголовком реестра читается схемой
+// продюсера из реестра, сведенной со схемой заказа по правилам разрешения схем Avro
+type avroCodec struct {
+	registry interfaces.SchemaRegistry
+
+	mu      sync.RWMutex
+	schemas map[int]avro.Schema // сведенные схемы продюсеров по id
+}
+
+func newAvroCodec(registry interfaces.SchemaRegistry) *avroCodec {
+	return &avroCodec{
+		registry: registry,
+		schemas:  make(map[int]avro.Schema),
+	}
+}
+
+func (c *avroCodec) decode(data []byte) (*models.Order, error) {
+	schema := orderSchema
+	if c.registry != nil && len(data) > 0 && data[0] == registryMagicByte {
+		if len(data) < registryHeaderSize {
+			return nil, errors.New("truncated schema registry header")
+		}
+
+		id := int(binary.BigEndian.Uint32(data[1:registryHeaderSize]))
+		resolved, err := c.resolvedSchema(id)
+		if err != nil {
+			return nil, err
+		}
+		schema, data = resolved, data[registryHeaderSize:]
+	}
+
+	var v avroOrder
+	if err := avro.Unmarshal(schema, data, &v); err != nil {
+		return nil, fmt.Errorf("invalid avro order: %w", err)
+	}
+
+	return v.toModel(), nil
+}
+
+func (c *avroCodec) encode(order *models.Order) ([]byte, error) {
+	return avro.Marshal(orderSchema, fromModel(order))
+}
+
+// resolvedSchema возвращает схему для чтения сообщений продюсера со схемой id: схема
+// продюсера из реестра, сведенная со схемой заказа. Поля, которых нет у продюсера,
+// получают значения по умолчанию, лишние поля пропускаются, типы расширяются (int -> long).
+// Несовместимая схема продюсера - ошибка
+func (c *avroCodec) resolvedSchema(id int) (avro.Schema, error) {
+	c.mu.RLock()
+	schema, ok := c.schemas[id]
+	c.mu.RUnlock()
+	if ok {
+		return schema, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), registryTimeout)
+	defer cancel()
+
+	raw, err := c.registry.Schema(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch schema %d: %w", id, err)
+	}
+
+	// Отдельный кеш, чтобы схема продюсера не подменила именованные типы схемы заказа
+	writer, err := avro.ParseWithCache(raw, "", &avro.SchemaCache{})
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema %d: %w", id, err)
+	}
+	schema, err = avro.NewSchemaCompatibility().Resolve(orderSchema, writer)
+	if err != nil {
+		return nil, fmt.Errorf("schema %d is incompatible with order schema: %w", id, err)
+	}
+
+	c.mu.Lock()
+	c.schemas[id] = schema
+	c.mu.Unlock()
+
+	return schema, nil
+}
+
+func (v *avroOrder) toModel() *models.Order {
+	order := &models.Order{
+		OrderUID:    v.OrderUID,
+		TrackNumber: v.TrackNumber,
+		Entry:       v.Entry,
+		Delivery:    models.Delivery(v.Delivery),
+		Payment: models.Payment{
+			Transaction:  v.Payment.Transaction,
+			RequestID:    v.Payment.RequestID,
+			Currency:     v.Payment.Currency,
+			Provider:     v.Payment.Provider,
+			Amount:       v.Payment.Amount,
+			PaymentDt:    v.Payment.PaymentDt,
+			Bank:         v.Payment.Bank,
+			DeliveryCost: v.Payment.DeliveryCost,
+			GoodsTotal:   v.Payment.GoodsTotal,
+			CustomFee:    v.Payment.CustomFee,
+		},
+		Locale:            v.Locale,
+		InternalSignature: v.InternalSignature,
+		CustomerID:        v.CustomerID,
+		DeliveryService:   v.DeliveryService,
+		Shardkey:          v.Shardkey,
+		SmID:              v.SmID,
+		DateCreated:       v.DateCreated,
+		OofShard:          v.OofShard,
+	}
+	for _, item := range v.Items {
+		order.Items = append(order.Items, models.Item(item))
+	}
+	return order
+}
+
+func fromModel(order *models.Order) *avroOrder {
+	v := &avroOrder{
+		OrderUID:    order.OrderUID,
+		TrackNumber: order.TrackNumber,
+		Entry:       order.Entry,
+		Delivery:    avroDelivery(order.Delivery),
+		Payment: avroPayment{
+			Transaction:  order.Payment.Transaction,
+			RequestID:    order.Payment.RequestID,
+			Currency:     order.Payment.Currency,
+			Provider:     order.Payment.Provider,
+			Amount:       order.Payment.Amount,
+			PaymentDt:    order.Payment.PaymentDt,
+			Bank:         order.Payment.Bank,
+			DeliveryCost: order.Payment.DeliveryCost,
+			GoodsTotal:   order.Payment.GoodsTotal,
+			CustomFee:    order.Payment.CustomFee,
+		},
+		Items:             make([]avroItem, 0, len(order.Items)),
+		Locale:            order.Locale,
+		InternalSignature: order.InternalSignature,
+		CustomerID:        order.CustomerID,
+		DeliveryService:   order.DeliveryService,
+		Shardkey:          order.Shardkey,
+		SmID:              order.SmID,
+		DateCreated:       order.DateCreated,
+		OofShard:          order.OofShard,
+	}
+	for _, item := range order.Items {
+		v.Items = append(v.Items, avroItem(item))
+	}
+	return v
+}
@@ -0,0 +1,134 @@
+package codec_test
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hamba/avro/v2"
+
+	"order-service/internal/codec"
+)
+
+// Схемы продюсеров в testdata/registry:
+// 1 - старая версия схемы заказа: нет полей со значениями по умолчанию, суммы int, лишнее поле comment;
+// 2 - несовместимая схема: sm_id - строка
+const (
+	oldWriterSchemaID    = 1
+	incompatibleSchemaID = 2
+)
+
+func TestAvroDecodeOldWriterSchema(t *testing.T) {
+	created := time.Date(2021, 11, 26, 6, 22, 19, 0, time.UTC)
+	data := encodeWithSchema(t, oldWriterSchemaID, map[string]any{
+		"order_uid":    "b563feb7b2b84b6test",
+		"track_number": "WBILMTESTTRACK",
+		"entry":        "WBIL",
+		"comment":      "legacy field",
+		"delivery": map[string]any{
+			"name": "Test Testov", "phone": "+9720000000", "zip": "2639809", "city": "Kiryat Mozkin",
+			"address": "Ploshad Mira 15", "region": "Kraiot", "email": "test@gmail.com",
+		},
+		"payment": map[string]any{
+			"transaction": "b563feb7b2b84b6test", "currency": "USD", "provider": "wbpay",
+			"amount": 1817, "payment_dt": int64(1637907727), "bank": "alpha",
+			"delivery_cost": 1500, "goods_total": 317,
+		},
+		"items": []any{
+			map[string]any{
+				"chrt_id": int64(9934930), "track_number": "WBILMTESTTRACK", "price": int64(453),
+				"rid": "ab4219087a764ae0btest", "name": "Mascaras", "sale": 30, "size": "0",
+				"total_price": int64(317), "nm_id": int64(2389212), "brand": "Vivienne Sabo", "status": 202,
+			},
+		},
+		"locale":           "en",
+		"customer_id":      "test",
+		"delivery_service": "meest",
+		"shardkey":         "9",
+		"sm_id":            99,
+		"date_created":     created,
+		"oof_shard":        "1",
+	})
+
+	order, err := newRegistryCodec().Decode(codec.ContentTypeAvro, data)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+
+	if order.OrderUID != "b563feb7b2b84b6test" || order.TrackNumber != "WBILMTESTTRACK" {
+		t.Errorf("unexpected order identity: %q %q", order.OrderUID, order.TrackNumber)
+	}
+	if order.Payment.Amount != 1817 || order.Payment.DeliveryCost != 1500 || order.Payment.GoodsTotal != 317 {
+		t.Errorf("amounts not promoted from int: %+v", order.Payment)
+	}
+	// Поля, которых нет в схеме продюсера, получают значения по умолчанию схемы заказа
+	if order.Payment.RequestID != "" || order.Payment.CustomFee != 0 || order.InternalSignature != "" {
+		t.Errorf("missing fields not defaulted: request_id=%q custom_fee=%d internal_signature=%q",
+			order.Payment.RequestID, order.Payment.CustomFee, order.InternalSignature)
+	}
+	if order.SmID != 99 || order.OofShard != "1" || !order.DateCreated.Equal(created) {
+		t.Errorf("fields after the skipped field decoded incorrectly: sm_id=%d oof_shard=%q date_created=%s",
+			order.SmID, order.OofShard, order.DateCreated)
+	}
+	if len(order.Items) != 1 || order.Items[0].Brand != "Vivienne Sabo" || order.Items[0].Status != 202 {
+		t.Errorf("unexpected items: %+v", order.Items)
+	}
+	if order.Delivery.Email != "test@gmail.com" {
+		t.Errorf("unexpected delivery: %+v", order.Delivery)
+	}
+}
+
+func TestAvroDecodeIncompatibleSchema(t *testing.T) {
+	// Данные не важны: схема отклоняется до декодирования
+	data := header(incompatibleSchemaID)
+
+	_, err := newRegistryCodec().Decode(codec.ContentTypeAvro, data)
+	if err == nil {
+		t.Fatal("expected incompatible schema to be rejected")
+	}
+	if !strings.Contains(err.Error(), "incompatible") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestAvroDecodeUnknownSchema(t *testing.T) {
+	_, err := newRegistryCodec().Decode(codec.ContentTypeAvro, header(42))
+	if err == nil {
+		t.Fatal("expected unknown schema id to fail")
+	}
+}
+
+func newRegistryCodec() *codec.Codec {
+	return codec.New(codec.NewFileRegistry("testdata/registry"))
+}
+
+// encodeWithSchema кодирует значение схемой продюсера id и добавляет заголовок реестра
+func encodeWithSchema(t *testing.T, id int, v any) []byte {
+	t.Helper()
+
+	raw, err := os.ReadFile(filepath.Join("testdata", "registry", strconv.Itoa(id)+".avsc"))
+	if err != nil {
+		t.Fatalf("read schema: %v", err)
+	}
+	schema, err := avro.ParseWithCache(string(raw), "", &avro.SchemaCache{})
+	if err != nil {
+		t.Fatalf("parse schema: %v", err)
+	}
+
+	data, err := avro.Marshal(schema, v)
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	return append(header(id), data...)
+}
+
+// header - заголовок Confluent Schema Registry: нулевой байт и id схемы
+func header(id int) []byte {
+	h := make([]byte, 5)
+	binary.BigEndian.PutUint32(h[1:], uint32(id))
+	return h
+}
@@ -0,0 +1,104 @@
+package codec
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"strings"
+
+	"order-service/internal/interfaces"
+	"order-service/internal/models"
+)
+
+// Content-type сообщений с заказами
+const (
+	ContentTypeJSON     = "application/json"
+	ContentTypeProtobuf = "application/x-protobuf"
+	ContentTypeAvro     = "application/avro"
+)
+
+// Синонимы content-type, которые встречаются у разных продюсеров
+var contentTypeAliases = map[string]string{
+	"":                                   ContentTypeJSON,
+	"json":                               ContentTypeJSON,
+	"text/json":                          ContentTypeJSON,
+	"protobuf":                           ContentTypeProtobuf,
+	"application/protobuf":               ContentTypeProtobuf,
+	"application/vnd.google.protobuf":    ContentTypeProtobuf,
+	"avro":                               ContentTypeAvro,
+	"avro/binary":                        ContentTypeAvro,
+	"application/vnd.apache.avro+binary": ContentTypeAvro,
+}
+
+// Проверка соответствия интерфейсу
+var _ interfaces.OrderCodec = (*Codec)(nil)
+
+// Codec выбирает формат заказа по content-type: JSON, Protobuf или Avro.
+// Схемы Avro, на которые ссылаются сообщения, запрашиваются в реестре схем
+type Codec struct {
+	avro *avroCodec
+}
+
+// New создает кодек. registry может быть nil - тогда сообщения Avro
+// должны быть закодированы встроенной схемой заказа без заголовка реестра
+func New(registry interfaces.SchemaRegistry) *Codec {
+	return &Codec{avro: newAvroCodec(registry)}
+}
+
+// Decode декодирует заказ. Пустой content-type означает JSON
+func (c *Codec) Decode(contentType string, data []byte) (*models.Order, error) {
+	normalized, err := Normalize(contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	switch normalized {
+	case ContentTypeProtobuf:
+		return decodeProtobuf(data)
+	case ContentTypeAvro:
+		return c.avro.decode(data)
+	default:
+		var order models.Order
+		if err := json.Unmarshal(data, &order); err != nil {
+			return nil, err
+		}
+		return &order, nil
+	}
+}
+
+// Encode кодирует заказ. Avro кодируется встроенной схемой без заголовка реестра
+func (c *Codec) Encode(contentType string, order *models.Order) ([]byte, error) {
+	normalized, err := Normalize(contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	switch normalized {
+	case ContentTypeProtobuf:
+		return encodeProtobuf(order), nil
+	case ContentTypeAvro:
+		return c.avro.encode(order)
+	default:
+		return json.Marshal(order)
+	}
+}
+
+// Normalize приводит content-type к одной из констант ContentType*
+func Normalize(contentType string) (string, error) {
+	mediaType := strings.ToLower(strings.TrimSpace(contentType))
+	if mediaType != "" {
+		if parsed, _, err := mime.ParseMediaType(mediaType); err == nil {
+			mediaType = parsed
+		}
+	}
+
+	if alias, ok := contentTypeAliases[mediaType]; ok {
+		return alias, nil
+	}
+
+	switch mediaType {
+	case ContentTypeJSON, ContentTypeProtobuf, ContentTypeAvro:
+		return mediaType, nil
+	}
+	return "", fmt.Errorf("unsupported content type %q", contentType)
+}
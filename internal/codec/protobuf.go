@@ -0,0 +1,345 @@
+package codec
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"order-service/internal/models"
+)
+
+// Заказ в Protobuf читается и записывается напрямую по номерам полей из order.proto,
+// без сгенерированного кода. Неизвестные поля пропускаются, как в сгенерированном коде
+
+// fieldFunc обрабатывает одно поле сообщения и возвращает число прочитанных байт значения
+type fieldFunc func(num protowire.Number, typ protowire.Type, data []byte) (int, error)
+
+// decodeProtobuf декодирует заказ. Заголовок Confluent Schema Registry
+// (нулевой байт, id схемы и индексы сообщения) пропускается
+func decodeProtobuf(data []byte) (*models.Order, error) {
+	data, err := stripProtobufFraming(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var order models.Order
+	err = parseMessage(data, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch num {
+		case 1:
+			return consumeString(typ, b, &order.OrderUID)
+		case 2:
+			return consumeString(typ, b, &order.TrackNumber)
+		case 3:
+			return consumeString(typ, b, &order.Entry)
+		case 4:
+			return consumeMessage(typ, b, func(m []byte) error { return parseDelivery(m, &order.Delivery) })
+		case 5:
+			return consumeMessage(typ, b, func(m []byte) error { return parsePayment(m, &order.Payment) })
+		case 6:
+			return consumeMessage(typ, b, func(m []byte) error {
+				var item models.Item
+				if err := parseItem(m, &item); err != nil {
+					return err
+				}
+				order.Items = append(order.Items, item)
+				return nil
+			})
+		case 7:
+			return consumeString(typ, b, &order.Locale)
+		case 8:
+			return consumeString(typ, b, &order.InternalSignature)
+		case 9:
+			return consumeString(typ, b, &order.CustomerID)
+		case 10:
+			return consumeString(typ, b, &order.DeliveryService)
+		case 11:
+			return consumeString(typ, b, &order.Shardkey)
+		case 12:
+			return consumeInt(typ, b, &order.SmID)
+		case 13:
+			return consumeMessage(typ, b, func(m []byte) error { return parseTimestamp(m, &order.DateCreated) })
+		case 14:
+			return consumeString(typ, b, &order.OofShard)
+		}
+		return skipField(num, typ, b)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid protobuf order: %w", err)
+	}
+
+	return &order, nil
+}
+
+func parseDelivery(data []byte, d *models.Delivery) error {
+	fields := []*string{&d.Name, &d.Phone, &d.Zip, &d.City, &d.Address, &d.Region, &d.Email}
+	return parseMessage(data, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		if num >= 1 && int(num) <= len(fields) {
+			return consumeString(typ, b, fields[num-1])
+		}
+		return skipField(num, typ, b)
+	})
+}
+
+func parsePayment(data []byte, p *models.Payment) error {
+	return parseMessage(data, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch num {
+		case 1:
+			return consumeString(typ, b, &p.Transaction)
+		case 2:
+			return consumeString(typ, b, &p.RequestID)
+		case 3:
+			return consumeString(typ, b, &p.Currency)
+		case 4:
+			return consumeString(typ, b, &p.Provider)
+		case 5:
+			return consumeInt(typ, b, &p.Amount)
+		case 6:
+			return consumeInt64(typ, b, &p.PaymentDt)
+		case 7:
+			return consumeString(typ, b, &p.Bank)
+		case 8:
+			return consumeInt(typ, b, &p.DeliveryCost)
+		case 9:
+			return consumeInt(typ, b, &p.GoodsTotal)
+		case 10:
+			return consumeInt(typ, b, &p.CustomFee)
+		}
+		return skipField(num, typ, b)
+	})
+}
+
+func parseItem(data []byte, item *models.Item) error {
+	return parseMessage(data, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch num {
+		case 1:
+			return consumeInt(typ, b, &item.ChrtID)
+		case 2:
+			return consumeString(typ, b, &item.TrackNumber)
+		case 3:
+			return consumeInt(typ, b, &item.Price)
+		case 4:
+			return consumeString(typ, b, &item.Rid)
+		case 5:
+			return consumeString(typ, b, &item.Name)
+		case 6:
+			return consumeInt(typ, b, &item.Sale)
+		case 7:
+			return consumeString(typ, b, &item.Size)
+		case 8:
+			return consumeInt(typ, b, &item.TotalPrice)
+		case 9:
+			return consumeInt(typ, b, &item.NmID)
+		case 10:
+			return consumeString(typ, b, &item.Brand)
+		case 11:
+			return consumeInt(typ, b, &item.Status)
+		}
+		return skipField(num, typ, b)
+	})
+}
+
+// parseTimestamp читает google.protobuf.Timestamp
+func parseTimestamp(data []byte, t *time.Time) error {
+	var seconds, nanos int64
+	err := parseMessage(data, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch num {
+		case 1:
+			return consumeInt64(typ, b, &seconds)
+		case 2:
+			return consumeInt64(typ, b, &nanos)
+		}
+		return skipField(num, typ, b)
+	})
+	if err != nil {
+		return err
+	}
+
+	*t = time.Unix(seconds, nanos).UTC()
+	return nil
+}
+
+// parseMessage перебирает поля сообщения
+func parseMessage(data []byte, field fieldFunc) error {
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+
+		m, err := field(num, typ, data)
+		if err != nil {
+			return fmt.Errorf("field %d: %w", num, err)
+		}
+		data = data[m:]
+	}
+	return nil
+}
+
+func consumeString(typ protowire.Type, data []byte, s *string) (int, error) {
+	if typ != protowire.BytesType {
+		return 0, errors.New("expected string")
+	}
+	v, n := protowire.ConsumeString(data)
+	if n < 0 {
+		return 0, protowire.ParseError(n)
+	}
+	*s = v
+	return n, nil
+}
+
+func consumeInt64(typ protowire.Type, data []byte, v *int64) (int, error) {
+	if typ != protowire.VarintType {
+		return 0, errors.New("expected varint")
+	}
+	x, n := protowire.ConsumeVarint(data)
+	if n < 0 {
+		return 0, protowire.ParseError(n)
+	}
+	*v = int64(x)
+	return n, nil
+}
+
+func consumeInt(typ protowire.Type, data []byte, v *int) (int, error) {
+	var x int64
+	n, err := consumeInt64(typ, data, &x)
+	*v = int(x)
+	return n, err
+}
+
+func consumeMessage(typ protowire.Type, data []byte, parse func([]byte) error) (int, error) {
+	if typ != protowire.BytesType {
+		return 0, errors.New("expected message")
+	}
+	m, n := protowire.ConsumeBytes(data)
+	if n < 0 {
+		return 0, protowire.ParseError(n)
+	}
+	return n, parse(m)
+}
+
+func skipField(num protowire.Number, typ protowire.Type, data []byte) (int, error) {
+	n := protowire.ConsumeFieldValue(num, typ, data)
+	if n < 0 {
+		return 0, protowire.ParseError(n)
+	}
+	return n, nil
+}
+
+// stripProtobufFraming убирает заголовок Confluent Schema Registry, если он есть.
+// Сообщение Protobuf не может начинаться с нулевого байта, поэтому заголовок определяется однозначно
+func stripProtobufFraming(data []byte) ([]byte, error) {
+	if len(data) == 0 || data[0] != registryMagicByte {
+		return data, nil
+	}
+	if len(data) < registryHeaderSize {
+		return nil, errors.New("truncated schema registry header")
+	}
+	data = data[registryHeaderSize:]
+
+	// Индексы сообщения в схеме: количество и сами индексы (zigzag varint)
+	count, n := protowire.ConsumeVarint(data)
+	if n < 0 {
+		return nil, protowire.ParseError(n)
+	}
+	data = data[n:]
+
+	for i := int64(0); i < protowire.DecodeZigZag(count); i++ {
+		_, n := protowire.ConsumeVarint(data)
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		data = data[n:]
+	}
+	return data, nil
+}
+
+// encodeProtobuf кодирует заказ по схеме order.proto
+func encodeProtobuf(order *models.Order) []byte {
+	var b []byte
+	b = appendString(b, 1, order.OrderUID)
+	b = appendString(b, 2, order.TrackNumber)
+	b = appendString(b, 3, order.Entry)
+	b = appendMessage(b, 4, encodeDelivery(&order.Delivery))
+	b = appendMessage(b, 5, encodePayment(&order.Payment))
+	for i := range order.Items {
+		b = appendMessage(b, 6, encodeItem(&order.Items[i]))
+	}
+	b = appendString(b, 7, order.Locale)
+	b = appendString(b, 8, order.InternalSignature)
+	b = appendString(b, 9, order.CustomerID)
+	b = appendString(b, 10, order.DeliveryService)
+	b = appendString(b, 11, order.Shardkey)
+	b = appendInt(b, 12, int64(order.SmID))
+	if !order.DateCreated.IsZero() {
+		var ts []byte
+		ts = appendInt(ts, 1, order.DateCreated.Unix())
+		ts = appendInt(ts, 2, int64(order.DateCreated.Nanosecond()))
+		b = appendMessage(b, 13, ts)
+	}
+	b = appendString(b, 14, order.OofShard)
+	return b
+}
+
+func encodeDelivery(d *models.Delivery) []byte {
+	var b []byte
+	for i, s := range []string{d.Name, d.Phone, d.Zip, d.City, d.Address, d.Region, d.Email} {
+		b = appendString(b, protowire.Number(i+1), s)
+	}
+	return b
+}
+
+func encodePayment(p *models.Payment) []byte {
+	var b []byte
+	b = appendString(b, 1, p.Transaction)
+	b = appendString(b, 2, p.RequestID)
+	b = appendString(b, 3, p.Currency)
+	b = appendString(b, 4, p.Provider)
+	b = appendInt(b, 5, int64(p.Amount))
+	b = appendInt(b, 6, p.PaymentDt)
+	b = appendString(b, 7, p.Bank)
+	b = appendInt(b, 8, int64(p.DeliveryCost))
+	b = appendInt(b, 9, int64(p.GoodsTotal))
+	b = appendInt(b, 10, int64(p.CustomFee))
+	return b
+}
+
+func encodeItem(item *models.Item) []byte {
+	var b []byte
+	b = appendInt(b, 1, int64(item.ChrtID))
+	b = appendString(b, 2, item.TrackNumber)
+	b = appendInt(b, 3, int64(item.Price))
+	b = appendString(b, 4, item.Rid)
+	b = appendString(b, 5, item.Name)
+	b = appendInt(b, 6, int64(item.Sale))
+	b = appendString(b, 7, item.Size)
+	b = appendInt(b, 8, int64(item.TotalPrice))
+	b = appendInt(b, 9, int64(item.NmID))
+	b = appendString(b, 10, item.Brand)
+	b = appendInt(b, 11, int64(item.Status))
+	return b
+}
+
+// Нулевые значения в proto3 не записываются
+func appendString(b []byte, num protowire.Number, s string) []byte {
+	if s == "" {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, s)
+}
+
+func appendInt(b []byte, num protowire.Number, v int64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, uint64(v))
+}
+
+func appendMessage(b []byte, num protowire.Number, m []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, m)
+}
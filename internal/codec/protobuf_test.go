@@ -0,0 +1,375 @@
+package codec
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	_ "google.golang.org/protobuf/types/known/timestamppb" // google/protobuf/timestamp.proto для order.proto
+
+	"order-service/internal/models"
+)
+
+var update = flag.Bool("update", false, "перезаписать эталонные файлы в testdata")
+
+const (
+	goldenText   = "testdata/order.txtpb"
+	goldenBinary = "testdata/order.binpb"
+)
+
+// goldenOrder - заказ из testdata/order.txtpb
+func goldenOrder() *models.Order {
+	return &models.Order{
+		OrderUID:    "b563feb7b2b84b6test",
+		TrackNumber: "WBILMTESTTRACK",
+		Entry:       "WBIL",
+		Delivery: models.Delivery{
+			Name: "Test Testov", Phone: "+9720000000", Zip: "2639809", City: "Kiryat Mozkin",
+			Address: "Ploshad Mira 15", Region: "Kraiot", Email: "test@gmail.com",
+		},
+		Payment: models.Payment{
+			Transaction: "b563feb7b2b84b6test", Currency: "USD", Provider: "wbpay", Amount: 1817,
+			PaymentDt: 1637907727, Bank: "alpha", DeliveryCost: 1500, GoodsTotal: 317,
+		},
+		Items: []models.Item{
+			{ChrtID: 9934930, TrackNumber: "WBILMTESTTRACK", Price: 453, Rid: "ab4219087a764ae0btest",
+				Name: "Mascaras", Sale: 30, Size: "0", TotalPrice: 317, NmID: 2389212,
+				Brand: "Vivienne Sabo", Status: 202},
+			{ChrtID: 9934931, TrackNumber: "WBILMTESTTRACK", Price: 1000, Rid: "ab4219087a764ae0btest2",
+				Name: "Lipstick", TotalPrice: 1000, Brand: "Vivienne Sabo", Status: 202},
+		},
+		Locale:          "en",
+		CustomerID:      "test",
+		DeliveryService: "meest",
+		Shardkey:        "9",
+		SmID:            99,
+		DateCreated:     time.Date(2021, 11, 26, 6, 22, 19, 123000000, time.UTC),
+		OofShard:        "1",
+	}
+}
+
+// Эталон закодирован по order.proto библиотекой Protobuf: кодек должен читать его
+// и записывать заказ теми же байтами
+func TestProtobufGolden(t *testing.T) {
+	text, err := os.ReadFile(goldenText)
+	if err != nil {
+		t.Fatalf("read %s: %v", goldenText, err)
+	}
+	msg := dynamicpb.NewMessage(orderDescriptor(t))
+	if err := prototext.Unmarshal(text, msg); err != nil {
+		t.Fatalf("parse %s: %v", goldenText, err)
+	}
+	reference, err := proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+	if err != nil {
+		t.Fatalf("marshal reference: %v", err)
+	}
+
+	if *update {
+		if err := os.WriteFile(goldenBinary, reference, 0o644); err != nil {
+			t.Fatalf("write %s: %v", goldenBinary, err)
+		}
+	}
+	golden, err := os.ReadFile(goldenBinary)
+	if err != nil {
+		t.Fatalf("read %s: %v", goldenBinary, err)
+	}
+	if !bytes.Equal(golden, reference) {
+		t.Fatalf("%s is out of date with %s, regenerate it with -update", goldenBinary, goldenText)
+	}
+
+	order, err := decodeProtobuf(golden)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if want := goldenOrder(); !reflect.DeepEqual(order, want) {
+		t.Errorf("decoded order differs:\n got %+v\nwant %+v", order, want)
+	}
+
+	if encoded := encodeProtobuf(goldenOrder()); !bytes.Equal(encoded, golden) {
+		t.Errorf("encoded order differs from golden:\n got %x\nwant %x", encoded, golden)
+	}
+}
+
+func TestProtobufRoundTrip(t *testing.T) {
+	tests := map[string]*models.Order{
+		"full order":  goldenOrder(),
+		"empty order": {},
+		"negative values": {
+			OrderUID: "negative",
+			SmID:     -1,
+			Payment:  models.Payment{Amount: -1817, PaymentDt: -1, CustomFee: -5},
+			Items:    []models.Item{{ChrtID: -9934930, Sale: -30, Status: -202}},
+		},
+		"before unix epoch": {
+			OrderUID:    "old",
+			DateCreated: time.Date(1960, 1, 2, 3, 4, 5, 6, time.UTC),
+		},
+	}
+	for name, order := range tests {
+		t.Run(name, func(t *testing.T) {
+			decoded, err := decodeProtobuf(encodeProtobuf(order))
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if !reflect.DeepEqual(decoded, order) {
+				t.Errorf("round trip differs:\n got %+v\nwant %+v", decoded, order)
+			}
+		})
+	}
+}
+
+// Отрицательный int32 в Protobuf кодируется 10-байтным varint с расширением знака
+func TestProtobufNegativeInt32(t *testing.T) {
+	desc := orderDescriptor(t)
+	msg := dynamicpb.NewMessage(desc)
+	msg.Set(desc.Fields().ByName("sm_id"), protoreflect.ValueOfInt32(-5))
+	reference, err := proto.Marshal(msg)
+	if err != nil {
+		t.Fatalf("marshal reference: %v", err)
+	}
+
+	if len(reference) != 1+10 {
+		t.Fatalf("reference encoding has %d bytes, want tag and 10-byte varint", len(reference))
+	}
+
+	order, err := decodeProtobuf(reference)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if order.SmID != -5 {
+		t.Errorf("sm_id = %d, want -5", order.SmID)
+	}
+
+	encoded := encodeProtobuf(&models.Order{SmID: -5})
+	// Вложенные доставка и платеж записываются всегда, sm_id - после них
+	if !bytes.HasSuffix(encoded, reference) {
+		t.Errorf("encoded sm_id %x, want %x", encoded, reference)
+	}
+}
+
+func TestProtobufTimestamp(t *testing.T) {
+	desc := orderDescriptor(t)
+	created := desc.Fields().ByName("date_created")
+
+	msg := dynamicpb.NewMessage(desc)
+	ts := msg.Mutable(created).Message()
+	ts.Set(ts.Descriptor().Fields().ByName("seconds"), protoreflect.ValueOfInt64(1637907739))
+	ts.Set(ts.Descriptor().Fields().ByName("nanos"), protoreflect.ValueOfInt32(999999999))
+	reference, err := proto.Marshal(msg)
+	if err != nil {
+		t.Fatalf("marshal reference: %v", err)
+	}
+
+	order, err := decodeProtobuf(reference)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	want := time.Unix(1637907739, 999999999).UTC()
+	if !order.DateCreated.Equal(want) || order.DateCreated.Location() != time.UTC {
+		t.Errorf("date_created = %s, want %s", order.DateCreated, want)
+	}
+
+	// Нулевое время не записывается, как незаданное поле в proto3
+	decoded := dynamicpb.NewMessage(desc)
+	if err := proto.Unmarshal(encodeProtobuf(&models.Order{OrderUID: "no-date"}), decoded); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if decoded.Has(created) {
+		t.Error("zero date_created was encoded")
+	}
+}
+
+// Поля из более новой версии схемы пропускаются на любом уровне вложенности
+func TestProtobufSkipsUnknownFields(t *testing.T) {
+	unknown := func(b []byte) []byte {
+		b = protowire.AppendTag(b, 100, protowire.VarintType)
+		b = protowire.AppendVarint(b, 42)
+		b = protowire.AppendTag(b, 101, protowire.Fixed32Type)
+		b = protowire.AppendFixed32(b, 42)
+		b = protowire.AppendTag(b, 102, protowire.Fixed64Type)
+		b = protowire.AppendFixed64(b, 42)
+		b = protowire.AppendTag(b, 103, protowire.BytesType)
+		b = protowire.AppendString(b, "future field")
+		b = protowire.AppendTag(b, 104, protowire.StartGroupType)
+		b = protowire.AppendTag(b, 1, protowire.VarintType)
+		b = protowire.AppendVarint(b, 1)
+		return protowire.AppendTag(b, 104, protowire.EndGroupType)
+	}
+
+	order := goldenOrder()
+	data := unknown(nil)
+	data = appendString(data, 1, order.OrderUID)
+	data = appendMessage(data, 4, unknown(encodeDelivery(&order.Delivery)))
+	data = appendMessage(data, 5, unknown(encodePayment(&order.Payment)))
+	for i := range order.Items {
+		data = appendMessage(data, 6, unknown(encodeItem(&order.Items[i])))
+	}
+	var ts []byte
+	ts = appendInt(ts, 1, order.DateCreated.Unix())
+	ts = appendInt(ts, 2, int64(order.DateCreated.Nanosecond()))
+	data = appendMessage(data, 13, unknown(ts))
+	data = unknown(data)
+
+	decoded, err := decodeProtobuf(data)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if decoded.OrderUID != order.OrderUID || !reflect.DeepEqual(decoded.Delivery, order.Delivery) ||
+		!reflect.DeepEqual(decoded.Payment, order.Payment) || !reflect.DeepEqual(decoded.Items, order.Items) ||
+		!decoded.DateCreated.Equal(order.DateCreated) {
+		t.Errorf("unknown fields changed the decoded order: %+v", decoded)
+	}
+}
+
+func TestStripProtobufFraming(t *testing.T) {
+	message := encodeProtobuf(goldenOrder())
+	header := []byte{registryMagicByte, 0, 0, 0, 7}
+
+	tests := []struct {
+		name    string
+		data    []byte
+		want    []byte
+		wantErr bool
+	}{
+		{"no framing", message, message, false},
+		{"empty", nil, nil, false},
+		{"first message", concat(header, []byte{0}, message), message, false},
+		{"nested message indexes", concat(header, []byte{4, 2, 6}, message), message, false},
+		{"truncated header", []byte{registryMagicByte, 0, 0}, nil, true},
+		{"missing index count", header, nil, true},
+		{"unterminated index count", concat(header, []byte{0x80}), nil, true},
+		{"missing indexes", concat(header, []byte{6, 2}), nil, true},
+		{"unterminated index", concat(header, []byte{2, 0xff}), nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := stripProtobufFraming(tt.data)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error, got %x", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("got %x, want %x", got, tt.want)
+			}
+		})
+	}
+}
+
+// Обрезанное или поврежденное сообщение возвращает ошибку, а не панику
+func TestProtobufMalformedInput(t *testing.T) {
+	framed := concat([]byte{registryMagicByte, 0, 0, 0, 7, 0}, encodeProtobuf(goldenOrder()))
+
+	// Любой префикс либо декодируется, либо дает ошибку
+	for i := range framed {
+		decodeProtobuf(framed[:i])
+	}
+
+	for name, data := range map[string][]byte{
+		"truncated string":     {0x0a, 0x05, 'a', 'b'},
+		"truncated varint":     {0x60, 0x80},
+		"wrong wire type":      {0x0d, 0, 0, 0, 0},
+		"invalid field":        {0x00, 0x01},
+		"truncated submessage": {0x22, 0x03, 0x0a, 0x05, 'a'},
+	} {
+		if _, err := decodeProtobuf(data); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func concat(parts ...[]byte) []byte {
+	return bytes.Join(parts, nil)
+}
+
+var (
+	protoMessage = regexp.MustCompile(`^message (\w+) \{$`)
+	protoField   = regexp.MustCompile(`^(repeated )?([\w.]+) (\w+) = (\d+);$`)
+)
+
+// orderDescriptor строит описание сообщения Order из order.proto, чтобы эталонные
+// данные кодировались библиотекой Protobuf по той же схеме, что читает кодек
+func orderDescriptor(t *testing.T) protoreflect.MessageDescriptor {
+	t.Helper()
+
+	src, err := os.ReadFile("order.proto")
+	if err != nil {
+		t.Fatalf("read order.proto: %v", err)
+	}
+
+	file := &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("order.proto"),
+		Package:    proto.String("orders.v1"),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{"google/protobuf/timestamp.proto"},
+	}
+
+	var message *descriptorpb.DescriptorProto
+	for _, line := range strings.Split(string(src), "\n") {
+		line = strings.TrimSpace(line)
+		if m := protoMessage.FindStringSubmatch(line); m != nil {
+			message = &descriptorpb.DescriptorProto{Name: proto.String(m[1])}
+			file.MessageType = append(file.MessageType, message)
+			continue
+		}
+
+		m := protoField.FindStringSubmatch(line)
+		if m == nil || message == nil {
+			continue
+		}
+		number, _ := strconv.Atoi(m[4])
+		field := &descriptorpb.FieldDescriptorProto{
+			Name:   proto.String(m[3]),
+			Number: proto.Int32(int32(number)),
+			Label:  descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+		}
+		if m[1] != "" {
+			field.Label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+		}
+
+		switch m[2] {
+		case "string":
+			field.Type = descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum()
+		case "int32":
+			field.Type = descriptorpb.FieldDescriptorProto_TYPE_INT32.Enum()
+		case "int64":
+			field.Type = descriptorpb.FieldDescriptorProto_TYPE_INT64.Enum()
+		default:
+			typeName := "." + m[2]
+			if !strings.Contains(m[2], ".") {
+				typeName = ".orders.v1." + m[2]
+			}
+			field.Type = descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum()
+			field.TypeName = proto.String(typeName)
+		}
+		message.Field = append(message.Field, field)
+	}
+
+	fd, err := protodesc.NewFile(file, protoregistry.GlobalFiles)
+	if err != nil {
+		t.Fatalf("build descriptor from order.proto: %v", err)
+	}
+	desc := fd.Messages().ByName("Order")
+	if desc == nil {
+		t.Fatal("message Order not found in order.proto")
+	}
+	return desc
+}
@@ -0,0 +1,106 @@
+package codec
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	apperrors "order-service/internal/errors" // кастомные ошибки
+	"order-service/internal/interfaces"
+)
+
+// Проверка соответствия интерфейсу
+var (
+	_ interfaces.SchemaRegistry = (*RegistryClient)(nil)
+	_ interfaces.SchemaRegistry = (*FileRegistry)(nil)
+)
+
+// RegistryClient - клиент Confluent-совместимого реестра схем.
+// Схема по id неизменна, поэтому полученные схемы кешируются навсегда
+type RegistryClient struct {
+	baseURL string
+	client  *http.Client
+
+	mu      sync.RWMutex
+	schemas map[int]string
+}
+
+func NewRegistryClient(baseURL string) *RegistryClient {
+	return &RegistryClient{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  &http.Client{Timeout: 10 * time.Second},
+		schemas: make(map[int]string),
+	}
+}
+
+// Schema возвращает схему по id. Недоступность реестра - временная ошибка
+func (r *RegistryClient) Schema(ctx context.Context, id int) (string, error) {
+	r.mu.RLock()
+	schema, ok := r.schemas[id]
+	r.mu.RUnlock()
+	if ok {
+		return schema, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.baseURL+"/schemas/ids/"+strconv.Itoa(id), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "application/vnd.schemaregistry.v1+json")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("%w: schema registry request failed: %v", apperrors.ErrTemporary, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= http.StatusInternalServerError:
+		return "", fmt.Errorf("%w: schema registry responded %s", apperrors.ErrTemporary, resp.Status)
+	case resp.StatusCode != http.StatusOK:
+		return "", fmt.Errorf("schema registry responded %s", resp.Status)
+	}
+
+	var body struct {
+		Schema string `json:"schema"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("invalid schema registry response: %w", err)
+	}
+
+	r.mu.Lock()
+	r.schemas[id] = body.Schema
+	r.mu.Unlock()
+
+	return body.Schema, nil
+}
+
+// FileRegistry - реестр схем в каталоге: схема с id N лежит в файле <dir>/N.avsc.
+// Заменяет реестр схем в тестах и локальной разработке
+type FileRegistry struct {
+	dir string
+}
+
+func NewFileRegistry(dir string) *FileRegistry {
+	return &FileRegistry{dir: dir}
+}
+
+func (r *FileRegistry) Schema(_ context.Context, id int) (string, error) {
+	data, err := os.ReadFile(filepath.Join(r.dir, strconv.Itoa(id)+".avsc"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", fmt.Errorf("schema %d not found in %s", id, r.dir)
+	}
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
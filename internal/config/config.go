@@ -1,11 +1,24 @@
 package config
 
-import "os"
+import (
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
 
 type Config struct {
-	Database DatabaseConfig
-	Kafka    KafkaConfig
-	Server   ServerConfig
+	Env            string // профиль окружения: dev, test или production
+	Database       DatabaseConfig
+	Migrations     MigrationsConfig
+	Broker         BrokerConfig
+	Kafka          KafkaConfig
+	SchemaRegistry SchemaRegistryConfig
+	Outbox         OutboxConfig
+	Webhook        WebhookConfig
+	Reconcile      ReconcileConfig
+	Server         ServerConfig
 }
 
 type DatabaseConfig struct {
@@ -15,20 +28,172 @@ type DatabaseConfig struct {
 	Password string
 	DBName   string
 	SSLMode  string
+
+	// QueryTimeout ограничивает один вызов репозитория заказов (0 - без ограничения).
+	// Выгрузка, сверка и проверка целостности ограничены только контекстом вызова
+	QueryTimeout time.Duration
+}
+
+// MigrationsConfig - применение миграций схемы при старте сервиса. Миграции выполняются
+// под advisory-блокировкой Postgres: одновременно запущенные реплики применяют их по очереди
+type MigrationsConfig struct {
+	OnStartup   bool
+	Required    bool          // не запускать сервис, если миграции не применились
+	LockTimeout time.Duration // ожидание блокировки, пока миграции применяет другая реплика
+}
+
+// BrokerConfig - выбор брокера сообщений. Топики, группа, повторы и обработка
+// настраиваются в KafkaConfig и одинаково используются для любого брокера
+type BrokerConfig struct {
+	Type string // kafka, nats или memory (в пределах одного процесса, для тестов)
+	NATS NATSConfig
+}
+
+// NATSConfig - подключение к NATS JetStream. Топики соответствуют субъектам одного стрима
+type NATSConfig struct {
+	URL         string
+	Stream      string
+	AckWait     time.Duration // время на обработку сообщения до повторной доставки
+	StartOffset string        // first или last: с какого сообщения начинает новая группа
 }
 
 type KafkaConfig struct {
-	Brokers []string
-	Topic   string
-	GroupID string
+	Brokers  []string
+	ClientID string
+	Rack     string // стойка (зона) сервиса для размещения партиций рядом с репликами
+	TLS      TLSConfig
+	SASL     SASLConfig
+	Topic    string // основной топик с заказами
+	GroupID  string
+	DLQTopic string
+	Topics   []TopicConfig // все топики, на которые подписан consumer, включая основной
+	Retry    RetryConfig
+
+	// Параметры чтения: сколько данных ждать в одном запросе, сколько ждать их накопления
+	// и с какого офсета ("first" или "last") начинать чтение новой группой
+	MinBytes    int
+	MaxBytes    int
+	MaxWait     time.Duration
+	StartOffset string
+
+	// Сжатие сообщений, которые отправляет сервис (DLQ, повторы): none, gzip, snappy, lz4, zstd
+	Compression string
+
+	// Сколько writer ждет накопления пачки перед отправкой в партицию
+	Linger time.Duration
+
+	// Офсеты фиксируются после обработки: пачкой из CommitBatchSize сообщений
+	// или раз в CommitInterval
+	CommitBatchSize int
+	CommitInterval  time.Duration
+
+	// Пул обработчиков: Workers параллельных обработчиков с очередью WorkerQueueSize,
+	// порядок сохраняется внутри партиции ("partition") или ключа ("key")
+	Workers         int
+	WorkerQueueSize int
+	Ordering        string
+
+	// Пакетная запись: до BatchSize сообщений или ожидание не дольше BatchTimeout
+	BatchSize    int
+	BatchTimeout time.Duration
+}
+
+// TLSConfig - шифрование соединения с брокерами.
+// CertFile и KeyFile нужны только для аутентификации клиента по сертификату
+type TLSConfig struct {
+	Enabled            bool
+	CAFile             string
+	CertFile           string
+	KeyFile            string
+	ServerName         string
+	InsecureSkipVerify bool
+}
+
+// SASLConfig - аутентификация в Kafka
+type SASLConfig struct {
+	Mechanism string // plain, scram-sha-256, scram-sha-512; пусто - без аутентификации
+	Username  string
+	Password  string
+}
+
+// TopicConfig - топик, обработчик его сообщений, формат и собственная DLQ
+type TopicConfig struct {
+	Topic    string
+	Handler  string // orders, order-status, payment, cancellation
+	Format   string // формат сообщений без заголовка content-type: json, protobuf, avro
+	DLQTopic string
+}
+
+// SchemaRegistryConfig - реестр схем Avro. URL задает Confluent-совместимый реестр,
+// Dir - каталог со схемами <id>.avsc вместо него; если не задано ни то, ни другое,
+// сообщения Avro читаются встроенной схемой заказа
+type SchemaRegistryConfig struct {
+	URL string
+	Dir string
+}
+
+// RetryConfig - политика повторной обработки сообщений при временных ошибках
+type RetryConfig struct {
+	MaxAttempts    int           // попыток в процессе до перехода на следующий уровень
+	InitialBackoff time.Duration // пауза перед второй попыткой
+	MaxBackoff     time.Duration // верхняя граница паузы
+	Tiers          []RetryTier   // топики отложенной обработки (необязательно)
+}
+
+// RetryTier - топик повторной обработки с задержкой
+type RetryTier struct {
+	Topic string
+	Delay time.Duration
+}
+
+// OutboxConfig - отправка событий заказов из таблицы outbox в топик Topic.
+// События пишутся в outbox всегда, Enabled включает отправку на этой реплике
+type OutboxConfig struct {
+	Enabled         bool
+	Topic           string
+	PollInterval    time.Duration // пауза между опросами, когда отправлять нечего
+	BatchSize       int           // событий за одну транзакцию
+	PublishTimeout  time.Duration // сколько ждать отправки пачки, пока ее строки заблокированы
+	InitialBackoff  time.Duration // задержка повторной отправки после первой ошибки
+	MaxBackoff      time.Duration
+	Retention       time.Duration // сколько хранить отправленные события
+	CleanupInterval time.Duration
+}
+
+// WebhookConfig - доставка событий заказов партнерам по HTTP.
+// Подписки и доставки хранятся в БД, Enabled включает отправку на этой реплике
+type WebhookConfig struct {
+	Enabled        bool
+	PollInterval   time.Duration // пауза между опросами, когда отправлять нечего
+	BatchSize      int           // доставок, выбираемых за один опрос
+	Concurrency    int           // одновременных HTTP-запросов
+	Timeout        time.Duration // таймаут одного запроса
+	MaxAttempts    int           // попыток доставки одного события
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	DisableAfter   int  // подписка отключается после стольких ошибок подряд
+	AllowPrivate   bool // разрешить адреса внутренней сети (loopback, частные) - для локальной разработки
+}
+
+// ReconcileConfig - периодическая сверка кеша заказов с БД.
+// Enabled включает сверку по расписанию, по запросу через API она доступна всегда
+type ReconcileConfig struct {
+	Enabled  bool
+	Interval time.Duration
+	Repair   bool // исправлять расхождения при сверке по расписанию
 }
 
 type ServerConfig struct {
 	Port string
+
+	// AdminToken - токен административного API (/admin/*), передается в заголовке
+	// "Authorization: Bearer <токен>". Пустой токен отключает административный API
+	AdminToken string
 }
 
 func Load() *Config {
-	return &Config{
+	cfg := &Config{
+		Env: getEnv("APP_ENV", "production"),
 		Database: DatabaseConfig{
 			Host:     getEnv("DB_HOST", "localhost"),
 			Port:     getEnv("DB_PORT", "5432"),
@@ -36,16 +201,141 @@ func Load() *Config {
 			Password: getEnv("DB_PASSWORD", ""),
 			DBName:   getEnv("DB_NAME", "database"),
 			SSLMode:  getEnv("DB_SSLMODE", "disable"),
+
+			QueryTimeout: getEnvDuration("DB_QUERY_TIMEOUT", 10*time.Second),
+		},
+		Migrations: MigrationsConfig{
+			OnStartup:   getEnvBool("MIGRATIONS_ON_STARTUP", true),
+			Required:    getEnvBool("MIGRATIONS_REQUIRED", false),
+			LockTimeout: getEnvDuration("MIGRATIONS_LOCK_TIMEOUT", 5*time.Minute),
+		},
+		Broker: BrokerConfig{
+			Type: getEnv("BROKER", "kafka"),
+			NATS: NATSConfig{
+				URL:         getEnv("NATS_URL", "nats://localhost:4222"),
+				Stream:      getEnv("NATS_STREAM", "ORDERS"),
+				AckWait:     getEnvDuration("NATS_ACK_WAIT", time.Minute),
+				StartOffset: getEnv("NATS_START_OFFSET", "first"),
+			},
 		},
 		Kafka: KafkaConfig{
-			Brokers: []string{getEnv("KAFKA_BROKERS", "localhost:9092")},
-			Topic:   getEnv("KAFKA_TOPIC", "orders"),
-			GroupID: getEnv("KAFKA_GROUP_ID", "order-service"),
+			Brokers:  getEnvList("KAFKA_BROKERS", "localhost:9092"),
+			ClientID: getEnv("KAFKA_CLIENT_ID", "order-service"),
+			Rack:     getEnv("KAFKA_RACK", ""),
+			TLS: TLSConfig{
+				Enabled:            getEnvBool("KAFKA_TLS_ENABLED", false),
+				CAFile:             getEnv("KAFKA_TLS_CA_FILE", ""),
+				CertFile:           getEnv("KAFKA_TLS_CERT_FILE", ""),
+				KeyFile:            getEnv("KAFKA_TLS_KEY_FILE", ""),
+				ServerName:         getEnv("KAFKA_TLS_SERVER_NAME", ""),
+				InsecureSkipVerify: getEnvBool("KAFKA_TLS_INSECURE_SKIP_VERIFY", false),
+			},
+			SASL: SASLConfig{
+				Mechanism: getEnv("KAFKA_SASL_MECHANISM", ""),
+				Username:  getEnv("KAFKA_SASL_USERNAME", ""),
+				Password:  getEnv("KAFKA_SASL_PASSWORD", ""),
+			},
+			Topic:    getEnv("KAFKA_TOPIC", "orders"),
+			GroupID:  getEnv("KAFKA_GROUP_ID", "order-service"),
+			DLQTopic: getEnv("KAFKA_DLQ_TOPIC", "orders.dlq"),
+			Retry: RetryConfig{
+				MaxAttempts:    getEnvInt("KAFKA_RETRY_MAX_ATTEMPTS", 3),
+				InitialBackoff: getEnvDuration("KAFKA_RETRY_INITIAL_BACKOFF", 500*time.Millisecond),
+				MaxBackoff:     getEnvDuration("KAFKA_RETRY_MAX_BACKOFF", 30*time.Second),
+				Tiers:          getEnvRetryTiers("KAFKA_RETRY_TOPICS"),
+			},
+			MinBytes:        getEnvInt("KAFKA_MIN_BYTES", 10e3),
+			MaxBytes:        getEnvInt("KAFKA_MAX_BYTES", 10e6),
+			MaxWait:         getEnvDuration("KAFKA_MAX_WAIT", time.Second),
+			StartOffset:     getEnv("KAFKA_START_OFFSET", "first"),
+			Compression:     getEnv("KAFKA_COMPRESSION", "none"),
+			Linger:          getEnvDuration("KAFKA_LINGER", time.Second),
+			CommitBatchSize: getEnvInt("KAFKA_COMMIT_BATCH_SIZE", 100),
+			CommitInterval:  getEnvDuration("KAFKA_COMMIT_INTERVAL", time.Second),
+			Workers:         getEnvInt("KAFKA_WORKERS", 4),
+			WorkerQueueSize: getEnvInt("KAFKA_WORKER_QUEUE_SIZE", 100),
+			Ordering:        getEnv("KAFKA_ORDERING", "partition"),
+			BatchSize:       getEnvInt("KAFKA_BATCH_SIZE", 1),
+			BatchTimeout:    getEnvDuration("KAFKA_BATCH_TIMEOUT", 100*time.Millisecond),
+		},
+		SchemaRegistry: SchemaRegistryConfig{
+			URL: getEnv("SCHEMA_REGISTRY_URL", ""),
+			Dir: getEnv("SCHEMA_REGISTRY_DIR", ""),
+		},
+		Outbox: OutboxConfig{
+			Enabled:         getEnvBool("OUTBOX_ENABLED", true),
+			Topic:           getEnv("OUTBOX_TOPIC", "order-events"),
+			PollInterval:    getEnvDuration("OUTBOX_POLL_INTERVAL", time.Second),
+			BatchSize:       getEnvInt("OUTBOX_BATCH_SIZE", 100),
+			PublishTimeout:  getEnvDuration("OUTBOX_PUBLISH_TIMEOUT", 10*time.Second),
+			InitialBackoff:  getEnvDuration("OUTBOX_INITIAL_BACKOFF", time.Second),
+			MaxBackoff:      getEnvDuration("OUTBOX_MAX_BACKOFF", 5*time.Minute),
+			Retention:       getEnvDuration("OUTBOX_RETENTION", 24*time.Hour),
+			CleanupInterval: getEnvDuration("OUTBOX_CLEANUP_INTERVAL", time.Hour),
+		},
+		Webhook: WebhookConfig{
+			Enabled:        getEnvBool("WEBHOOK_ENABLED", true),
+			PollInterval:   getEnvDuration("WEBHOOK_POLL_INTERVAL", time.Second),
+			BatchSize:      getEnvInt("WEBHOOK_BATCH_SIZE", 50),
+			Concurrency:    getEnvInt("WEBHOOK_CONCURRENCY", 4),
+			Timeout:        getEnvDuration("WEBHOOK_TIMEOUT", 10*time.Second),
+			MaxAttempts:    getEnvInt("WEBHOOK_MAX_ATTEMPTS", 8),
+			InitialBackoff: getEnvDuration("WEBHOOK_INITIAL_BACKOFF", 10*time.Second),
+			MaxBackoff:     getEnvDuration("WEBHOOK_MAX_BACKOFF", time.Hour),
+			DisableAfter:   getEnvInt("WEBHOOK_DISABLE_AFTER", 20),
+			AllowPrivate:   getEnvBool("WEBHOOK_ALLOW_PRIVATE", false),
+		},
+		Reconcile: ReconcileConfig{
+			Enabled:  getEnvBool("RECONCILE_ENABLED", true),
+			Interval: getEnvDuration("RECONCILE_INTERVAL", time.Hour),
+			Repair:   getEnvBool("RECONCILE_REPAIR", false),
 		},
 		Server: ServerConfig{
-			Port: getEnv("SERVER_PORT", "8081"),
+			Port:       getEnv("SERVER_PORT", "8081"),
+			AdminToken: getEnv("ADMIN_TOKEN", ""),
 		},
 	}
+
+	cfg.Kafka.Topics = loadTopics(cfg.Kafka.Topic, cfg.Kafka.DLQTopic)
+	return cfg
+}
+
+// IsDevelopment сообщает, что сервис запущен в профиле dev или test,
+// где разрешены операции с тестовыми данными
+func (c *Config) IsDevelopment() bool {
+	return c.Env == "dev" || c.Env == "test"
+}
+
+// loadTopics собирает список топиков: основной топик с заказами и необязательные
+// топики событий, которые включаются заданием KAFKA_<ТИП>_TOPIC
+func loadTopics(ordersTopic, ordersDLQ string) []TopicConfig {
+	topics := []TopicConfig{{
+		Topic:    ordersTopic,
+		Handler:  "orders",
+		Format:   getEnv("KAFKA_FORMAT", "json"),
+		DLQTopic: ordersDLQ,
+	}}
+
+	optional := []struct{ handler, prefix string }{
+		{"order-status", "KAFKA_STATUS"},
+		{"payment", "KAFKA_PAYMENT"},
+		{"cancellation", "KAFKA_CANCELLATION"},
+	}
+	for _, t := range optional {
+		topic := os.Getenv(t.prefix + "_TOPIC")
+		if topic == "" {
+			continue
+		}
+
+		topics = append(topics, TopicConfig{
+			Topic:    topic,
+			Handler:  t.handler,
+			Format:   getEnv(t.prefix+"_FORMAT", "json"),
+			DLQTopic: getEnv(t.prefix+"_DLQ_TOPIC", topic+".dlq"),
+		})
+	}
+
+	return topics
 }
 
 func getEnv(key, defaultValue string) string {
@@ -54,3 +344,75 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getEnvInt(key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("Warning: invalid %s=%q, using default %d", key, value, defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("Warning: invalid %s=%q, using default %s", key, value, defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Printf("Warning: invalid %s=%q, using default %t", key, value, defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
+
+// getEnvList разбирает список значений, разделенных запятыми.
+// Если переменная не задана, используются значения по умолчанию
+func getEnvList(key string, defaultValues ...string) []string {
+	var list []string
+	for _, part := range strings.Split(os.Getenv(key), ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			list = append(list, part)
+		}
+	}
+	if len(list) == 0 {
+		return defaultValues
+	}
+	return list
+}
+
+// getEnvRetryTiers разбирает список уровней повтора в формате "topic:delay,topic:delay"
+func getEnvRetryTiers(key string) []RetryTier {
+	var tiers []RetryTier
+	for _, part := range getEnvList(key) {
+		topic, rawDelay, found := strings.Cut(part, ":")
+		delay, err := time.ParseDuration(rawDelay)
+		if !found || topic == "" || err != nil {
+			log.Printf("Warning: invalid retry tier %q in %s, expected topic:delay", part, key)
+			continue
+		}
+		tiers = append(tiers, RetryTier{Topic: topic, Delay: delay})
+	}
+	return tiers
+}
@@ -0,0 +1,52 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestGetEnvList(t *testing.T) {
+	tests := []struct {
+		value string
+		want  []string
+	}{
+		{"", []string{"localhost:9092"}},
+		{"kafka-1:9092", []string{"kafka-1:9092"}},
+		{"kafka-1:9092, kafka-2:9092 ,kafka-3:9092", []string{"kafka-1:9092", "kafka-2:9092", "kafka-3:9092"}},
+		{"kafka-1:9092,,kafka-2:9092,", []string{"kafka-1:9092", "kafka-2:9092"}},
+		{" , ", []string{"localhost:9092"}},
+	}
+
+	for _, tt := range tests {
+		t.Setenv("KAFKA_BROKERS", tt.value)
+		if got := getEnvList("KAFKA_BROKERS", "localhost:9092"); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("KAFKA_BROKERS=%q: got %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestGetEnvRetryTiers(t *testing.T) {
+	tests := []struct {
+		value string
+		want  []RetryTier
+	}{
+		{"", nil},
+		{"orders-retry-1m:1m", []RetryTier{{Topic: "orders-retry-1m", Delay: time.Minute}}},
+		{"orders-retry-5s:5s, orders-retry-1h:1h", []RetryTier{
+			{Topic: "orders-retry-5s", Delay: 5 * time.Second},
+			{Topic: "orders-retry-1h", Delay: time.Hour},
+		}},
+		// Некорректные уровни пропускаются
+		{"orders-retry,:1m,orders-retry-x:soon,orders-retry-10s:10s", []RetryTier{
+			{Topic: "orders-retry-10s", Delay: 10 * time.Second},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Setenv("KAFKA_RETRY_TOPICS", tt.value)
+		if got := getEnvRetryTiers("KAFKA_RETRY_TOPICS"); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("KAFKA_RETRY_TOPICS=%q: got %+v, want %+v", tt.value, got, tt.want)
+		}
+	}
+}
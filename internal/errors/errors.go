@@ -6,4 +6,62 @@ var (
 	ErrOrderNotFound   = errors.New("order not found")
 	ErrInvalidOrderUID = errors.New("invalid order UID")
 	ErrOrderExists     = errors.New("order already exists")
+
+	// ErrOrderIncomplete - заказ сохранен без доставки, платежа или товаров
+	ErrOrderIncomplete = errors.New("order is incomplete")
+
+	ErrSubscriptionNotFound = errors.New("webhook subscription not found")
+	ErrInvalidSubscription  = errors.New("invalid webhook subscription")
+
+	// ErrTemporary - временная ошибка (БД недоступна, таймаут), обработку можно повторить
+	ErrTemporary = errors.New("temporary failure")
+
+	// ErrUnknownTopic - топик не настроен в конфигурации
+	ErrUnknownTopic = errors.New("unknown topic")
+
+	// ErrUnsupported - операция не поддерживается выбранным брокером сообщений
+	ErrUnsupported = errors.New("operation is not supported by the message broker")
+)
+
+// Этапы обработки сообщения с заказом
+const (
+	StageDecode   = "decode"
+	StageValidate = "validate"
+	StagePersist  = "persist"
+	StageUnknown  = "unknown"
 )
+
+// ProcessingError - ошибка обработки заказа с указанием этапа, на котором она произошла
+type ProcessingError struct {
+	Stage string
+	Err   error
+}
+
+func (e *ProcessingError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ProcessingError) Unwrap() error {
+	return e.Err
+}
+
+// NewProcessingError оборачивает ошибку с указанием этапа обработки
+func NewProcessingError(stage string, err error) error {
+	return &ProcessingError{Stage: stage, Err: err}
+}
+
+// StageOf возвращает этап обработки, на котором произошла ошибка
+func StageOf(err error) string {
+	var procErr *ProcessingError
+	if errors.As(err, &procErr) {
+		return procErr.Stage
+	}
+	return StageUnknown
+}
+
+// IsRetryable сообщает, имеет ли смысл повторять обработку после ошибки.
+// Ошибки декодирования и валидации постоянные, повторять их бессмысленно,
+// если только причина не временная (например, реестр схем недоступен при декодировании)
+func IsRetryable(err error) bool {
+	return errors.Is(err, ErrTemporary)
+}
@@ -0,0 +1,37 @@
+package export
+
+import (
+	"context"
+	"io"
+
+	"order-service/internal/interfaces"
+	"order-service/internal/models"
+)
+
+// Exporter выгружает заказы из БД. Заказы читаются серверным курсором и сразу
+// записываются в выходной поток, поэтому память не зависит от размера выгрузки
+type Exporter struct {
+	repo interfaces.OrderRepository
+}
+
+func New(repo interfaces.OrderRepository) *Exporter {
+	return &Exporter{repo: repo}
+}
+
+// Export записывает заказы по фильтру в w и возвращает количество выгруженных заказов
+func (e *Exporter) Export(ctx context.Context, filter models.OrderFilter, format string, w io.Writer) (int, error) {
+	ew, err := NewWriter(format, w)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	err = e.repo.StreamOrders(ctx, filter, func(order *models.Order) error {
+		count++
+		return ew.Write(order)
+	})
+	if err != nil {
+		return count, err
+	}
+	return count, ew.Close()
+}
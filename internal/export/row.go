@@ -0,0 +1,148 @@
+package export
+
+import (
+	"strconv"
+	"time"
+
+	"order-service/internal/models"
+)
+
+// Row - заказ в плоском виде: одна строка на товар. Заказ без товаров дает одну строку
+// с пустыми полями товара. Используется для CSV и Parquet
+type Row struct {
+	OrderUID          string `parquet:"name=order_uid, type=BYTE_ARRAY, convertedtype=UTF8"`
+	TrackNumber       string `parquet:"name=track_number, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Entry             string `parquet:"name=entry, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Locale            string `parquet:"name=locale, type=BYTE_ARRAY, convertedtype=UTF8"`
+	InternalSignature string `parquet:"name=internal_signature, type=BYTE_ARRAY, convertedtype=UTF8"`
+	CustomerID        string `parquet:"name=customer_id, type=BYTE_ARRAY, convertedtype=UTF8"`
+	DeliveryService   string `parquet:"name=delivery_service, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Shardkey          string `parquet:"name=shardkey, type=BYTE_ARRAY, convertedtype=UTF8"`
+	SmID              int64  `parquet:"name=sm_id, type=INT64"`
+	DateCreated       int64  `parquet:"name=date_created, type=INT64, convertedtype=TIMESTAMP_MILLIS"`
+	OofShard          string `parquet:"name=oof_shard, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Status            string `parquet:"name=status, type=BYTE_ARRAY, convertedtype=UTF8"`
+
+	DeliveryName    string `parquet:"name=delivery_name, type=BYTE_ARRAY, convertedtype=UTF8"`
+	DeliveryPhone   string `parquet:"name=delivery_phone, type=BYTE_ARRAY, convertedtype=UTF8"`
+	DeliveryZip     string `parquet:"name=delivery_zip, type=BYTE_ARRAY, convertedtype=UTF8"`
+	DeliveryCity    string `parquet:"name=delivery_city, type=BYTE_ARRAY, convertedtype=UTF8"`
+	DeliveryAddress string `parquet:"name=delivery_address, type=BYTE_ARRAY, convertedtype=UTF8"`
+	DeliveryRegion  string `parquet:"name=delivery_region, type=BYTE_ARRAY, convertedtype=UTF8"`
+	DeliveryEmail   string `parquet:"name=delivery_email, type=BYTE_ARRAY, convertedtype=UTF8"`
+
+	PaymentTransaction  string `parquet:"name=payment_transaction, type=BYTE_ARRAY, convertedtype=UTF8"`
+	PaymentRequestID    string `parquet:"name=payment_request_id, type=BYTE_ARRAY, convertedtype=UTF8"`
+	PaymentCurrency     string `parquet:"name=payment_currency, type=BYTE_ARRAY, convertedtype=UTF8"`
+	PaymentProvider     string `parquet:"name=payment_provider, type=BYTE_ARRAY, convertedtype=UTF8"`
+	PaymentAmount       int64  `parquet:"name=payment_amount, type=INT64"`
+	PaymentDt           int64  `parquet:"name=payment_dt, type=INT64"`
+	PaymentBank         string `parquet:"name=payment_bank, type=BYTE_ARRAY, convertedtype=UTF8"`
+	PaymentDeliveryCost int64  `parquet:"name=payment_delivery_cost, type=INT64"`
+	PaymentGoodsTotal   int64  `parquet:"name=payment_goods_total, type=INT64"`
+	PaymentCustomFee    int64  `parquet:"name=payment_custom_fee, type=INT64"`
+	PaymentStatus       string `parquet:"name=payment_status, type=BYTE_ARRAY, convertedtype=UTF8"`
+
+	ItemChrtID      int64  `parquet:"name=item_chrt_id, type=INT64"`
+	ItemTrackNumber string `parquet:"name=item_track_number, type=BYTE_ARRAY, convertedtype=UTF8"`
+	ItemPrice       int64  `parquet:"name=item_price, type=INT64"`
+	ItemRid         string `parquet:"name=item_rid, type=BYTE_ARRAY, convertedtype=UTF8"`
+	ItemName        string `parquet:"name=item_name, type=BYTE_ARRAY, convertedtype=UTF8"`
+	ItemSale        int64  `parquet:"name=item_sale, type=INT64"`
+	ItemSize        string `parquet:"name=item_size, type=BYTE_ARRAY, convertedtype=UTF8"`
+	ItemTotalPrice  int64  `parquet:"name=item_total_price, type=INT64"`
+	ItemNmID        int64  `parquet:"name=item_nm_id, type=INT64"`
+	ItemBrand       string `parquet:"name=item_brand, type=BYTE_ARRAY, convertedtype=UTF8"`
+	ItemStatus      int64  `parquet:"name=item_status, type=INT64"`
+}
+
+// Columns - заголовок CSV, порядок совпадает с Row.Record
+var Columns = []string{
+	"order_uid", "track_number", "entry", "locale", "internal_signature", "customer_id",
+	"delivery_service", "shardkey", "sm_id", "date_created", "oof_shard", "status",
+	"delivery_name", "delivery_phone", "delivery_zip", "delivery_city", "delivery_address",
+	"delivery_region", "delivery_email",
+	"payment_transaction", "payment_request_id", "payment_currency", "payment_provider",
+	"payment_amount", "payment_dt", "payment_bank", "payment_delivery_cost",
+	"payment_goods_total", "payment_custom_fee", "payment_status",
+	"item_chrt_id", "item_track_number", "item_price", "item_rid", "item_name", "item_sale",
+	"item_size", "item_total_price", "item_nm_id", "item_brand", "item_status",
+}
+
+// Flatten раскладывает заказ на строки, по одной на товар
+func Flatten(order *models.Order) []Row {
+	base := Row{
+		OrderUID:          order.OrderUID,
+		TrackNumber:       order.TrackNumber,
+		Entry:             order.Entry,
+		Locale:            order.Locale,
+		InternalSignature: order.InternalSignature,
+		CustomerID:        order.CustomerID,
+		DeliveryService:   order.DeliveryService,
+		Shardkey:          order.Shardkey,
+		SmID:              int64(order.SmID),
+		DateCreated:       order.DateCreated.UnixMilli(),
+		OofShard:          order.OofShard,
+		Status:            order.Status,
+
+		DeliveryName:    order.Delivery.Name,
+		DeliveryPhone:   order.Delivery.Phone,
+		DeliveryZip:     order.Delivery.Zip,
+		DeliveryCity:    order.Delivery.City,
+		DeliveryAddress: order.Delivery.Address,
+		DeliveryRegion:  order.Delivery.Region,
+		DeliveryEmail:   order.Delivery.Email,
+
+		PaymentTransaction:  order.Payment.Transaction,
+		PaymentRequestID:    order.Payment.RequestID,
+		PaymentCurrency:     order.Payment.Currency,
+		PaymentProvider:     order.Payment.Provider,
+		PaymentAmount:       int64(order.Payment.Amount),
+		PaymentDt:           order.Payment.PaymentDt,
+		PaymentBank:         order.Payment.Bank,
+		PaymentDeliveryCost: int64(order.Payment.DeliveryCost),
+		PaymentGoodsTotal:   int64(order.Payment.GoodsTotal),
+		PaymentCustomFee:    int64(order.Payment.CustomFee),
+		PaymentStatus:       order.Payment.Status,
+	}
+
+	if len(order.Items) == 0 {
+		return []Row{base}
+	}
+
+	rows := make([]Row, len(order.Items))
+	for i, item := range order.Items {
+		row := base
+		row.ItemChrtID = int64(item.ChrtID)
+		row.ItemTrackNumber = item.TrackNumber
+		row.ItemPrice = int64(item.Price)
+		row.ItemRid = item.Rid
+		row.ItemName = item.Name
+		row.ItemSale = int64(item.Sale)
+		row.ItemSize = item.Size
+		row.ItemTotalPrice = int64(item.TotalPrice)
+		row.ItemNmID = int64(item.NmID)
+		row.ItemBrand = item.Brand
+		row.ItemStatus = int64(item.Status)
+		rows[i] = row
+	}
+	return rows
+}
+
+// Record возвращает значения строки для CSV в порядке Columns
+func (r Row) Record() []string {
+	i := strconv.FormatInt
+	return []string{
+		r.OrderUID, r.TrackNumber, r.Entry, r.Locale, r.InternalSignature, r.CustomerID,
+		r.DeliveryService, r.Shardkey, i(r.SmID, 10),
+		time.UnixMilli(r.DateCreated).UTC().Format(time.RFC3339Nano), r.OofShard, r.Status,
+		r.DeliveryName, r.DeliveryPhone, r.DeliveryZip, r.DeliveryCity, r.DeliveryAddress,
+		r.DeliveryRegion, r.DeliveryEmail,
+		r.PaymentTransaction, r.PaymentRequestID, r.PaymentCurrency, r.PaymentProvider,
+		i(r.PaymentAmount, 10), i(r.PaymentDt, 10), r.PaymentBank, i(r.PaymentDeliveryCost, 10),
+		i(r.PaymentGoodsTotal, 10), i(r.PaymentCustomFee, 10), r.PaymentStatus,
+		i(r.ItemChrtID, 10), r.ItemTrackNumber, i(r.ItemPrice, 10), r.ItemRid, r.ItemName,
+		i(r.ItemSale, 10), r.ItemSize, i(r.ItemTotalPrice, 10), i(r.ItemNmID, 10), r.ItemBrand,
+		i(r.ItemStatus, 10),
+	}
+}
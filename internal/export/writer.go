@@ -0,0 +1,131 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/writer"
+
+	"order-service/internal/models"
+)
+
+// Форматы выгрузки
+const (
+	FormatCSV     = "csv"
+	FormatNDJSON  = "ndjson"
+	FormatParquet = "parquet"
+)
+
+// Размер группы строк Parquet: строки группы хранятся в памяти до записи
+const parquetRowGroupSize = 8 << 20
+
+// Writer записывает заказы в выбранном формате. Close дописывает буферизованные
+// данные (для Parquet - последнюю группу строк и метаданные файла)
+type Writer interface {
+	Write(order *models.Order) error
+	Close() error
+}
+
+// ParseFormat проверяет название формата выгрузки. Пустое название означает NDJSON
+func ParseFormat(format string) (string, error) {
+	switch f := strings.ToLower(format); f {
+	case FormatCSV, FormatNDJSON, FormatParquet:
+		return f, nil
+	case "", "jsonl":
+		return FormatNDJSON, nil
+	default:
+		return "", fmt.Errorf("unsupported export format %q, expected csv, ndjson or parquet", format)
+	}
+}
+
+// NewWriter создает Writer для формата: csv, ndjson или parquet
+func NewWriter(format string, w io.Writer) (Writer, error) {
+	format, err := ParseFormat(format)
+	if err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(Columns); err != nil {
+			return nil, err
+		}
+		return &csvWriter{w: cw}, nil
+
+	case FormatParquet:
+		pw, err := writer.NewParquetWriterFromWriter(w, new(Row), 1)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create parquet writer: %w", err)
+		}
+		pw.RowGroupSize = parquetRowGroupSize
+		pw.CompressionType = parquet.CompressionCodec_SNAPPY
+		return &parquetWriter{w: pw}, nil
+
+	default:
+		return &ndjsonWriter{enc: json.NewEncoder(w)}, nil
+	}
+}
+
+// ContentType возвращает MIME-тип формата выгрузки
+func ContentType(format string) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatParquet:
+		return "application/vnd.apache.parquet"
+	default:
+		return "application/x-ndjson"
+	}
+}
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func (c *csvWriter) Write(order *models.Order) error {
+	for _, row := range Flatten(order) {
+		if err := c.w.Write(row.Record()); err != nil {
+			return err
+		}
+	}
+	return c.w.Error()
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+type ndjsonWriter struct {
+	enc *json.Encoder
+}
+
+func (n *ndjsonWriter) Write(order *models.Order) error {
+	return n.enc.Encode(order)
+}
+
+func (n *ndjsonWriter) Close() error {
+	return nil
+}
+
+type parquetWriter struct {
+	w *writer.ParquetWriter
+}
+
+func (p *parquetWriter) Write(order *models.Order) error {
+	for _, row := range Flatten(order) {
+		if err := p.w.Write(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *parquetWriter) Close() error {
+	return p.w.WriteStop()
+}
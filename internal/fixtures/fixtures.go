@@ -0,0 +1,49 @@
+// Package fixtures содержит наборы тестовых заказов для локальной разработки и тестов.
+// Наборы хранятся в sets/<имя>.json как JSON-массив заказов в формате сообщений Kafka
+package fixtures
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+)
+
+//go:embed sets/*.json
+var sets embed.FS
+
+// ErrUnknownSet - набора с таким именем нет
+var ErrUnknownSet = errors.New("unknown fixture set")
+
+// Names возвращает имена всех наборов по алфавиту
+func Names() []string {
+	files, _ := fs.Glob(sets, "sets/*.json")
+	names := make([]string, 0, len(files))
+	for _, file := range files {
+		names = append(names, strings.TrimSuffix(path.Base(file), ".json"))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Load возвращает заказы набора в исходном JSON: они сохраняются так же,
+// как заказы из брокера, с декодированием и валидацией
+func Load(name string) ([]json.RawMessage, error) {
+	data, err := sets.ReadFile("sets/" + name + ".json")
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w %q, available: %s", ErrUnknownSet, name, strings.Join(Names(), ", "))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var orders []json.RawMessage
+	if err := json.Unmarshal(data, &orders); err != nil {
+		return nil, fmt.Errorf("invalid fixture set %q: %w", name, err)
+	}
+	return orders, nil
+}
@@ -0,0 +1,115 @@
+package importer
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Checkpoint - отметка импорта архива: итог на последней сохраненной пачке
+type Checkpoint struct {
+	Source string `json:"source"`
+	Format string `json:"format"`
+	Report
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// LoadCheckpoint читает отметку импорта. Если файла нет, возвращает nil
+func LoadCheckpoint(path string) (*Checkpoint, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var cp Checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, fmt.Errorf("invalid checkpoint %s: %w", path, err)
+	}
+	return &cp, nil
+}
+
+// FileHandler записывает отклоненные записи в файл NDJSON, а после каждой пачки -
+// отметку импорта. Отметка записывается после отклоненных записей пачки, поэтому
+// при продолжении импорта записи не теряются (но могут повториться)
+type FileHandler struct {
+	checkpointPath string
+	checkpoint     Checkpoint
+
+	file    *os.File
+	rejects *bufio.Writer
+	encoder *json.Encoder
+}
+
+// NewFileHandler открывает файл отклоненных записей: при продолжении импорта (resume)
+// записи дописываются в конец, иначе файл создается заново
+func NewFileHandler(rejectsPath, checkpointPath string, checkpoint Checkpoint, resume bool) (*FileHandler, error) {
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if resume {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+	file, err := os.OpenFile(rejectsPath, flags, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	rejects := bufio.NewWriter(file)
+	return &FileHandler{
+		checkpointPath: checkpointPath,
+		checkpoint:     checkpoint,
+		file:           file,
+		rejects:        rejects,
+		encoder:        json.NewEncoder(rejects),
+	}, nil
+}
+
+func (f *FileHandler) Reject(reject Reject) error {
+	return f.encoder.Encode(reject)
+}
+
+func (f *FileHandler) Checkpoint(report Report) error {
+	if err := f.rejects.Flush(); err != nil {
+		return err
+	}
+	if err := f.file.Sync(); err != nil {
+		return err
+	}
+
+	f.checkpoint.Report = report
+	f.checkpoint.UpdatedAt = time.Now().UTC()
+	data, err := json.MarshalIndent(f.checkpoint, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Отметка заменяется целиком, чтобы прерывание не оставило ее недописанной
+	tmp, err := os.CreateTemp(filepath.Dir(f.checkpointPath), filepath.Base(f.checkpointPath)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), f.checkpointPath)
+}
+
+// Close дописывает и закрывает файл отклоненных записей
+func (f *FileHandler) Close() error {
+	return errors.Join(f.rejects.Flush(), f.file.Close())
+}
@@ -0,0 +1,111 @@
+package importer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileHandler(t *testing.T) {
+	dir := t.TempDir()
+	rejectsPath := filepath.Join(dir, "orders.ndjson.rejects.ndjson")
+	checkpointPath := filepath.Join(dir, "orders.ndjson.checkpoint.json")
+
+	h, err := NewFileHandler(rejectsPath, checkpointPath,
+		Checkpoint{Source: "orders.ndjson", Format: FormatNDJSON}, false)
+	if err != nil {
+		t.Fatalf("NewFileHandler: %v", err)
+	}
+	defer h.Close()
+
+	report, err := New(newTestService()).Import(context.Background(), strings.NewReader(testArchive),
+		Options{Format: FormatNDJSON, BatchSize: 2, Source: "orders.ndjson"}, h)
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+
+	// Отклоненные записи на диске еще до Close: их дописывает отметка
+	if rejects := readLines(t, rejectsPath); len(rejects) != 2 ||
+		!strings.Contains(rejects[0], `"position":2`) || !strings.Contains(rejects[1], `"position":4`) {
+		t.Errorf("unexpected rejects file: %q", rejects)
+	}
+
+	cp, err := LoadCheckpoint(checkpointPath)
+	if err != nil || cp == nil {
+		t.Fatalf("LoadCheckpoint: %v", err)
+	}
+	if cp.Report != report || cp.Source != "orders.ndjson" || cp.Format != FormatNDJSON || cp.UpdatedAt.IsZero() {
+		t.Errorf("checkpoint %+v, want report %+v", cp, report)
+	}
+
+	// Временные файлы отметки не остаются
+	if entries, _ := os.ReadDir(dir); len(entries) != 2 {
+		t.Errorf("%d files in directory, want rejects and checkpoint", len(entries))
+	}
+
+	// При продолжении отклоненные записи дописываются
+	if err := h.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	resumed, err := NewFileHandler(rejectsPath, checkpointPath, *cp, true)
+	if err != nil {
+		t.Fatalf("NewFileHandler: %v", err)
+	}
+	if err := resumed.Reject(Reject{Position: 6, Stage: "decode"}); err != nil {
+		t.Fatalf("Reject: %v", err)
+	}
+	if err := resumed.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if rejects := readLines(t, rejectsPath); len(rejects) != 3 {
+		t.Errorf("%d rejects after resume, want 3", len(rejects))
+	}
+}
+
+// Отклоненные записи сбрасываются на диск до замены отметки: если отметку записать
+// не удалось, записи пачки уже сохранены, а отметка осталась прежней
+func TestFileHandlerFlushesRejectsBeforeCheckpoint(t *testing.T) {
+	dir := t.TempDir()
+	rejectsPath := filepath.Join(dir, "rejects.ndjson")
+	checkpointPath := filepath.Join(dir, "checkpoint.json")
+
+	// Отметку нельзя заменить: на ее месте каталог
+	if err := os.Mkdir(checkpointPath, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	h, err := NewFileHandler(rejectsPath, checkpointPath, Checkpoint{}, false)
+	if err != nil {
+		t.Fatalf("NewFileHandler: %v", err)
+	}
+	defer h.Close()
+
+	if err := h.Reject(Reject{Position: 1, Stage: "decode", Reason: "invalid JSON"}); err != nil {
+		t.Fatalf("Reject: %v", err)
+	}
+	if err := h.Checkpoint(Report{Position: 1, Rejected: 1}); err == nil {
+		t.Fatal("expected checkpoint error")
+	}
+
+	if rejects := readLines(t, rejectsPath); len(rejects) != 1 || !strings.Contains(rejects[0], "invalid JSON") {
+		t.Errorf("rejects not flushed before checkpoint: %q", rejects)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 2 {
+		t.Errorf("temporary checkpoint file left behind: %d files", len(entries))
+	}
+}
+
+func readLines(t *testing.T, path string) []string {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read %s: %v", path, err)
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+}
@@ -0,0 +1,211 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"time"
+
+	"order-service/internal/interfaces"
+	"order-service/internal/models"
+
+	apperrors "order-service/internal/errors" // кастомные ошибки
+)
+
+// IngestionTopic - source_topic сведений о сообщении для импортированных заказов.
+// Ключ сообщения - имя источника, офсет - номер записи в архиве
+const IngestionTopic = "import"
+
+// Размер пачки по умолчанию
+const defaultBatchSize = 500
+
+// Options - параметры импорта
+type Options struct {
+	Format    string
+	Columns   map[string]string // переименование колонок CSV, см. NewReader
+	BatchSize int
+	Source    string // имя архива, сохраняется в сведениях о сообщении
+	Resume    Report // итог прерванного импорта: записи до Resume.Position пропускаются
+}
+
+// Report - итог импорта. Position - номер последней обработанной записи:
+// все записи до нее сохранены, пропущены как уже существующие или отклонены
+type Report struct {
+	Position int `json:"position"`
+	Imported int `json:"imported"`
+	Existing int `json:"existing"`
+	Rejected int `json:"rejected"`
+}
+
+// Reject - отклоненная запись архива с причиной
+type Reject struct {
+	Position int    `json:"position"`
+	Line     int    `json:"line"`
+	OrderUID string `json:"order_uid,omitempty"`
+	Stage    string `json:"stage"`
+	Reason   string `json:"reason"`
+	Raw      string `json:"raw,omitempty"`
+}
+
+// Handler получает результаты импорта. Reject вызывается для отклоненных записей пачки,
+// Checkpoint - после того как пачка сохранена и все ее отклоненные записи переданы
+type Handler interface {
+	Reject(reject Reject) error
+	Checkpoint(report Report) error
+}
+
+// Importer загружает заказы из архивов CSV и NDJSON: записи разбираются, проходят
+// валидацию сервиса заказов и сохраняются пачками
+type Importer struct {
+	service interfaces.OrderService
+}
+
+func New(service interfaces.OrderService) *Importer {
+	return &Importer{service: service}
+}
+
+// Import читает архив из r и сохраняет заказы. Временная ошибка БД или отмена контекста
+// прерывают импорт: возвращается итог на последней сохраненной пачке, с которого
+// импорт можно продолжить через Options.Resume
+func (im *Importer) Import(ctx context.Context, r io.Reader, opts Options, h Handler) (Report, error) {
+	report := opts.Resume
+
+	format, err := ParseFormat(opts.Format)
+	if err != nil {
+		return report, err
+	}
+	reader, err := NewReader(format, r, opts.Columns)
+	if err != nil {
+		return report, err
+	}
+
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+
+	b := &batch{format: format, source: opts.Source}
+	for {
+		rec, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return report, fmt.Errorf("failed to read record %d: %w", report.Position+1, err)
+		}
+		if rec.Position <= opts.Resume.Position {
+			continue
+		}
+
+		b.add(rec)
+		if b.size() >= batchSize {
+			if err := im.flush(ctx, b, &report, h); err != nil {
+				return report, err
+			}
+		}
+	}
+
+	if err := im.flush(ctx, b, &report, h); err != nil {
+		return report, err
+	}
+
+	log.Printf("Import of %s finished: %d imported, %d already existed, %d rejected",
+		opts.Source, report.Imported, report.Existing, report.Rejected)
+	return report, nil
+}
+
+// flush сохраняет пачку, передает отклоненные записи и отмечает пачку обработанной
+func (im *Importer) flush(ctx context.Context, b *batch, report *Report, h Handler) error {
+	if b.size() == 0 {
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	next := *report
+	next.Position = b.last
+
+	rejects := b.rejects
+	for i, err := range im.service.ImportOrders(ctx, b.orders, b.ingestions) {
+		rec := b.records[i]
+		switch {
+		case err == nil:
+			next.Imported++
+		case errors.Is(err, apperrors.ErrOrderExists):
+			// Заказ уже сохранен, например пачкой, отметка о которой не успела записаться
+			next.Existing++
+		case apperrors.IsRetryable(err):
+			return fmt.Errorf("failed to import records %d-%d: %w", report.Position+1, b.last, err)
+		default:
+			rejects = append(rejects, Reject{Position: rec.Position, Line: rec.Line,
+				OrderUID: rec.Order.OrderUID, Stage: apperrors.StageOf(err), Reason: err.Error(), Raw: rec.Raw})
+		}
+	}
+
+	sort.Slice(rejects, func(i, j int) bool { return rejects[i].Position < rejects[j].Position })
+	for _, reject := range rejects {
+		if err := h.Reject(reject); err != nil {
+			return fmt.Errorf("failed to write reject: %w", err)
+		}
+	}
+	next.Rejected += len(rejects)
+
+	if err := h.Checkpoint(next); err != nil {
+		return fmt.Errorf("failed to save checkpoint: %w", err)
+	}
+
+	*report = next
+	b.reset()
+	return nil
+}
+
+// batch - записи, ожидающие сохранения
+type batch struct {
+	format string
+	source string
+
+	records    []Record
+	orders     []*models.Order
+	ingestions []*models.OrderIngestion
+	rejects    []Reject // записи, которые не удалось разобрать
+	last       int      // номер последней записи пачки
+}
+
+func (b *batch) add(rec Record) {
+	b.last = rec.Position
+	if rec.Err != nil {
+		b.rejects = append(b.rejects, Reject{Position: rec.Position, Line: rec.Line,
+			Stage: apperrors.StageDecode, Reason: rec.Err.Error(), Raw: rec.Raw})
+		return
+	}
+
+	b.records = append(b.records, rec)
+	b.orders = append(b.orders, rec.Order)
+	b.ingestions = append(b.ingestions, &models.OrderIngestion{
+		Topic:       IngestionTopic,
+		Offset:      int64(rec.Position),
+		Key:         b.source,
+		ContentType: contentTypes[b.format],
+		IngestedAt:  time.Now(),
+	})
+}
+
+func (b *batch) size() int {
+	return len(b.records) + len(b.rejects)
+}
+
+func (b *batch) reset() {
+	b.records = b.records[:0]
+	b.orders = b.orders[:0]
+	b.ingestions = b.ingestions[:0]
+	b.rejects = b.rejects[:0]
+}
+
+var contentTypes = map[string]string{
+	FormatCSV:    "text/csv",
+	FormatNDJSON: "application/x-ndjson",
+}
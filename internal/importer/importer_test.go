@@ -0,0 +1,144 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"order-service/internal/interfaces"
+	"order-service/internal/models"
+
+	apperrors "order-service/internal/errors" // кастомные ошибки
+)
+
+// fakeService возвращает для заказа ошибку из errs и запоминает полученные пачки
+type fakeService struct {
+	interfaces.OrderService
+	errs    map[string]error
+	batches [][]string
+}
+
+func (s *fakeService) ImportOrders(ctx context.Context, orders []*models.Order, ingestions []*models.OrderIngestion) []error {
+	errs := make([]error, len(orders))
+	uids := make([]string, len(orders))
+	for i, order := range orders {
+		uids[i] = order.OrderUID
+		errs[i] = s.errs[order.OrderUID]
+
+		ingestion := ingestions[i]
+		if ingestion.Topic != IngestionTopic || ingestion.Key != "orders.ndjson" ||
+			ingestion.ContentType != "application/x-ndjson" || fmt.Sprint(ingestion.Offset) != strings.TrimPrefix(order.OrderUID, "order-") {
+			errs[i] = fmt.Errorf("unexpected ingestion %+v", ingestion)
+		}
+	}
+	s.batches = append(s.batches, uids)
+	return errs
+}
+
+// recordingHandler запоминает вызовы по порядку
+type recordingHandler struct {
+	calls       []string
+	checkpoints []Report
+}
+
+func (h *recordingHandler) Reject(reject Reject) error {
+	h.calls = append(h.calls, fmt.Sprintf("reject %d %s", reject.Position, reject.Stage))
+	return nil
+}
+
+func (h *recordingHandler) Checkpoint(report Report) error {
+	h.calls = append(h.calls, fmt.Sprintf("checkpoint %d", report.Position))
+	h.checkpoints = append(h.checkpoints, report)
+	return nil
+}
+
+// Номер записи совпадает с номером в order_uid, запись 2 не разбирается
+const testArchive = `{"order_uid":"order-1"}
+not json
+{"order_uid":"order-3"}
+{"order_uid":"order-4"}
+{"order_uid":"order-5"}
+`
+
+func newTestService() *fakeService {
+	return &fakeService{errs: map[string]error{
+		"order-3": apperrors.ErrOrderExists,
+		"order-4": apperrors.NewProcessingError(apperrors.StageValidate, errors.New("invalid order")),
+	}}
+}
+
+func TestImport(t *testing.T) {
+	service := newTestService()
+	h := &recordingHandler{}
+
+	report, err := New(service).Import(context.Background(), strings.NewReader(testArchive),
+		Options{Format: FormatNDJSON, BatchSize: 2, Source: "orders.ndjson"}, h)
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+
+	if want := (Report{Position: 5, Imported: 2, Existing: 1, Rejected: 2}); report != want {
+		t.Errorf("report %+v, want %+v", report, want)
+	}
+	// Отклоненная при разборе запись занимает место в пачке, но в сервис не передается
+	if want := [][]string{{"order-1"}, {"order-3", "order-4"}, {"order-5"}}; !reflect.DeepEqual(service.batches, want) {
+		t.Errorf("batches %v, want %v", service.batches, want)
+	}
+	// Отклоненные записи пачки передаются до ее отметки
+	want := []string{"reject 2 decode", "checkpoint 2", "reject 4 validate", "checkpoint 4", "checkpoint 5"}
+	if !reflect.DeepEqual(h.calls, want) {
+		t.Errorf("handler calls %v, want %v", h.calls, want)
+	}
+	if last := h.checkpoints[len(h.checkpoints)-1]; last != report {
+		t.Errorf("last checkpoint %+v differs from report %+v", last, report)
+	}
+}
+
+// Временная ошибка прерывает импорт на последней сохраненной пачке, с нее импорт продолжается
+func TestImportResume(t *testing.T) {
+	service := newTestService()
+	service.errs["order-4"] = fmt.Errorf("database is down: %w", apperrors.ErrTemporary)
+	h := &recordingHandler{}
+	opts := Options{Format: FormatNDJSON, BatchSize: 2, Source: "orders.ndjson"}
+
+	report, err := New(service).Import(context.Background(), strings.NewReader(testArchive), opts, h)
+	if !errors.Is(err, apperrors.ErrTemporary) {
+		t.Fatalf("expected temporary error, got %v", err)
+	}
+	if want := (Report{Position: 2, Imported: 1, Rejected: 1}); report != want {
+		t.Fatalf("interrupted report %+v, want %+v", report, want)
+	}
+
+	service = newTestService()
+	h = &recordingHandler{}
+	opts.Resume = report
+	report, err = New(service).Import(context.Background(), strings.NewReader(testArchive), opts, h)
+	if err != nil {
+		t.Fatalf("resumed Import: %v", err)
+	}
+
+	if want := (Report{Position: 5, Imported: 2, Existing: 1, Rejected: 2}); report != want {
+		t.Errorf("resumed report %+v, want %+v", report, want)
+	}
+	if want := [][]string{{"order-3", "order-4"}, {"order-5"}}; !reflect.DeepEqual(service.batches, want) {
+		t.Errorf("resumed batches %v, want %v", service.batches, want)
+	}
+	if want := []string{"reject 4 validate", "checkpoint 4", "checkpoint 5"}; !reflect.DeepEqual(h.calls, want) {
+		t.Errorf("resumed handler calls %v, want %v", h.calls, want)
+	}
+}
+
+func TestImportCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	service := newTestService()
+	report, err := New(service).Import(ctx, strings.NewReader(testArchive),
+		Options{Format: FormatNDJSON, Source: "orders.ndjson"}, &recordingHandler{})
+	if !errors.Is(err, context.Canceled) || report != (Report{}) || len(service.batches) != 0 {
+		t.Errorf("cancelled import: report %+v, %d batches, err %v", report, len(service.batches), err)
+	}
+}
@@ -0,0 +1,378 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"order-service/internal/export"
+	"order-service/internal/models"
+)
+
+// Форматы архивов для импорта
+const (
+	FormatCSV    = export.FormatCSV
+	FormatNDJSON = export.FormatNDJSON
+)
+
+// Максимальная длина строки NDJSON
+const maxLineSize = 16 << 20
+
+// Record - запись архива: один заказ. Position - порядковый номер записи начиная с 1,
+// Line - строка файла, с которой запись начинается. Err - ошибка разбора записи
+type Record struct {
+	Position int
+	Line     int
+	Order    *models.Order
+	Raw      string
+	Err      error
+}
+
+// Reader читает записи архива по порядку. В конце архива возвращает io.EOF
+type Reader interface {
+	Next() (Record, error)
+}
+
+// ParseFormat проверяет название формата архива. Пустое название означает NDJSON
+func ParseFormat(format string) (string, error) {
+	switch f := strings.ToLower(format); f {
+	case FormatCSV, FormatNDJSON:
+		return f, nil
+	case "", "jsonl":
+		return FormatNDJSON, nil
+	default:
+		return "", fmt.Errorf("unsupported import format %q, expected csv or ndjson", format)
+	}
+}
+
+// NewReader создает Reader для формата csv или ndjson. columns переименовывает колонки
+// CSV: колонка архива -> колонка выгрузки (export.Columns); для NDJSON не используется
+func NewReader(format string, r io.Reader, columns map[string]string) (Reader, error) {
+	format, err := ParseFormat(format)
+	if err != nil {
+		return nil, err
+	}
+
+	if format == FormatCSV {
+		return newCSVReader(r, columns)
+	}
+	return &ndjsonReader{r: bufio.NewReaderSize(r, 64<<10)}, nil
+}
+
+// ndjsonReader - один заказ в формате JSON на строку, пустые строки пропускаются
+type ndjsonReader struct {
+	r        *bufio.Reader
+	line     int
+	position int
+}
+
+func (n *ndjsonReader) Next() (Record, error) {
+	for {
+		data, err := n.r.ReadSlice('\n')
+		if errors.Is(err, bufio.ErrBufferFull) {
+			data, err = n.readLong(data)
+		}
+		if len(data) == 0 && err != nil {
+			return Record{}, err
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return Record{}, err
+		}
+		n.line++
+
+		data = bytes.TrimSpace(data)
+		if len(data) == 0 {
+			continue
+		}
+
+		n.position++
+		rec := Record{Position: n.position, Line: n.line, Raw: string(data)}
+
+		var order models.Order
+		if err := json.Unmarshal(data, &order); err != nil {
+			rec.Err = fmt.Errorf("invalid JSON: %w", err)
+		} else {
+			rec.Order = &order
+		}
+		return rec, nil
+	}
+}
+
+// readLong дочитывает строку длиннее буфера
+func (n *ndjsonReader) readLong(prefix []byte) ([]byte, error) {
+	line := append([]byte(nil), prefix...)
+	for {
+		chunk, err := n.r.ReadSlice('\n')
+		line = append(line, chunk...)
+		if len(line) > maxLineSize {
+			return nil, fmt.Errorf("line %d is longer than %d bytes", n.line+1, maxLineSize)
+		}
+		if !errors.Is(err, bufio.ErrBufferFull) {
+			return line, err
+		}
+	}
+}
+
+// csvReader - плоский формат выгрузки: одна строка на товар, строки одного заказа идут подряд.
+// Колонки сопоставляются по заголовку, неизвестные колонки пропускаются
+type csvReader struct {
+	r        *csv.Reader
+	index    map[string]int // колонка выгрузки -> номер колонки в файле
+	pending  []string       // первая строка следующего заказа
+	line     int            // строка файла, с которой начинается pending
+	err      error          // ошибка чтения после последнего заказа
+	position int
+}
+
+func newCSVReader(r io.Reader, columns map[string]string) (*csvReader, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = false
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+
+	known := make(map[string]bool, len(export.Columns))
+	for _, name := range export.Columns {
+		known[name] = true
+	}
+
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.TrimSpace(name)
+		if i == 0 {
+			name = strings.TrimPrefix(name, "\uFEFF") // BOM
+		}
+		if mapped, ok := columns[name]; ok {
+			name = mapped
+		}
+		name = strings.ToLower(name)
+		if !known[name] {
+			continue
+		}
+		if _, dup := index[name]; dup {
+			return nil, fmt.Errorf("CSV header maps column %q more than once", name)
+		}
+		index[name] = i
+	}
+
+	if _, ok := index["order_uid"]; !ok {
+		return nil, fmt.Errorf("CSV header has no order_uid column")
+	}
+	return &csvReader{r: cr, index: index}, nil
+}
+
+func (c *csvReader) Next() (Record, error) {
+	first, line, err := c.pending, c.line, c.err
+	c.pending, c.err = nil, nil
+	if first == nil && err == nil {
+		first, line, err = c.read()
+	}
+	if err != nil {
+		// Строка с ошибкой разбора CSV отклоняется, чтение продолжается со следующей
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			c.position++
+			return Record{Position: c.position, Line: parseErr.StartLine, Err: err}, nil
+		}
+		return Record{}, err
+	}
+
+	// Строки заказа идут подряд: читаем, пока не сменится order_uid
+	rows := [][]string{first}
+	uid := c.value(first, "order_uid")
+	for {
+		row, rowLine, err := c.read()
+		if err != nil {
+			// Ошибку вернет следующий вызов Next
+			c.err = err
+			break
+		}
+		if c.value(row, "order_uid") != uid {
+			c.pending, c.line = row, rowLine
+			break
+		}
+		rows = append(rows, row)
+	}
+
+	c.position++
+	rec := Record{Position: c.position, Line: line, Raw: encodeCSV(rows)}
+	rec.Order, rec.Err = c.order(rows)
+	return rec, nil
+}
+
+// read читает строку CSV и возвращает номер строки файла, с которой она начинается
+func (c *csvReader) read() ([]string, int, error) {
+	row, err := c.r.Read()
+	if err != nil {
+		return nil, 0, err
+	}
+	line, _ := c.r.FieldPos(0)
+	return row, line, nil
+}
+
+func (c *csvReader) value(row []string, column string) string {
+	i, ok := c.index[column]
+	if !ok || i >= len(row) {
+		return ""
+	}
+	return row[i]
+}
+
+// order собирает заказ из его строк: поля заказа берутся из первой строки,
+// каждая строка с заполненными полями товара дает товар. Выгрузка заказа без товаров
+// содержит строку с пустыми и нулевыми полями товара - товара она не дает
+func (c *csvReader) order(rows [][]string) (*models.Order, error) {
+	order := &models.Order{}
+	for _, column := range export.Columns {
+		if set, ok := orderFields[column]; ok {
+			if err := set(order, c.value(rows[0], column)); err != nil {
+				return nil, fmt.Errorf("column %s: %w", column, err)
+			}
+		}
+	}
+
+	for n, row := range rows {
+		var item models.Item
+		empty := true
+		for _, column := range export.Columns {
+			set, ok := itemFields[column]
+			if !ok {
+				continue
+			}
+			v := c.value(row, column)
+			if v != "" && v != "0" {
+				empty = false
+			}
+			if err := set(&item, v); err != nil {
+				return nil, fmt.Errorf("row %d, column %s: %w", n+1, column, err)
+			}
+		}
+		if !empty {
+			order.Items = append(order.Items, item)
+		}
+	}
+	return order, nil
+}
+
+func encodeCSV(rows [][]string) string {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.WriteAll(rows)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// Разбор значений колонок CSV. Пустое значение оставляет поле нулевым
+var orderFields = map[string]func(o *models.Order, v string) error{
+	"order_uid":             func(o *models.Order, v string) error { o.OrderUID = v; return nil },
+	"track_number":          func(o *models.Order, v string) error { o.TrackNumber = v; return nil },
+	"entry":                 func(o *models.Order, v string) error { o.Entry = v; return nil },
+	"locale":                func(o *models.Order, v string) error { o.Locale = v; return nil },
+	"internal_signature":    func(o *models.Order, v string) error { o.InternalSignature = v; return nil },
+	"customer_id":           func(o *models.Order, v string) error { o.CustomerID = v; return nil },
+	"delivery_service":      func(o *models.Order, v string) error { o.DeliveryService = v; return nil },
+	"shardkey":              func(o *models.Order, v string) error { o.Shardkey = v; return nil },
+	"sm_id":                 func(o *models.Order, v string) error { return parseInt(&o.SmID, v) },
+	"date_created":          func(o *models.Order, v string) error { return parseTime(&o.DateCreated, v) },
+	"oof_shard":             func(o *models.Order, v string) error { o.OofShard = v; return nil },
+	"status":                func(o *models.Order, v string) error { o.Status = v; return nil },
+	"delivery_name":         func(o *models.Order, v string) error { o.Delivery.Name = v; return nil },
+	"delivery_phone":        func(o *models.Order, v string) error { o.Delivery.Phone = v; return nil },
+	"delivery_zip":          func(o *models.Order, v string) error { o.Delivery.Zip = v; return nil },
+	"delivery_city":         func(o *models.Order, v string) error { o.Delivery.City = v; return nil },
+	"delivery_address":      func(o *models.Order, v string) error { o.Delivery.Address = v; return nil },
+	"delivery_region":       func(o *models.Order, v string) error { o.Delivery.Region = v; return nil },
+	"delivery_email":        func(o *models.Order, v string) error { o.Delivery.Email = v; return nil },
+	"payment_transaction":   func(o *models.Order, v string) error { o.Payment.Transaction = v; return nil },
+	"payment_request_id":    func(o *models.Order, v string) error { o.Payment.RequestID = v; return nil },
+	"payment_currency":      func(o *models.Order, v string) error { o.Payment.Currency = v; return nil },
+	"payment_provider":      func(o *models.Order, v string) error { o.Payment.Provider = v; return nil },
+	"payment_amount":        func(o *models.Order, v string) error { return parseInt(&o.Payment.Amount, v) },
+	"payment_dt":            func(o *models.Order, v string) error { return parseInt64(&o.Payment.PaymentDt, v) },
+	"payment_bank":          func(o *models.Order, v string) error { o.Payment.Bank = v; return nil },
+	"payment_delivery_cost": func(o *models.Order, v string) error { return parseInt(&o.Payment.DeliveryCost, v) },
+	"payment_goods_total":   func(o *models.Order, v string) error { return parseInt(&o.Payment.GoodsTotal, v) },
+	"payment_custom_fee":    func(o *models.Order, v string) error { return parseInt(&o.Payment.CustomFee, v) },
+	"payment_status":        func(o *models.Order, v string) error { o.Payment.Status = v; return nil },
+}
+
+var itemFields = map[string]func(i *models.Item, v string) error{
+	"item_chrt_id":      func(i *models.Item, v string) error { return parseInt(&i.ChrtID, v) },
+	"item_track_number": func(i *models.Item, v string) error { i.TrackNumber = v; return nil },
+	"item_price":        func(i *models.Item, v string) error { return parseInt(&i.Price, v) },
+	"item_rid":          func(i *models.Item, v string) error { i.Rid = v; return nil },
+	"item_name":         func(i *models.Item, v string) error { i.Name = v; return nil },
+	"item_sale":         func(i *models.Item, v string) error { return parseInt(&i.Sale, v) },
+	"item_size":         func(i *models.Item, v string) error { i.Size = v; return nil },
+	"item_total_price":  func(i *models.Item, v string) error { return parseInt(&i.TotalPrice, v) },
+	"item_nm_id":        func(i *models.Item, v string) error { return parseInt(&i.NmID, v) },
+	"item_brand":        func(i *models.Item, v string) error { i.Brand = v; return nil },
+	"item_status":       func(i *models.Item, v string) error { return parseInt(&i.Status, v) },
+}
+
+func parseInt(dst *int, v string) error {
+	if v == "" {
+		return nil
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(v))
+	if err != nil {
+		return fmt.Errorf("invalid integer %q", v)
+	}
+	*dst = n
+	return nil
+}
+
+func parseInt64(dst *int64, v string) error {
+	if v == "" {
+		return nil
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid integer %q", v)
+	}
+	*dst = n
+	return nil
+}
+
+func parseTime(dst *time.Time, v string) error {
+	if v == "" {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(v))
+	if err != nil {
+		return fmt.Errorf("invalid RFC3339 time %q", v)
+	}
+	*dst = t
+	return nil
+}
+
+// ParseColumns разбирает переименование колонок CSV вида "колонка_архива=колонка_выгрузки,..."
+func ParseColumns(s string) (map[string]string, error) {
+	columns := make(map[string]string)
+	if strings.TrimSpace(s) == "" {
+		return columns, nil
+	}
+
+	for _, pair := range strings.Split(s, ",") {
+		from, to, ok := strings.Cut(pair, "=")
+		from, to = strings.TrimSpace(from), strings.ToLower(strings.TrimSpace(to))
+		if !ok || from == "" || to == "" {
+			return nil, fmt.Errorf("invalid column mapping %q, expected source=column", pair)
+		}
+		if !slices.Contains(export.Columns, to) {
+			return nil, fmt.Errorf("unknown column %q in mapping %q", to, pair)
+		}
+		columns[from] = to
+	}
+	return columns, nil
+}
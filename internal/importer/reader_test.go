@@ -0,0 +1,160 @@
+package importer
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func readAll(t *testing.T, r Reader) []Record {
+	t.Helper()
+
+	var records []Record
+	for {
+		rec, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			return records
+		}
+		if err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+		records = append(records, rec)
+	}
+}
+
+// Строки одного заказа идут подряд и собираются в одну запись с товарами
+func TestCSVReaderGroupsRowsByOrder(t *testing.T) {
+	archive := "order_uid,track_number,item_name,item_price,date_created\n" +
+		"order-1,TRACK-1,Mascaras,453,2021-11-26T06:22:19Z\n" +
+		"order-1,TRACK-1,Lipstick,1000,2021-11-26T06:22:19Z\n" +
+		"order-2,TRACK-2,\"Two\nlines\",10,\n" +
+		"order-3,TRACK-3,,0,\n" +
+		"order-4,TRACK-4,Broken,x,\n" +
+		"order-1,TRACK-1,Again,1,\n"
+
+	r, err := NewReader(FormatCSV, strings.NewReader(archive), nil)
+	if err != nil {
+		t.Fatalf("NewReader: %v", err)
+	}
+	records := readAll(t, r)
+
+	want := []struct {
+		uid   string
+		line  int
+		items []string
+		err   bool
+	}{
+		{"order-1", 2, []string{"Mascaras", "Lipstick"}, false},
+		{"order-2", 4, []string{"Two\nlines"}, false},
+		{"order-3", 6, nil, false}, // строка заказа без товаров
+		{"", 7, nil, true},
+		{"order-1", 8, []string{"Again"}, false}, // не подряд - отдельная запись
+	}
+	if len(records) != len(want) {
+		t.Fatalf("read %d records, want %d", len(records), len(want))
+	}
+	for i, w := range want {
+		rec := records[i]
+		if rec.Position != i+1 || rec.Line != w.line {
+			t.Errorf("record %d: position %d, line %d, want %d and %d", i, rec.Position, rec.Line, i+1, w.line)
+		}
+		if w.err {
+			if rec.Err == nil || !strings.Contains(rec.Err.Error(), "item_price") {
+				t.Errorf("record %d: expected item_price error, got %v", i, rec.Err)
+			}
+			if !strings.Contains(rec.Raw, "Broken") {
+				t.Errorf("record %d: raw %q does not contain the rejected row", i, rec.Raw)
+			}
+			continue
+		}
+		if rec.Err != nil {
+			t.Errorf("record %d: %v", i, rec.Err)
+			continue
+		}
+		if rec.Order.OrderUID != w.uid {
+			t.Errorf("record %d: order_uid %q, want %q", i, rec.Order.OrderUID, w.uid)
+		}
+		var items []string
+		for _, item := range rec.Order.Items {
+			items = append(items, item.Name)
+		}
+		if strings.Join(items, "|") != strings.Join(w.items, "|") {
+			t.Errorf("record %d: items %q, want %q", i, items, w.items)
+		}
+	}
+
+	if got := records[0].Order.DateCreated.Format("2006-01-02T15:04:05Z07:00"); got != "2021-11-26T06:22:19Z" {
+		t.Errorf("date_created = %s", got)
+	}
+}
+
+func TestCSVReaderHeader(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		columns map[string]string
+		wantErr string
+	}{
+		{"BOM before first column", "\uFEFForder_uid,Track_Number,unknown", nil, ""},
+		{"renamed columns", "\uFEFFid,track,unknown", map[string]string{"id": "order_uid", "track": "track_number"}, ""},
+		{"no order_uid", "id,track_number", nil, "no order_uid"},
+		{"column mapped twice", "id,order_uid,track_number", map[string]string{"id": "order_uid"}, "more than once"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			archive := tt.header + "\norder-1,TRACK-1,ignored\n"
+			r, err := NewReader(FormatCSV, strings.NewReader(archive), tt.columns)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected %q error, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewReader: %v", err)
+			}
+
+			records := readAll(t, r)
+			if len(records) != 1 || records[0].Err != nil {
+				t.Fatalf("unexpected records: %+v", records)
+			}
+			if o := records[0].Order; o.OrderUID != "order-1" || o.TrackNumber != "TRACK-1" {
+				t.Errorf("columns not mapped: order_uid %q, track_number %q", o.OrderUID, o.TrackNumber)
+			}
+		})
+	}
+}
+
+// Строки длиннее буфера чтения дочитываются целиком, слишком длинная строка - ошибка
+func TestNDJSONReaderLongLines(t *testing.T) {
+	long := strings.Repeat("x", 200<<10)
+	archive := `{"order_uid":"order-1","track_number":"` + long + `"}` + "\n" +
+		"\n" +
+		"not json\n" +
+		`{"order_uid":"order-2"}`
+
+	r, err := NewReader("jsonl", strings.NewReader(archive), nil)
+	if err != nil {
+		t.Fatalf("NewReader: %v", err)
+	}
+	records := readAll(t, r)
+
+	if len(records) != 3 {
+		t.Fatalf("read %d records, want 3", len(records))
+	}
+	if o := records[0].Order; records[0].Err != nil || o.OrderUID != "order-1" || o.TrackNumber != long {
+		t.Errorf("long line not read: %v", records[0].Err)
+	}
+	if records[1].Err == nil || records[1].Position != 2 || records[1].Line != 3 {
+		t.Errorf("invalid JSON: position %d, line %d, err %v", records[1].Position, records[1].Line, records[1].Err)
+	}
+	if records[2].Err != nil || records[2].Order.OrderUID != "order-2" || records[2].Line != 4 {
+		t.Errorf("last line without newline: %+v", records[2])
+	}
+
+	r, _ = NewReader(FormatNDJSON, strings.NewReader(strings.Repeat("x", maxLineSize+1)+"\n"), nil)
+	if _, err := r.Next(); err == nil || !strings.Contains(err.Error(), "longer than") {
+		t.Errorf("expected line length error, got %v", err)
+	}
+}
@@ -0,0 +1,30 @@
+package interfaces
+
+import (
+	"context"
+
+	"order-service/internal/models"
+)
+
+// MessageSource - подписка группы на топики. Сообщения читаются без автоматического
+// подтверждения: Commit подтверждает сообщение и все предыдущие в его партиции
+type MessageSource interface {
+	Fetch(ctx context.Context) (models.Message, error)
+	Commit(ctx context.Context, msgs ...models.Message) error
+	Close() error
+}
+
+// MessageSink отправляет сообщения в один топик. Topic, Partition и Offset сообщений игнорируются.
+// Send отправляет одно сообщение и возвращает его с заполненными Topic, Partition и Offset
+type MessageSink interface {
+	Publish(ctx context.Context, msgs ...models.Message) error
+	Send(ctx context.Context, msg models.Message) (models.Message, error)
+	Close() error
+}
+
+// MessageBroker создает подписки и отправителей для конкретного брокера
+type MessageBroker interface {
+	Source(groupID string, topics ...string) (MessageSource, error)
+	Sink(topic string) (MessageSink, error)
+	Close() error
+}
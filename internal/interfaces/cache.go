@@ -7,6 +7,9 @@ type Cache interface {
 	Get(orderUID string) (*models.Order, bool)
 	LoadFromDB(orders []models.Order)
 	Size() int
+	Clear() int
+	Delete(orderUID string) bool
+	Snapshot() map[string]*models.Order
 	GetMetrics() CacheMetrics
 }
 
@@ -0,0 +1,18 @@
+package interfaces
+
+import (
+	"context"
+
+	"order-service/internal/models"
+)
+
+// OrderCodec декодирует и кодирует заказ в формате, заданном content-type
+type OrderCodec interface {
+	Decode(contentType string, data []byte) (*models.Order, error)
+	Encode(contentType string, order *models.Order) ([]byte, error)
+}
+
+// SchemaRegistry возвращает схему по идентификатору, под которым она зарегистрирована
+type SchemaRegistry interface {
+	Schema(ctx context.Context, id int) (string, error)
+}
@@ -0,0 +1,21 @@
+package interfaces
+
+import (
+	"context"
+
+	"order-service/internal/models"
+)
+
+type ConsumerMonitor interface {
+	Status() models.ConsumerStatus
+}
+
+// ConsumerController - административное управление consumer'ом
+type ConsumerController interface {
+	ConsumerMonitor
+	Pause()
+	Resume()
+	Seek(ctx context.Context, positions []models.SeekPosition) error
+	StartReplay(ctx context.Context, req models.ReplayRequest) (models.ReplayJob, error)
+	ReplayJob(id string) (models.ReplayJob, bool)
+}
@@ -0,0 +1,14 @@
+package interfaces
+
+import (
+	"context"
+
+	"order-service/internal/models"
+)
+
+// DeadLetterQueue - просмотр и повторная отправка сообщений из DLQ.
+// Пустой topic означает все настроенные DLQ-топики
+type DeadLetterQueue interface {
+	List(ctx context.Context, topic string, limit int) ([]models.DeadLetter, error)
+	Redrive(ctx context.Context, topic string, limit int) (int, error)
+}
@@ -1,9 +1,34 @@
 package interfaces
 
-import "order-service/internal/models"
+import (
+	"context"
+	"time"
+
+	"order-service/internal/models"
+)
 
 type OrderRepository interface {
-	CreateOrder(order *models.Order) error
-	GetOrder(orderUID string) (*models.Order, error)
-	GetAllOrders() ([]models.Order, error)
+	CreateOrder(ctx context.Context, order *models.Order, ingestion *models.OrderIngestion) error
+	CreateOrders(ctx context.Context, orders []*models.Order, ingestions []*models.OrderIngestion) []error
+	ImportOrders(ctx context.Context, orders []*models.Order, ingestions []*models.OrderIngestion) []error
+	GetOrder(ctx context.Context, orderUID string) (*models.Order, error)
+	GetOrderIngestion(ctx context.Context, orderUID string) (*models.OrderIngestion, error)
+	GetAllOrders(ctx context.Context) ([]models.Order, error)
+	ListOrders(ctx context.Context, filter models.OrderFilter) ([]models.OrderSummary, error)
+	StreamOrders(ctx context.Context, filter models.OrderFilter, fn func(*models.Order) error) error
+	UpdateOrderStatus(ctx context.Context, orderUID, status, reason string, updatedAt time.Time) error
+	UpdatePaymentStatus(ctx context.Context, orderUID, status string, paymentDt int64) error
+}
+
+// IntegrityRepository - проверка целостности данных заказов и перенос нарушений в карантин
+type IntegrityRepository interface {
+	CheckIntegrity(ctx context.Context) (*models.IntegrityReport, error)
+	Quarantine(ctx context.Context) (*models.QuarantineReport, error)
+}
+
+// OutboxRepository - события заказов, ожидающие отправки во внешний топик
+type OutboxRepository interface {
+	PublishPending(ctx context.Context, limit int,
+		publish func([]models.OutboxEvent) error, retryDelay func(attempts int) time.Duration) (int, int, error)
+	DeletePublished(ctx context.Context, retention time.Duration) (int64, error)
 }
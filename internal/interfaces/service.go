@@ -1,11 +1,23 @@
 package interfaces
 
-import "order-service/internal/models"
+import (
+	"context"
+
+	"order-service/internal/models"
+)
 
 type OrderService interface {
-	ProcessOrder(data []byte) error
-	GetOrder(orderUID string) (*models.Order, error)
-	LoadCacheFromDB() error
+	ProcessOrder(ctx context.Context, payload models.OrderPayload) error
+	ProcessOrders(ctx context.Context, batch []models.OrderPayload) []error
+	ReplayOrder(ctx context.Context, payload models.OrderPayload) (bool, error)
+	ImportOrders(ctx context.Context, orders []*models.Order, ingestions []*models.OrderIngestion) []error
+	UpdateOrderStatus(ctx context.Context, update models.OrderStatusUpdate) error
+	ApplyPaymentEvent(ctx context.Context, event models.PaymentEvent) error
+	CancelOrder(ctx context.Context, cancellation models.OrderCancellation) error
+	GetOrder(ctx context.Context, orderUID string) (*models.Order, error)
+	GetOrderIngestion(ctx context.Context, orderUID string) (*models.OrderIngestion, error)
+	LoadCacheFromDB(ctx context.Context) error
 	GetCacheMetrics() CacheMetrics
 	GetCacheSize() int
+	FlushCache() int
 }
@@ -0,0 +1,30 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+
+	"order-service/internal/models"
+)
+
+// WebhookManager - управление подписками партнеров на webhook
+type WebhookManager interface {
+	Subscribe(ctx context.Context, req models.WebhookSubscriptionRequest) (*models.WebhookSubscription, error)
+	Subscription(ctx context.Context, id int64) (*models.WebhookSubscription, error)
+	Subscriptions(ctx context.Context) ([]models.WebhookSubscription, error)
+	Unsubscribe(ctx context.Context, id int64) error
+	Enable(ctx context.Context, id int64) (*models.WebhookSubscription, error)
+	Deliveries(ctx context.Context, id int64, limit int) ([]models.WebhookDelivery, error)
+}
+
+type WebhookRepository interface {
+	CreateSubscription(ctx context.Context, sub *models.WebhookSubscription) error
+	GetSubscription(ctx context.Context, id int64) (*models.WebhookSubscription, error)
+	ListSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, id int64) error
+	EnableSubscription(ctx context.Context, id int64) error
+	ListDeliveries(ctx context.Context, subscriptionID int64, limit int) ([]models.WebhookDelivery, error)
+	ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookTask, error)
+	RecordResult(ctx context.Context, result models.WebhookResult,
+		retryDelay time.Duration, maxAttempts, disableAfter int) (bool, error)
+}
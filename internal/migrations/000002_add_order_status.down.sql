@@ -0,0 +1,5 @@
+ALTER TABLE payments DROP COLUMN IF EXISTS status;
+ALTER TABLE orders
+    DROP COLUMN IF EXISTS status_updated_at,
+    DROP COLUMN IF EXISTS status_reason,
+    DROP COLUMN IF EXISTS status;
@@ -0,0 +1,6 @@
+ALTER TABLE orders
+    ADD COLUMN status VARCHAR(32) NOT NULL DEFAULT 'created',
+    ADD COLUMN status_reason TEXT NOT NULL DEFAULT '',
+    ADD COLUMN status_updated_at TIMESTAMP;
+ALTER TABLE payments
+    ADD COLUMN status VARCHAR(32) NOT NULL DEFAULT '';
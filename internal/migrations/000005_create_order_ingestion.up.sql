@@ -0,0 +1,16 @@
+CREATE TABLE order_ingestion (
+    order_uid VARCHAR(255) PRIMARY KEY REFERENCES orders(order_uid) ON DELETE CASCADE,
+    source_topic VARCHAR(255) NOT NULL,
+    source_partition INTEGER NOT NULL,
+    source_offset BIGINT NOT NULL,
+    message_key TEXT NOT NULL DEFAULT '',
+    message_time TIMESTAMP,
+    content_type VARCHAR(255) NOT NULL DEFAULT '',
+    trace_id VARCHAR(255) NOT NULL DEFAULT '',
+    producer_id VARCHAR(255) NOT NULL DEFAULT '',
+    schema_version VARCHAR(64) NOT NULL DEFAULT '',
+    ingested_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+-- Поиск заказа по положению сообщения
+CREATE INDEX idx_order_ingestion_source ON order_ingestion (source_topic, source_partition, source_offset);
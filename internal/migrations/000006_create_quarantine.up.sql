@@ -0,0 +1,12 @@
+-- Строки, убранные проверкой целостности: неполные заказы со всеми их строками,
+-- строки без заказа и дубликаты доставок и платежей. data - исходная строка таблицы
+CREATE TABLE quarantine (
+    id BIGSERIAL PRIMARY KEY,
+    source_table VARCHAR(64) NOT NULL,
+    order_uid VARCHAR(255),
+    reason VARCHAR(64) NOT NULL,
+    data JSONB NOT NULL,
+    quarantined_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_quarantine_order_uid ON quarantine (order_uid);
@@ -0,0 +1,200 @@
+-- Перед ограничениями убираем нарушающие их данные. Строки без заказа и повторные
+-- доставки и платежи переносятся в карантин, как это делает проверка целостности
+WITH moved AS (DELETE FROM deliveries WHERE order_uid IS NULL RETURNING *)
+INSERT INTO quarantine (source_table, order_uid, reason, data)
+SELECT 'deliveries', moved.order_uid, 'orphaned', to_jsonb(moved) FROM moved;
+
+WITH moved AS (DELETE FROM payments WHERE order_uid IS NULL RETURNING *)
+INSERT INTO quarantine (source_table, order_uid, reason, data)
+SELECT 'payments', moved.order_uid, 'orphaned', to_jsonb(moved) FROM moved;
+
+WITH moved AS (DELETE FROM items WHERE order_uid IS NULL RETURNING *)
+INSERT INTO quarantine (source_table, order_uid, reason, data)
+SELECT 'items', moved.order_uid, 'orphaned', to_jsonb(moved) FROM moved;
+
+WITH moved AS (
+    DELETE FROM deliveries d
+    WHERE d.id > (SELECT MIN(k.id) FROM deliveries k WHERE k.order_uid = d.order_uid)
+    RETURNING *
+)
+INSERT INTO quarantine (source_table, order_uid, reason, data)
+SELECT 'deliveries', moved.order_uid, 'duplicate', to_jsonb(moved) FROM moved;
+
+WITH moved AS (
+    DELETE FROM payments p
+    WHERE p.id > (SELECT MIN(k.id) FROM payments k WHERE k.order_uid = p.order_uid)
+    RETURNING *
+)
+INSERT INTO quarantine (source_table, order_uid, reason, data)
+SELECT 'payments', moved.order_uid, 'duplicate', to_jsonb(moved) FROM moved;
+
+-- Отрицательные суммы обнуляются, исходная строка сохраняется в карантине
+INSERT INTO quarantine (source_table, order_uid, reason, data)
+SELECT 'payments', p.order_uid, 'negative_amount', to_jsonb(p) FROM payments p
+WHERE p.amount < 0 OR p.delivery_cost < 0 OR p.goods_total < 0 OR p.custom_fee < 0;
+
+UPDATE payments SET
+    amount = GREATEST(amount, 0),
+    delivery_cost = GREATEST(delivery_cost, 0),
+    goods_total = GREATEST(goods_total, 0),
+    custom_fee = GREATEST(custom_fee, 0)
+WHERE amount < 0 OR delivery_cost < 0 OR goods_total < 0 OR custom_fee < 0;
+
+INSERT INTO quarantine (source_table, order_uid, reason, data)
+SELECT 'items', i.order_uid, 'negative_amount', to_jsonb(i) FROM items i
+WHERE i.price < 0 OR i.sale < 0 OR i.total_price < 0;
+
+UPDATE items SET
+    price = GREATEST(price, 0),
+    sale = GREATEST(sale, 0),
+    total_price = GREATEST(total_price, 0)
+WHERE price < 0 OR sale < 0 OR total_price < 0;
+
+-- NULL заменяются значениями по умолчанию
+UPDATE orders SET
+    locale = COALESCE(locale, ''),
+    internal_signature = COALESCE(internal_signature, ''),
+    customer_id = COALESCE(customer_id, ''),
+    delivery_service = COALESCE(delivery_service, ''),
+    shardkey = COALESCE(shardkey, ''),
+    sm_id = COALESCE(sm_id, 0),
+    date_created = COALESCE(date_created, created_at, now()),
+    oof_shard = COALESCE(oof_shard, ''),
+    created_at = COALESCE(created_at, now())
+WHERE locale IS NULL OR internal_signature IS NULL OR customer_id IS NULL
+   OR delivery_service IS NULL OR shardkey IS NULL OR sm_id IS NULL
+   OR date_created IS NULL OR oof_shard IS NULL OR created_at IS NULL;
+
+UPDATE deliveries SET
+    name = COALESCE(name, ''),
+    phone = COALESCE(phone, ''),
+    zip = COALESCE(zip, ''),
+    city = COALESCE(city, ''),
+    address = COALESCE(address, ''),
+    region = COALESCE(region, ''),
+    email = COALESCE(email, '')
+WHERE name IS NULL OR phone IS NULL OR zip IS NULL OR city IS NULL
+   OR address IS NULL OR region IS NULL OR email IS NULL;
+
+UPDATE payments SET
+    transaction = COALESCE(transaction, ''),
+    request_id = COALESCE(request_id, ''),
+    currency = COALESCE(currency, ''),
+    provider = COALESCE(provider, ''),
+    amount = COALESCE(amount, 0),
+    payment_dt = COALESCE(payment_dt, 0),
+    bank = COALESCE(bank, ''),
+    delivery_cost = COALESCE(delivery_cost, 0),
+    goods_total = COALESCE(goods_total, 0),
+    custom_fee = COALESCE(custom_fee, 0)
+WHERE transaction IS NULL OR request_id IS NULL OR currency IS NULL OR provider IS NULL
+   OR amount IS NULL OR payment_dt IS NULL OR bank IS NULL
+   OR delivery_cost IS NULL OR goods_total IS NULL OR custom_fee IS NULL;
+
+UPDATE items SET
+    chrt_id = COALESCE(chrt_id, 0),
+    track_number = COALESCE(track_number, ''),
+    price = COALESCE(price, 0),
+    rid = COALESCE(rid, ''),
+    name = COALESCE(name, ''),
+    sale = COALESCE(sale, 0),
+    size = COALESCE(size, ''),
+    total_price = COALESCE(total_price, 0),
+    nm_id = COALESCE(nm_id, 0),
+    brand = COALESCE(brand, ''),
+    status = COALESCE(status, 0)
+WHERE chrt_id IS NULL OR track_number IS NULL OR price IS NULL OR rid IS NULL
+   OR name IS NULL OR sale IS NULL OR size IS NULL OR total_price IS NULL
+   OR nm_id IS NULL OR brand IS NULL OR status IS NULL;
+
+ALTER TABLE orders
+    ALTER COLUMN locale SET DEFAULT '',
+    ALTER COLUMN locale SET NOT NULL,
+    ALTER COLUMN internal_signature SET DEFAULT '',
+    ALTER COLUMN internal_signature SET NOT NULL,
+    ALTER COLUMN customer_id SET DEFAULT '',
+    ALTER COLUMN customer_id SET NOT NULL,
+    ALTER COLUMN delivery_service SET DEFAULT '',
+    ALTER COLUMN delivery_service SET NOT NULL,
+    ALTER COLUMN shardkey SET DEFAULT '',
+    ALTER COLUMN shardkey SET NOT NULL,
+    ALTER COLUMN sm_id SET DEFAULT 0,
+    ALTER COLUMN sm_id SET NOT NULL,
+    ALTER COLUMN date_created SET DEFAULT now(),
+    ALTER COLUMN date_created SET NOT NULL,
+    ALTER COLUMN oof_shard SET DEFAULT '',
+    ALTER COLUMN oof_shard SET NOT NULL,
+    ALTER COLUMN created_at SET NOT NULL;
+
+ALTER TABLE deliveries
+    ALTER COLUMN order_uid SET NOT NULL,
+    ALTER COLUMN name SET DEFAULT '',
+    ALTER COLUMN name SET NOT NULL,
+    ALTER COLUMN phone SET DEFAULT '',
+    ALTER COLUMN phone SET NOT NULL,
+    ALTER COLUMN zip SET DEFAULT '',
+    ALTER COLUMN zip SET NOT NULL,
+    ALTER COLUMN city SET DEFAULT '',
+    ALTER COLUMN city SET NOT NULL,
+    ALTER COLUMN address SET DEFAULT '',
+    ALTER COLUMN address SET NOT NULL,
+    ALTER COLUMN region SET DEFAULT '',
+    ALTER COLUMN region SET NOT NULL,
+    ALTER COLUMN email SET DEFAULT '',
+    ALTER COLUMN email SET NOT NULL,
+    ADD CONSTRAINT deliveries_order_uid_key UNIQUE (order_uid);
+
+ALTER TABLE payments
+    ALTER COLUMN order_uid SET NOT NULL,
+    ALTER COLUMN transaction SET DEFAULT '',
+    ALTER COLUMN transaction SET NOT NULL,
+    ALTER COLUMN request_id SET DEFAULT '',
+    ALTER COLUMN request_id SET NOT NULL,
+    ALTER COLUMN currency SET DEFAULT '',
+    ALTER COLUMN currency SET NOT NULL,
+    ALTER COLUMN provider SET DEFAULT '',
+    ALTER COLUMN provider SET NOT NULL,
+    ALTER COLUMN amount SET DEFAULT 0,
+    ALTER COLUMN amount SET NOT NULL,
+    ALTER COLUMN payment_dt SET DEFAULT 0,
+    ALTER COLUMN payment_dt SET NOT NULL,
+    ALTER COLUMN bank SET DEFAULT '',
+    ALTER COLUMN bank SET NOT NULL,
+    ALTER COLUMN delivery_cost SET DEFAULT 0,
+    ALTER COLUMN delivery_cost SET NOT NULL,
+    ALTER COLUMN goods_total SET DEFAULT 0,
+    ALTER COLUMN goods_total SET NOT NULL,
+    ALTER COLUMN custom_fee SET DEFAULT 0,
+    ALTER COLUMN custom_fee SET NOT NULL,
+    ADD CONSTRAINT payments_order_uid_key UNIQUE (order_uid),
+    ADD CONSTRAINT payments_amounts_check
+        CHECK (amount >= 0 AND delivery_cost >= 0 AND goods_total >= 0 AND custom_fee >= 0);
+
+ALTER TABLE items
+    ALTER COLUMN order_uid SET NOT NULL,
+    ALTER COLUMN chrt_id SET DEFAULT 0,
+    ALTER COLUMN chrt_id SET NOT NULL,
+    ALTER COLUMN track_number SET DEFAULT '',
+    ALTER COLUMN track_number SET NOT NULL,
+    ALTER COLUMN price SET DEFAULT 0,
+    ALTER COLUMN price SET NOT NULL,
+    ALTER COLUMN rid SET DEFAULT '',
+    ALTER COLUMN rid SET NOT NULL,
+    ALTER COLUMN name SET DEFAULT '',
+    ALTER COLUMN name SET NOT NULL,
+    ALTER COLUMN sale SET DEFAULT 0,
+    ALTER COLUMN sale SET NOT NULL,
+    ALTER COLUMN size SET DEFAULT '',
+    ALTER COLUMN size SET NOT NULL,
+    ALTER COLUMN total_price SET DEFAULT 0,
+    ALTER COLUMN total_price SET NOT NULL,
+    ALTER COLUMN nm_id SET DEFAULT 0,
+    ALTER COLUMN nm_id SET NOT NULL,
+    ALTER COLUMN brand SET DEFAULT '',
+    ALTER COLUMN brand SET NOT NULL,
+    ALTER COLUMN status SET DEFAULT 0,
+    ALTER COLUMN status SET NOT NULL,
+    ADD CONSTRAINT items_amounts_check CHECK (price >= 0 AND sale >= 0 AND total_price >= 0);
+
+-- Доставка и платеж индексируются уникальными ограничениями
+CREATE INDEX idx_items_order_uid ON items (order_uid);
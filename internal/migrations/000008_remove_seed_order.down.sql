@@ -0,0 +1,50 @@
+-- Возвращает тестовый заказ, который создавала миграция 000001 (данные - копия ее INSERT).
+-- Если заказ уже загружен командой orderctl seed, он не изменяется
+WITH seed AS (
+    INSERT INTO orders (
+            order_uid,
+            track_number,
+            entry,
+            locale,
+            internal_signature,
+            customer_id,
+            delivery_service,
+            shardkey,
+            sm_id,
+            date_created,
+            oof_shard
+        )
+    VALUES (
+            'b563feb7b2b84b6test',
+            'WBILMTESTTRACK',
+            'WBIL',
+            'en',
+            '',
+            'test',
+            'meest',
+            '9',
+            99,
+            '2021-11-26T06:22:19Z',
+            '1'
+        )
+    ON CONFLICT (order_uid) DO NOTHING
+    RETURNING order_uid
+),
+seed_delivery AS (
+    INSERT INTO deliveries (order_uid, name, phone, zip, city, address, region, email)
+    SELECT order_uid, 'Test Testov', '+9720000000', '2639809', 'Kiryat Mozkin',
+           'Ploshad Mira 15', 'Kraiot', 'test@gmail.com'
+    FROM seed
+),
+seed_payment AS (
+    INSERT INTO payments (order_uid, transaction, request_id, currency, provider, amount,
+                          payment_dt, bank, delivery_cost, goods_total, custom_fee)
+    SELECT order_uid, 'b563feb7b2b84b6test', '', 'USD', 'wbpay', 1817,
+           1637907727, 'alpha', 1500, 317, 0
+    FROM seed
+)
+INSERT INTO items (order_uid, chrt_id, track_number, price, rid, name,
+                   sale, size, total_price, nm_id, brand, status)
+SELECT order_uid, 9934930, 'WBILMTESTTRACK', 453, 'ab4219087a764ae0btest', 'Mascaras',
+       30, '0', 317, 2389212, 'Vivienne Sabo', 202
+FROM seed;
@@ -0,0 +1,3 @@
+-- Тестовый заказ, созданный миграцией 000001, удаляется: в dev и test он загружается
+-- командой orderctl seed. Строки доставки, платежа и товаров удаляются каскадно
+DELETE FROM orders WHERE order_uid = 'b563feb7b2b84b6test';
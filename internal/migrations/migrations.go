@@ -0,0 +1,9 @@
+// Package migrations содержит миграции схемы БД, встроенные в бинарники сервиса
+package migrations
+
+import "embed"
+
+// FS - файлы миграций golang-migrate (<версия>_<название>.up.sql и .down.sql)
+//
+//go:embed *.sql
+var FS embed.FS
@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// CacheStats - состояние кеша заказов
+type CacheStats struct {
+	Size     int     `json:"size"`
+	Hits     int64   `json:"hits"`
+	Misses   int64   `json:"misses"`
+	HitRatio float64 `json:"hit_ratio"`
+}
+
+// ReconcileReport - результат сверки кеша с БД. Списки содержат не больше
+// ReconcileSampleSize идентификаторов, полное количество - в счетчиках
+type ReconcileReport struct {
+	StartedAt   time.Time `json:"started_at"`
+	FinishedAt  time.Time `json:"finished_at"`
+	DBOrders    int       `json:"db_orders"`
+	CacheOrders int       `json:"cache_orders"`
+
+	Missing      int      `json:"missing"` // есть в БД, нет в кеше
+	Extra        int      `json:"extra"`   // есть в кеше, нет в БД
+	Stale        int      `json:"stale"`   // содержимое в кеше отличается от БД
+	MissingUIDs  []string `json:"missing_uids"`
+	ExtraUIDs    []string `json:"extra_uids"`
+	StaleUIDs    []string `json:"stale_uids"`
+	Repair       bool     `json:"repair"`
+	Repaired     int      `json:"repaired"`
+	RepairFailed int      `json:"repair_failed"`
+	Error        string   `json:"error,omitempty"`
+}
+
+// ReconcileSampleSize - сколько идентификаторов каждого вида расхождений попадает в отчет
+const ReconcileSampleSize = 100
@@ -0,0 +1,69 @@
+package models
+
+import "time"
+
+// ConsumerStatus - сводка о состоянии Kafka consumer'а
+type ConsumerStatus struct {
+	Topics         []string          `json:"topics"`
+	GroupID        string            `json:"group_id"`
+	StartedAt      time.Time         `json:"started_at"`
+	Paused         bool              `json:"paused"`
+	Processed      int64             `json:"processed"`
+	Failed         int64             `json:"failed"`
+	MessagesPerSec float64           `json:"messages_per_sec"`
+	AvgLatencyMs   float64           `json:"avg_latency_ms"`
+	TotalLag       int64             `json:"total_lag"`
+	Partitions     []PartitionStatus `json:"partitions"`
+	ErrorsByStage  map[string]int64  `json:"errors_by_stage"`
+	ErrorsByReason map[string]int64  `json:"errors_by_reason"`
+}
+
+// PartitionStatus - положение consumer'а в партиции
+type PartitionStatus struct {
+	Topic         string    `json:"topic"`
+	Partition     int       `json:"partition"`
+	Offset        int64     `json:"offset"`
+	HighWaterMark int64     `json:"high_water_mark"`
+	Lag           int64     `json:"lag"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// SeekPosition - новая позиция consumer'а в партиции: по офсету или по времени
+type SeekPosition struct {
+	Topic     string     `json:"topic,omitempty"` // по умолчанию - основной топик
+	Partition int        `json:"partition"`
+	Offset    *int64     `json:"offset,omitempty"`
+	Timestamp *time.Time `json:"timestamp,omitempty"`
+}
+
+// ReplayRequest - диапазон сообщений для повторной обработки.
+// Начало задается офсетом или временем (по умолчанию - начало партиции),
+// конец (не включительно) - офсетом или временем (по умолчанию - текущий конец партиции)
+type ReplayRequest struct {
+	Topic      string     `json:"topic,omitempty"`      // по умолчанию - основной топик
+	Partitions []int      `json:"partitions,omitempty"` // по умолчанию - все партиции
+	FromOffset *int64     `json:"from_offset,omitempty"`
+	ToOffset   *int64     `json:"to_offset,omitempty"`
+	From       *time.Time `json:"from,omitempty"`
+	To         *time.Time `json:"to,omitempty"`
+}
+
+// Статусы задачи повторной обработки
+const (
+	ReplayRunning   = "running"
+	ReplayCompleted = "completed"
+	ReplayFailed    = "failed"
+)
+
+// ReplayJob - задача повторной обработки диапазона сообщений
+type ReplayJob struct {
+	ID         string        `json:"id"`
+	Status     string        `json:"status"`
+	Request    ReplayRequest `json:"request"`
+	Processed  int64         `json:"processed"`
+	Skipped    int64         `json:"skipped"`
+	Failed     int64         `json:"failed"`
+	Error      string        `json:"error,omitempty"`
+	StartedAt  time.Time     `json:"started_at"`
+	FinishedAt *time.Time    `json:"finished_at,omitempty"`
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// DeadLetter - сообщение из DLQ-топика вместе с информацией об ошибке обработки
+type DeadLetter struct {
+	Topic           string            `json:"topic"` // DLQ-топик
+	Partition       int               `json:"partition"`
+	Offset          int64             `json:"offset"`
+	Time            time.Time         `json:"time"`
+	Key             string            `json:"key"`
+	Value           string            `json:"value"`
+	Headers         map[string]string `json:"headers"`
+	Error           string            `json:"error"`
+	Stage           string            `json:"stage"`
+	Attempts        int               `json:"attempts"`
+	SourceTopic     string            `json:"source_topic"`
+	SourcePartition int               `json:"source_partition"`
+	SourceOffset    int64             `json:"source_offset"`
+}
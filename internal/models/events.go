@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// Статусы заказа
+const (
+	OrderStatusCreated   = "created"
+	OrderStatusCancelled = "cancelled"
+)
+
+// OrderStatusUpdate - событие изменения статуса заказа
+type OrderStatusUpdate struct {
+	OrderUID  string    `json:"order_uid"`
+	Status    string    `json:"status"`
+	Reason    string    `json:"reason"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// PaymentEvent - событие платежной системы по заказу
+type PaymentEvent struct {
+	OrderUID    string `json:"order_uid"`
+	Transaction string `json:"transaction"`
+	Status      string `json:"status"`
+	PaymentDt   int64  `json:"payment_dt"`
+}
+
+// OrderCancellation - событие отмены заказа
+type OrderCancellation struct {
+	OrderUID    string    `json:"order_uid"`
+	Reason      string    `json:"reason"`
+	CancelledAt time.Time `json:"cancelled_at"`
+}
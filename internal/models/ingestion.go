@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// OrderIngestion - сообщение, из которого был сохранен заказ: по нему заказ
+// можно найти в исходном топике. Для сообщений из топиков повторной обработки
+// указывается исходное положение сообщения
+type OrderIngestion struct {
+	Topic         string     `json:"topic" db:"source_topic"`
+	Partition     int        `json:"partition" db:"source_partition"`
+	Offset        int64      `json:"offset" db:"source_offset"`
+	Key           string     `json:"key" db:"message_key"`
+	MessageTime   *time.Time `json:"message_time,omitempty" db:"message_time"`
+	ContentType   string     `json:"content_type" db:"content_type"`
+	TraceID       string     `json:"trace_id,omitempty" db:"trace_id"`
+	ProducerID    string     `json:"producer_id,omitempty" db:"producer_id"`
+	SchemaVersion string     `json:"schema_version,omitempty" db:"schema_version"`
+	IngestedAt    time.Time  `json:"ingested_at" db:"ingested_at"`
+}
@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// Виды нарушений целостности данных заказов
+const (
+	IntegrityMissingDelivery = "missing_delivery" // заказ без доставки
+	IntegrityMissingPayment  = "missing_payment"  // заказ без платежа
+	IntegrityMissingItems    = "missing_items"    // заказ без товаров
+	IntegrityOrphaned        = "orphaned"         // строка без заказа
+	IntegrityDuplicate       = "duplicate"        // вторая доставка или платеж заказа
+)
+
+// IntegrityIssue - нарушение одного вида в одной таблице. Samples - order_uid затронутых
+// заказов (для строк без order_uid - id строки), не больше IntegritySampleSize
+type IntegrityIssue struct {
+	Kind    string   `json:"kind"`
+	Table   string   `json:"table"`
+	Count   int      `json:"count"`
+	Samples []string `json:"samples,omitempty"`
+}
+
+// IntegrityReport - результат проверки целостности
+type IntegrityReport struct {
+	CheckedAt time.Time        `json:"checked_at"`
+	Orders    int              `json:"orders"`
+	Issues    []IntegrityIssue `json:"issues"`
+}
+
+// QuarantineReport - строки, перенесенные в карантин: Count в Issues - количество строк
+type QuarantineReport struct {
+	QuarantinedAt time.Time        `json:"quarantined_at"`
+	Orders        int              `json:"orders"` // удаленных неполных заказов
+	Issues        []IntegrityIssue `json:"issues"`
+}
+
+// IntegritySampleSize - сколько примеров каждого нарушения попадает в отчет
+const IntegritySampleSize = 20
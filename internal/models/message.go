@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// Message - сообщение брокера, не зависящее от конкретной реализации (Kafka, NATS, память).
+// Partition и Offset задают положение сообщения в топике; у брокеров без партиций Partition = 0
+type Message struct {
+	Topic         string
+	Partition     int
+	Offset        int64
+	HighWaterMark int64 // офсет, следующий за последним сообщением партиции (0 - неизвестен)
+	Key           []byte
+	Value         []byte
+	Headers       []MessageHeader
+	Time          time.Time
+}
+
+// MessageHeader - заголовок сообщения
+type MessageHeader struct {
+	Key   string
+	Value []byte
+}
+
+// OrderPayload - закодированный заказ из сообщения, его content-type и сведения о сообщении
+type OrderPayload struct {
+	Data        []byte
+	ContentType string          // пустой content-type означает JSON
+	Ingestion   *OrderIngestion // nil, если заказ получен не из брокера
+}
@@ -17,6 +17,7 @@ type Order struct {
 	SmID              int       `json:"sm_id" db:"sm_id"`
 	DateCreated       time.Time `json:"date_created" db:"date_created"`
 	OofShard          string    `json:"oof_shard" db:"oof_shard"`
+	Status            string    `json:"status,omitempty" db:"status"`
 }
 
 type Delivery struct {
@@ -40,6 +41,7 @@ type Payment struct {
 	DeliveryCost int    `json:"delivery_cost" db:"delivery_cost"`
 	GoodsTotal   int    `json:"goods_total" db:"goods_total"`
 	CustomFee    int    `json:"custom_fee" db:"custom_fee"`
+	Status       string `json:"status,omitempty" db:"status"`
 }
 
 type Item struct {
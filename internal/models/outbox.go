@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// Типы событий заказа, которые публикуются через outbox
+const (
+	EventOrderCreated = "order.created"
+	EventOrderUpdated = "order.updated"
+)
+
+// OrderEvent - событие заказа для внешних потребителей
+type OrderEvent struct {
+	Type       string    `json:"type"`
+	OrderUID   string    `json:"order_uid"`
+	OccurredAt time.Time `json:"occurred_at"`
+
+	Order         *Order `json:"order,omitempty"`          // order.created: заказ целиком
+	Status        string `json:"status,omitempty"`         // order.updated: новый статус заказа
+	Reason        string `json:"reason,omitempty"`         // order.updated: причина смены статуса
+	PaymentStatus string `json:"payment_status,omitempty"` // order.updated: новый статус платежа
+}
+
+// NewOrderCreatedEvent - событие о сохранении нового заказа
+func NewOrderCreatedEvent(order *Order) OrderEvent {
+	return OrderEvent{
+		Type:       EventOrderCreated,
+		OrderUID:   order.OrderUID,
+		OccurredAt: time.Now().UTC(),
+		Order:      order,
+		Status:     OrderStatusCreated,
+	}
+}
+
+// NewOrderUpdatedEvent - событие об изменении статуса заказа или платежа
+func NewOrderUpdatedEvent(orderUID string) OrderEvent {
+	return OrderEvent{
+		Type:       EventOrderUpdated,
+		OrderUID:   orderUID,
+		OccurredAt: time.Now().UTC(),
+	}
+}
+
+// OutboxEvent - событие, сохраненное в outbox и ожидающее отправки
+type OutboxEvent struct {
+	ID          int64     `db:"id"`
+	AggregateID string    `db:"aggregate_id"`
+	EventType   string    `db:"event_type"`
+	Payload     []byte    `db:"payload"`
+	CreatedAt   time.Time `db:"created_at"`
+	Attempts    int       `db:"attempts"`
+}
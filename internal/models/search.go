@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// OrderFilter - условия выборки заказов. Пустые поля не ограничивают выборку
+type OrderFilter struct {
+	Query       string     // подстрока order_uid, track_number, customer_id, имени или email получателя
+	CustomerID  string     // точное совпадение
+	TrackNumber string     // точное совпадение
+	Status      string     // статус заказа
+	From        *time.Time // date_created >= From
+	To          *time.Time // date_created < To
+	Limit       int        // 0 - без ограничения
+	Offset      int
+}
+
+// OrderSummary - краткие сведения о заказе для списков
+type OrderSummary struct {
+	OrderUID        string    `json:"order_uid" db:"order_uid"`
+	TrackNumber     string    `json:"track_number" db:"track_number"`
+	CustomerID      string    `json:"customer_id" db:"customer_id"`
+	Status          string    `json:"status" db:"status"`
+	Locale          string    `json:"locale" db:"locale"`
+	DeliveryService string    `json:"delivery_service" db:"delivery_service"`
+	DateCreated     time.Time `json:"date_created" db:"date_created"`
+	Currency        string    `json:"currency" db:"currency"`
+	Amount          int       `json:"amount" db:"amount"`
+	Items           int       `json:"items" db:"items"`
+}
@@ -0,0 +1,65 @@
+package models
+
+import "time"
+
+// Статусы доставки webhook
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliveryDelivered = "delivered"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// WebhookSubscription - подписка партнера на события заказов.
+// Secret возвращается только при создании подписки
+type WebhookSubscription struct {
+	ID                  int64      `json:"id" db:"id"`
+	URL                 string     `json:"url" db:"url"`
+	EventTypes          []string   `json:"event_types" db:"-"`
+	Secret              string     `json:"secret,omitempty" db:"secret"`
+	Active              bool       `json:"active" db:"active"`
+	ConsecutiveFailures int        `json:"consecutive_failures" db:"consecutive_failures"`
+	DisabledAt          *time.Time `json:"disabled_at,omitempty" db:"disabled_at"`
+	CreatedAt           time.Time  `json:"created_at" db:"created_at"`
+}
+
+// WebhookSubscriptionRequest - запрос на создание подписки.
+// Пустой список событий означает все события, пустой secret генерируется сервисом
+type WebhookSubscriptionRequest struct {
+	URL        string   `json:"url"`
+	EventTypes []string `json:"event_types"`
+	Secret     string   `json:"secret,omitempty"`
+}
+
+// WebhookDelivery - запись журнала доставки события по подписке
+type WebhookDelivery struct {
+	ID             int64      `json:"id" db:"id"`
+	SubscriptionID int64      `json:"subscription_id" db:"subscription_id"`
+	EventType      string     `json:"event_type" db:"event_type"`
+	OrderUID       string     `json:"order_uid" db:"order_uid"`
+	Status         string     `json:"status" db:"status"`
+	Attempts       int        `json:"attempts" db:"attempts"`
+	LastStatusCode int        `json:"last_status_code,omitempty" db:"last_status_code"`
+	LastError      string     `json:"last_error,omitempty" db:"last_error"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at" db:"next_attempt_at"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty" db:"delivered_at"`
+}
+
+// WebhookTask - доставка, выбранная для отправки, вместе с адресом и секретом подписки
+type WebhookTask struct {
+	DeliveryID     int64  `db:"id"`
+	SubscriptionID int64  `db:"subscription_id"`
+	EventType      string `db:"event_type"`
+	Payload        []byte `db:"payload"`
+	Attempts       int    `db:"attempts"`
+	URL            string `db:"url"`
+	Secret         string `db:"secret"`
+}
+
+// WebhookResult - результат одной попытки доставки
+type WebhookResult struct {
+	DeliveryID     int64
+	SubscriptionID int64
+	StatusCode     int   // 0, если ответ не получен
+	Err            error // nil - доставлено
+}
@@ -0,0 +1,122 @@
+package outbox
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"time"
+
+	"order-service/internal/codec"
+	"order-service/internal/config"
+	"order-service/internal/interfaces"
+	"order-service/internal/models"
+)
+
+// Заголовки событий заказа
+const (
+	HeaderEventID   = "x-event-id"
+	HeaderEventType = "x-event-type"
+)
+
+// Relay отправляет события из таблицы outbox в топик событий заказов.
+// Ключ сообщения - order_uid, поэтому события одного заказа попадают в одну партицию по порядку
+type Relay struct {
+	repo interfaces.OutboxRepository
+	sink interfaces.MessageSink
+	cfg  config.OutboxConfig
+}
+
+func NewRelay(repo interfaces.OutboxRepository, sink interfaces.MessageSink, cfg config.OutboxConfig) *Relay {
+	// Непустая пачка и положительные интервалы: иначе ticker паникует, а цикл крутится без пауз
+	if cfg.BatchSize < 1 {
+		cfg.BatchSize = 100
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = time.Second
+	}
+	if cfg.CleanupInterval <= 0 {
+		cfg.CleanupInterval = time.Hour
+	}
+
+	return &Relay{repo: repo, sink: sink, cfg: cfg}
+}
+
+// Run отправляет события, пока не отменен контекст. Пока есть что отправлять,
+// пачки выбираются без пауз; старые отправленные события периодически удаляются
+func (r *Relay) Run(ctx context.Context) {
+	log.Printf("Outbox relay started: topic=%s", r.cfg.Topic)
+
+	cleanup := time.NewTicker(r.cfg.CleanupInterval)
+	defer cleanup.Stop()
+
+	for {
+		published, failed, err := r.repo.PublishPending(ctx, r.cfg.BatchSize, r.publish(ctx), r.retryDelay)
+		switch {
+		case err != nil && ctx.Err() == nil:
+			log.Printf("Outbox relay error: %v", err)
+		case published > 0 || failed > 0:
+			log.Printf("Outbox relay: %d events published, %d failed", published, failed)
+		}
+
+		// Полная пачка без ошибок - скорее всего, есть еще события
+		if err == nil && failed == 0 && published == r.cfg.BatchSize && ctx.Err() == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Println("Outbox relay stopped")
+			return
+		case <-cleanup.C:
+			r.cleanup(ctx)
+		case <-time.After(r.cfg.PollInterval):
+		}
+	}
+}
+
+// publish возвращает функцию отправки пачки событий одним запросом к брокеру.
+// Отправка ограничена PublishTimeout: на это время в БД заблокированы строки пачки
+func (r *Relay) publish(ctx context.Context) func([]models.OutboxEvent) error {
+	return func(events []models.OutboxEvent) error {
+		msgs := make([]models.Message, len(events))
+		for i, event := range events {
+			msgs[i] = models.Message{
+				Key:   []byte(event.AggregateID),
+				Value: event.Payload,
+				Headers: []models.MessageHeader{
+					{Key: "content-type", Value: []byte(codec.ContentTypeJSON)},
+					{Key: HeaderEventID, Value: []byte(strconv.FormatInt(event.ID, 10))},
+					{Key: HeaderEventType, Value: []byte(event.EventType)},
+				},
+			}
+		}
+
+		publishCtx, cancel := context.WithTimeout(ctx, r.cfg.PublishTimeout)
+		defer cancel()
+		return r.sink.Publish(publishCtx, msgs...)
+	}
+}
+
+// retryDelay - экспоненциальная задержка повторной отправки после attempts неудачных попыток
+func (r *Relay) retryDelay(attempts int) time.Duration {
+	delay := r.cfg.InitialBackoff
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= r.cfg.MaxBackoff {
+			return r.cfg.MaxBackoff
+		}
+	}
+	return min(delay, r.cfg.MaxBackoff)
+}
+
+// cleanup удаляет отправленные события старше срока хранения
+func (r *Relay) cleanup(ctx context.Context) {
+	deleted, err := r.repo.DeletePublished(ctx, r.cfg.Retention)
+	if err != nil {
+		log.Printf("Outbox cleanup error: %v", err)
+		return
+	}
+	if deleted > 0 {
+		log.Printf("Outbox cleanup: %d published events deleted", deleted)
+	}
+}
@@ -0,0 +1,42 @@
+package outbox
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"order-service/internal/config"
+	"order-service/internal/models"
+)
+
+type fakeRepository struct {
+	polls atomic.Int64
+}
+
+func (r *fakeRepository) PublishPending(ctx context.Context, limit int,
+	publish func([]models.OutboxEvent) error, retryDelay func(attempts int) time.Duration) (int, int, error) {
+	r.polls.Add(1)
+	return 0, 0, nil
+}
+
+func (r *fakeRepository) DeletePublished(ctx context.Context, retention time.Duration) (int64, error) {
+	return 0, nil
+}
+
+// Нулевые и отрицательные значения из окружения заменяются значениями по умолчанию:
+// relay не паникует на ticker'е и не опрашивает БД без пауз
+func TestRelayInvalidIntervals(t *testing.T) {
+	for _, d := range []time.Duration{0, -time.Second} {
+		repo := &fakeRepository{}
+		r := NewRelay(repo, nil, config.OutboxConfig{PollInterval: d, CleanupInterval: d, BatchSize: int(d)})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		r.Run(ctx)
+		cancel()
+
+		if polls := repo.polls.Load(); polls != 1 {
+			t.Errorf("interval %s: %d polls, want 1", d, polls)
+		}
+	}
+}
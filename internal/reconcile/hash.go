@@ -0,0 +1,39 @@
+package reconcile
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"slices"
+	"time"
+
+	"order-service/internal/models"
+)
+
+// Hash - хеш содержимого заказа для сверки. Заказ приводится к виду, в котором он
+// читается из БД, чтобы одинаковые заказы из кеша и БД давали одинаковый хеш
+func Hash(order *models.Order) string {
+	o := *order
+
+	// date_created хранится как TIMESTAMP без часового пояса с точностью до микросекунд
+	wall := o.DateCreated
+	o.DateCreated = time.Date(wall.Year(), wall.Month(), wall.Day(), wall.Hour(), wall.Minute(),
+		wall.Second(), wall.Nanosecond(), time.UTC).Round(time.Microsecond)
+
+	// Заказ, сохраненный без статуса, получает в БД статус по умолчанию
+	if o.Status == "" {
+		o.Status = models.OrderStatusCreated
+	}
+
+	// Порядок товаров в БД не задан
+	o.Items = slices.Clone(o.Items)
+	slices.SortFunc(o.Items, func(a, b models.Item) int {
+		return cmp.Or(cmp.Compare(a.ChrtID, b.ChrtID), cmp.Compare(a.Rid, b.Rid),
+			cmp.Compare(a.NmID, b.NmID), cmp.Compare(a.Name, b.Name))
+	})
+
+	data, _ := json.Marshal(o)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
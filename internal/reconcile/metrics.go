@@ -0,0 +1,65 @@
+package reconcile
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"order-service/internal/models"
+)
+
+// Метрики Prometheus сверки кеша с БД
+var (
+	runsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "order_service",
+		Subsystem: "cache_reconcile",
+		Name:      "runs_total",
+		Help:      "Number of cache reconciliation runs by result.",
+	}, []string{"result"})
+
+	discrepancies = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "order_service",
+		Subsystem: "cache_reconcile",
+		Name:      "discrepancies",
+		Help:      "Discrepancies between the cache and the database found by the last reconciliation run.",
+	}, []string{"kind"})
+
+	repairedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "order_service",
+		Subsystem: "cache_reconcile",
+		Name:      "repairs_total",
+		Help:      "Number of cache entries repaired by reconciliation, by result.",
+	}, []string{"result"})
+
+	durationSeconds = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "order_service",
+		Subsystem: "cache_reconcile",
+		Name:      "last_duration_seconds",
+		Help:      "Duration of the last cache reconciliation run.",
+	})
+
+	lastRunTimestamp = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "order_service",
+		Subsystem: "cache_reconcile",
+		Name:      "last_run_timestamp_seconds",
+		Help:      "Time the last cache reconciliation run finished.",
+	})
+)
+
+// observe публикует метрики завершенной сверки
+func observe(report *models.ReconcileReport, err error) {
+	durationSeconds.Set(report.FinishedAt.Sub(report.StartedAt).Seconds())
+	lastRunTimestamp.Set(float64(report.FinishedAt.Unix()))
+
+	if err != nil {
+		runsTotal.WithLabelValues("error").Inc()
+		return
+	}
+	runsTotal.WithLabelValues("ok").Inc()
+
+	discrepancies.WithLabelValues("missing").Set(float64(report.Missing))
+	discrepancies.WithLabelValues("extra").Set(float64(report.Extra))
+	discrepancies.WithLabelValues("stale").Set(float64(report.Stale))
+
+	repairedTotal.WithLabelValues("ok").Add(float64(report.Repaired))
+	repairedTotal.WithLabelValues("failed").Add(float64(report.RepairFailed))
+}
@@ -0,0 +1,195 @@
+package reconcile
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"order-service/internal/config"
+	"order-service/internal/interfaces"
+	"order-service/internal/models"
+
+	apperrors "order-service/internal/errors" // кастомные ошибки
+)
+
+// ErrRunning - сверка уже выполняется
+var ErrRunning = errors.New("cache reconciliation is already running")
+
+// Reconciler сверяет содержимое кеша с БД по хешу содержимого заказов: находит заказы,
+// которых нет в кеше (missing), заказы в кеше, которых нет в БД (extra), и заказы,
+// отличающиеся от БД (stale). При repair кеш приводится к состоянию БД
+type Reconciler struct {
+	repo  interfaces.OrderRepository
+	cache interfaces.Cache
+	cfg   config.ReconcileConfig
+
+	running sync.Mutex
+
+	mu   sync.Mutex
+	last *models.ReconcileReport
+}
+
+func New(repo interfaces.OrderRepository, cache interfaces.Cache, cfg config.ReconcileConfig) *Reconciler {
+	return &Reconciler{repo: repo, cache: cache, cfg: cfg}
+}
+
+// Run выполняет сверку раз в cfg.Interval, пока не отменен контекст.
+// При неположительном интервале сверка по расписанию не запускается
+func (r *Reconciler) Run(ctx context.Context) {
+	if r.cfg.Interval <= 0 {
+		log.Printf("Cache reconciliation is not scheduled: invalid RECONCILE_INTERVAL %s", r.cfg.Interval)
+		return
+	}
+
+	log.Printf("Cache reconciliation scheduled every %s (repair=%t)", r.cfg.Interval, r.cfg.Repair)
+
+	ticker := time.NewTicker(r.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Cache reconciliation stopped")
+			return
+		case <-ticker.C:
+			_, err := r.Reconcile(ctx, r.cfg.Repair)
+			if err != nil && !errors.Is(err, ErrRunning) && ctx.Err() == nil {
+				log.Printf("Cache reconciliation failed: %v", err)
+			}
+		}
+	}
+}
+
+// Last возвращает отчет последней сверки или nil, если сверок еще не было
+func (r *Reconciler) Last() *models.ReconcileReport {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.last
+}
+
+// Reconcile выполняет сверку. Одновременно выполняется только одна сверка,
+// при попытке запустить вторую возвращается ErrRunning
+func (r *Reconciler) Reconcile(ctx context.Context, repair bool) (*models.ReconcileReport, error) {
+	if !r.running.TryLock() {
+		return nil, ErrRunning
+	}
+	defer r.running.Unlock()
+
+	report := &models.ReconcileReport{
+		StartedAt:   time.Now().UTC(),
+		Repair:      repair,
+		MissingUIDs: []string{},
+		ExtraUIDs:   []string{},
+		StaleUIDs:   []string{},
+	}
+
+	err := r.reconcile(ctx, report)
+	report.FinishedAt = time.Now().UTC()
+	if err != nil {
+		report.Error = err.Error()
+	}
+	observe(report, err)
+
+	r.mu.Lock()
+	r.last = report
+	r.mu.Unlock()
+
+	if err != nil {
+		return report, err
+	}
+
+	log.Printf("Cache reconciliation: %d orders in DB, %d in cache, %d missing, %d extra, %d stale, %d repaired",
+		report.DBOrders, report.CacheOrders, report.Missing, report.Extra, report.Stale, report.Repaired)
+	return report, nil
+}
+
+func (r *Reconciler) reconcile(ctx context.Context, report *models.ReconcileReport) error {
+	// Снимок кеша делается до чтения БД: заказ, сохраненный во время сверки,
+	// попадет в БД и кеш позже снимка и проверяется повторно перед отчетом
+	before := r.cache.Snapshot()
+	report.CacheOrders = len(before)
+
+	cached := make(map[string]string, len(before))
+	for uid, order := range before {
+		cached[uid] = Hash(order)
+	}
+
+	var missing, stale []string
+	err := r.repo.StreamOrders(ctx, models.OrderFilter{}, func(order *models.Order) error {
+		report.DBOrders++
+
+		hash, ok := cached[order.OrderUID]
+		delete(cached, order.OrderUID)
+		switch {
+		case !ok:
+			missing = append(missing, order.OrderUID)
+		case hash != Hash(order):
+			stale = append(stale, order.OrderUID)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read orders from database: %w", err)
+	}
+
+	// В cached остались заказы, которых в БД не нашлось
+	after := r.cache.Snapshot()
+	for _, uid := range missing {
+		if _, ok := after[uid]; ok {
+			continue // заказ попал в кеш во время сверки
+		}
+		report.Missing++
+		sample(&report.MissingUIDs, uid)
+		if report.Repair {
+			r.refresh(ctx, report, uid)
+		}
+	}
+
+	for _, uid := range stale {
+		if after[uid] != before[uid] {
+			continue // заказ в кеше обновился во время сверки
+		}
+		report.Stale++
+		sample(&report.StaleUIDs, uid)
+		if report.Repair {
+			r.refresh(ctx, report, uid)
+		}
+	}
+
+	for uid := range cached {
+		if after[uid] != before[uid] {
+			continue
+		}
+		if _, err := r.repo.GetOrder(ctx, uid); !errors.Is(err, apperrors.ErrOrderNotFound) {
+			continue // заказ сохранен во время сверки
+		}
+		report.Extra++
+		sample(&report.ExtraUIDs, uid)
+		if report.Repair && r.cache.Delete(uid) {
+			report.Repaired++
+		}
+	}
+
+	return nil
+}
+
+// refresh загружает заказ из БД в кеш
+func (r *Reconciler) refresh(ctx context.Context, report *models.ReconcileReport, uid string) {
+	order, err := r.repo.GetOrder(ctx, uid)
+	if err != nil {
+		log.Printf("Cache reconciliation: failed to load order %s: %v", uid, err)
+		report.RepairFailed++
+		return
+	}
+	r.cache.Set(uid, order)
+	report.Repaired++
+}
+
+func sample(uids *[]string, uid string) {
+	if len(*uids) < models.ReconcileSampleSize {
+		*uids = append(*uids, uid)
+	}
+}
@@ -0,0 +1,239 @@
+package reconcile
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"order-service/internal/cache"
+	"order-service/internal/config"
+	"order-service/internal/interfaces"
+	"order-service/internal/models"
+
+	apperrors "order-service/internal/errors" // кастомные ошибки
+)
+
+// fakeRepository - заказы "в БД". afterStream вызывается после чтения всех заказов,
+// чтобы изменить БД и кеш так, будто это произошло во время сверки
+type fakeRepository struct {
+	interfaces.OrderRepository
+	orders      map[string]*models.Order
+	afterStream func()
+}
+
+func (r *fakeRepository) StreamOrders(ctx context.Context, filter models.OrderFilter, fn func(*models.Order) error) error {
+	uids := make([]string, 0, len(r.orders))
+	for uid := range r.orders {
+		uids = append(uids, uid)
+	}
+	slices.Sort(uids)
+
+	for _, uid := range uids {
+		order := *r.orders[uid]
+		if err := fn(&order); err != nil {
+			return err
+		}
+	}
+	if r.afterStream != nil {
+		r.afterStream()
+	}
+	return nil
+}
+
+func (r *fakeRepository) GetOrder(ctx context.Context, orderUID string) (*models.Order, error) {
+	order, ok := r.orders[orderUID]
+	if !ok {
+		return nil, apperrors.ErrOrderNotFound
+	}
+	orderCopy := *order
+	return &orderCopy, nil
+}
+
+func testOrder(uid string) *models.Order {
+	return &models.Order{
+		OrderUID:    uid,
+		TrackNumber: "TRACK-" + uid,
+		Entry:       "WBIL",
+		Status:      models.OrderStatusCreated,
+		Items: []models.Item{
+			{ChrtID: 1, Rid: "a", Name: "Mascaras", Price: 453},
+			{ChrtID: 2, Rid: "b", Name: "Lipstick", Price: 1000},
+		},
+		DateCreated: time.Date(2021, 11, 26, 6, 22, 19, 0, time.UTC),
+	}
+}
+
+// Заказ в кеше (как пришел из Kafka) и он же, прочитанный из БД, дают одинаковый хеш
+func TestHash(t *testing.T) {
+	tests := []struct {
+		name   string
+		cached func(*models.Order)
+		equal  bool
+	}{
+		{"same order", func(o *models.Order) {}, true},
+		{"date_created in another time zone", func(o *models.Order) {
+			o.DateCreated = time.Date(2021, 11, 26, 6, 22, 19, 0, time.FixedZone("MSK", 3*60*60))
+		}, true},
+		{"date_created with nanoseconds", func(o *models.Order) {
+			o.DateCreated = o.DateCreated.Add(400 * time.Nanosecond)
+		}, true},
+		{"empty status", func(o *models.Order) { o.Status = "" }, true},
+		{"items in another order", func(o *models.Order) {
+			o.Items = []models.Item{o.Items[1], o.Items[0]}
+		}, true},
+		{"another date_created", func(o *models.Order) { o.DateCreated = o.DateCreated.Add(time.Microsecond) }, false},
+		{"another status", func(o *models.Order) { o.Status = models.OrderStatusCancelled }, false},
+		{"another item", func(o *models.Order) { o.Items[0].Price++ }, false},
+		{"missing item", func(o *models.Order) { o.Items = o.Items[:1] }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cached := testOrder("order")
+			cached.Items = slices.Clone(cached.Items)
+			tt.cached(cached)
+
+			if equal := Hash(cached) == Hash(testOrder("order")); equal != tt.equal {
+				t.Errorf("hashes equal = %t, want %t", equal, tt.equal)
+			}
+		})
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	tests := []struct {
+		name   string
+		repair bool
+	}{
+		{"report only", false},
+		{"repair", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeRepository{orders: map[string]*models.Order{
+				"same":    testOrder("same"),
+				"stale":   testOrder("stale"),
+				"missing": testOrder("missing"),
+			}}
+			c := cache.NewMemoryCache()
+			c.Set("same", testOrder("same"))
+			stale := testOrder("stale")
+			stale.Status = models.OrderStatusCancelled
+			c.Set("stale", stale)
+			c.Set("extra", testOrder("extra"))
+
+			r := New(repo, c, config.ReconcileConfig{})
+			report, err := r.Reconcile(context.Background(), tt.repair)
+			if err != nil {
+				t.Fatalf("Reconcile: %v", err)
+			}
+
+			if report.DBOrders != 3 || report.CacheOrders != 3 {
+				t.Errorf("%d orders in DB, %d in cache, want 3 and 3", report.DBOrders, report.CacheOrders)
+			}
+			if report.Missing != 1 || !slices.Equal(report.MissingUIDs, []string{"missing"}) {
+				t.Errorf("missing %d %v, want [missing]", report.Missing, report.MissingUIDs)
+			}
+			if report.Extra != 1 || !slices.Equal(report.ExtraUIDs, []string{"extra"}) {
+				t.Errorf("extra %d %v, want [extra]", report.Extra, report.ExtraUIDs)
+			}
+			if report.Stale != 1 || !slices.Equal(report.StaleUIDs, []string{"stale"}) {
+				t.Errorf("stale %d %v, want [stale]", report.Stale, report.StaleUIDs)
+			}
+			if r.Last() != report {
+				t.Error("Last does not return the latest report")
+			}
+
+			if !tt.repair {
+				if report.Repaired != 0 || c.Size() != 3 {
+					t.Errorf("cache changed without repair: %d repaired, %d orders", report.Repaired, c.Size())
+				}
+				return
+			}
+
+			if report.Repaired != 3 || report.RepairFailed != 0 {
+				t.Errorf("%d repaired, %d failed, want 3 and 0", report.Repaired, report.RepairFailed)
+			}
+			snapshot := c.Snapshot()
+			if len(snapshot) != len(repo.orders) {
+				t.Errorf("%d orders in cache after repair, want %d", len(snapshot), len(repo.orders))
+			}
+			for uid, order := range repo.orders {
+				if cached, ok := snapshot[uid]; !ok || Hash(cached) != Hash(order) {
+					t.Errorf("order %s differs from DB after repair", uid)
+				}
+			}
+		})
+	}
+}
+
+// Изменения во время чтения БД не считаются расхождениями: заказы проверяются
+// повторно по снимку кеша, сделанному после чтения
+func TestReconcileConcurrentChanges(t *testing.T) {
+	repo := &fakeRepository{orders: map[string]*models.Order{
+		"saved":   testOrder("saved"),
+		"updated": testOrder("updated"),
+	}}
+	c := cache.NewMemoryCache()
+	outdated := testOrder("updated")
+	outdated.Status = ""
+	outdated.Items = outdated.Items[:1]
+	c.Set("updated", outdated)
+	c.Set("created", testOrder("created"))
+	c.Set("replaced", testOrder("replaced"))
+
+	repo.afterStream = func() {
+		// Новый заказ сохранен в БД и кеш после того, как его не было в кеше
+		c.Set("saved", testOrder("saved"))
+		// Заказ в кеше обновился
+		c.Set("updated", testOrder("updated"))
+		// Заказ сохранен в БД после того, как его прочитали
+		repo.orders["created"] = testOrder("created")
+		// Заказ заменен в кеше, хотя в БД его нет
+		c.Set("replaced", testOrder("replaced"))
+	}
+
+	report, err := New(repo, c, config.ReconcileConfig{}).Reconcile(context.Background(), true)
+	if err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	if report.Missing != 0 || report.Stale != 0 || report.Extra != 0 || report.Repaired != 0 {
+		t.Errorf("concurrent changes reported: %+v", report)
+	}
+	if c.Size() != 4 {
+		t.Errorf("%d orders in cache, want 4", c.Size())
+	}
+}
+
+func TestReconcileRunning(t *testing.T) {
+	repo := &fakeRepository{orders: map[string]*models.Order{}}
+	r := New(repo, cache.NewMemoryCache(), config.ReconcileConfig{})
+
+	repo.afterStream = func() {
+		if _, err := r.Reconcile(context.Background(), false); err != ErrRunning {
+			t.Errorf("concurrent Reconcile: expected ErrRunning, got %v", err)
+		}
+	}
+	if _, err := r.Reconcile(context.Background(), false); err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+}
+
+// Сверка с неположительным интервалом не планируется, а не паникует на ticker'е
+func TestRunInvalidInterval(t *testing.T) {
+	for _, interval := range []time.Duration{0, -time.Minute} {
+		r := New(&fakeRepository{}, cache.NewMemoryCache(), config.ReconcileConfig{Enabled: true, Interval: interval})
+
+		done := make(chan struct{})
+		go func() {
+			r.Run(context.Background())
+			close(done)
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatalf("interval %s: Run is scheduled", interval)
+		}
+	}
+}
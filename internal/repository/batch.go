@@ -0,0 +1,234 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+
+	"order-service/internal/models"
+
+	apperrors "order-service/internal/errors" // кастомные ошибки
+)
+
+// Максимальное количество параметров в одном запросе PostgreSQL
+const maxQueryParams = 65535
+
+var (
+	orderColumns = []string{"order_uid", "track_number", "entry", "locale", "internal_signature",
+		"customer_id", "delivery_service", "shardkey", "sm_id", "date_created", "oof_shard"}
+	deliveryColumns = []string{"order_uid", "name", "phone", "zip", "city", "address", "region", "email"}
+	paymentColumns  = []string{"order_uid", "transaction", "request_id", "currency", "provider",
+		"amount", "payment_dt", "bank", "delivery_cost", "goods_total", "custom_fee"}
+	itemColumns = []string{"order_uid", "chrt_id", "track_number", "price", "rid", "name",
+		"sale", "size", "total_price", "nm_id", "brand", "status"}
+	ingestionColumns = []string{"order_uid", "source_topic", "source_partition", "source_offset",
+		"message_key", "message_time", "content_type", "trace_id", "producer_id", "schema_version"}
+)
+
+// CreateOrders сохраняет пачку заказов. Сначала вся пачка вставляется в одной транзакции
+// многострочными INSERT. Если это не удалось, заказы сохраняются по одному внутри
+// SAVEPOINT'ов, чтобы плохой заказ не мешал остальным.
+// ingestions[i] - сообщение, из которого получен orders[i] (может быть nil или короче orders).
+// Возвращает ошибку для каждого заказа (nil - заказ сохранен)
+func (r *OrderRepository) CreateOrders(ctx context.Context, orders []*models.Order, ingestions []*models.OrderIngestion) []error {
+	return r.createOrders(ctx, orders, ingestions, true)
+}
+
+// ImportOrders сохраняет пачку исторических заказов, как CreateOrders, но без событий:
+// в outbox и в очередь доставки webhook попадают только новые заказы, иначе импорт
+// архива отправил бы в Kafka и партнерам order.created на каждый исторический заказ
+func (r *OrderRepository) ImportOrders(ctx context.Context, orders []*models.Order, ingestions []*models.OrderIngestion) []error {
+	return r.createOrders(ctx, orders, ingestions, false)
+}
+
+// createOrders сохраняет пачку заказов. publish - записывать ли события в outbox и очередь webhook
+func (r *OrderRepository) createOrders(ctx context.Context, orders []*models.Order, ingestions []*models.OrderIngestion,
+	publish bool) []error {
+	errs := make([]error, len(orders))
+	if len(orders) == 0 {
+		return errs
+	}
+
+	// Таймаут запроса действует на всю пачку вместе с повторной вставкой по одному
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	err := wrapError(r.createOrdersBulk(ctx, orders, ingestions, publish))
+	if err == nil {
+		return errs
+	}
+
+	// Временную ошибку повторять по одному заказу бессмысленно
+	if apperrors.IsRetryable(err) {
+		return fillErrors(errs, err)
+	}
+
+	log.Printf("Batch insert of %d orders failed, isolating bad orders: %v", len(orders), err)
+	return r.createOrdersIsolated(ctx, orders, ingestions, publish)
+}
+
+// createOrdersBulk вставляет все заказы многострочными INSERT в одной транзакции
+func (r *OrderRepository) createOrdersBulk(ctx context.Context, orders []*models.Order, ingestions []*models.OrderIngestion,
+	publish bool) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var orderRows, deliveryRows, paymentRows, itemRows, ingestionRows [][]interface{}
+	var events []models.OrderEvent
+	for i, o := range orders {
+		if publish {
+			events = append(events, models.NewOrderCreatedEvent(o))
+		}
+		if ingestion := ingestionAt(ingestions, i); ingestion != nil {
+			ingestionRows = append(ingestionRows, ingestionRow(o.OrderUID, ingestion))
+		}
+
+		orderRows = append(orderRows, []interface{}{o.OrderUID, o.TrackNumber, o.Entry, o.Locale,
+			o.InternalSignature, o.CustomerID, o.DeliveryService, o.Shardkey, o.SmID,
+			o.DateCreated, o.OofShard})
+
+		d := o.Delivery
+		deliveryRows = append(deliveryRows, []interface{}{o.OrderUID, d.Name, d.Phone, d.Zip,
+			d.City, d.Address, d.Region, d.Email})
+
+		p := o.Payment
+		paymentRows = append(paymentRows, []interface{}{o.OrderUID, p.Transaction, p.RequestID,
+			p.Currency, p.Provider, p.Amount, p.PaymentDt, p.Bank, p.DeliveryCost,
+			p.GoodsTotal, p.CustomFee})
+
+		for _, i := range o.Items {
+			itemRows = append(itemRows, []interface{}{o.OrderUID, i.ChrtID, i.TrackNumber,
+				i.Price, i.Rid, i.Name, i.Sale, i.Size, i.TotalPrice, i.NmID, i.Brand, i.Status})
+		}
+	}
+
+	if err := bulkInsert(ctx, tx, "orders", orderColumns, orderRows); err != nil {
+		return err
+	}
+	if err := bulkInsert(ctx, tx, "deliveries", deliveryColumns, deliveryRows); err != nil {
+		return err
+	}
+	if err := bulkInsert(ctx, tx, "payments", paymentColumns, paymentRows); err != nil {
+		return err
+	}
+	if err := bulkInsert(ctx, tx, "items", itemColumns, itemRows); err != nil {
+		return err
+	}
+	if err := bulkInsert(ctx, tx, "order_ingestion", ingestionColumns, ingestionRows); err != nil {
+		return err
+	}
+	if err := insertEvents(ctx, tx, events...); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// createOrdersIsolated сохраняет заказы по одному, каждый внутри своего SAVEPOINT
+func (r *OrderRepository) createOrdersIsolated(ctx context.Context, orders []*models.Order, ingestions []*models.OrderIngestion,
+	publish bool) []error {
+	errs := make([]error, len(orders))
+	var events []models.OrderEvent
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fillErrors(errs, wrapError(err))
+	}
+	defer tx.Rollback()
+
+	for i, order := range orders {
+		if _, err := tx.ExecContext(ctx, "SAVEPOINT batch_order"); err != nil {
+			return fillErrors(errs, wrapError(err))
+		}
+
+		if err := insertOrder(ctx, tx, order, ingestionAt(ingestions, i)); err != nil {
+			errs[i] = wrapError(err)
+			if _, err := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT batch_order"); err != nil {
+				return fillErrors(errs, wrapError(err))
+			}
+			continue
+		}
+
+		if _, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT batch_order"); err != nil {
+			return fillErrors(errs, wrapError(err))
+		}
+		if publish {
+			events = append(events, models.NewOrderCreatedEvent(order))
+		}
+	}
+
+	if err := insertEvents(ctx, tx, events...); err != nil {
+		return fillErrors(errs, wrapError(err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fillErrors(errs, wrapError(err))
+	}
+
+	return errs
+}
+
+// bulkInsert вставляет строки многострочными INSERT, разбивая их на части
+// с учетом ограничения на количество параметров
+func bulkInsert(ctx context.Context, tx *sqlx.Tx, table string, columns []string, rows [][]interface{}) error {
+	chunkSize := maxQueryParams / len(columns)
+
+	for start := 0; start < len(rows); start += chunkSize {
+		chunk := rows[start:min(start+chunkSize, len(rows))]
+
+		var query strings.Builder
+		fmt.Fprintf(&query, "INSERT INTO %s (%s) VALUES ", table, strings.Join(columns, ", "))
+
+		args := make([]interface{}, 0, len(chunk)*len(columns))
+		for i, row := range chunk {
+			if i > 0 {
+				query.WriteString(", ")
+			}
+			query.WriteString("(")
+			for j := range row {
+				if j > 0 {
+					query.WriteString(", ")
+				}
+				fmt.Fprintf(&query, "$%d", len(args)+j+1)
+			}
+			query.WriteString(")")
+			args = append(args, row...)
+		}
+
+		if _, err := tx.ExecContext(ctx, query.String(), args...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ingestionAt возвращает сведения о сообщении i-го заказа или nil
+func ingestionAt(ingestions []*models.OrderIngestion, i int) *models.OrderIngestion {
+	if i < len(ingestions) {
+		return ingestions[i]
+	}
+	return nil
+}
+
+// ingestionRow - строка таблицы order_ingestion в порядке ingestionColumns
+func ingestionRow(orderUID string, in *models.OrderIngestion) []interface{} {
+	return []interface{}{orderUID, in.Topic, in.Partition, in.Offset, in.Key, in.MessageTime,
+		in.ContentType, in.TraceID, in.ProducerID, in.SchemaVersion}
+}
+
+// fillErrors проставляет ошибку всем заказам, для которых она еще не задана
+func fillErrors(errs []error, err error) []error {
+	for i := range errs {
+		if errs[i] == nil {
+			errs[i] = err
+		}
+	}
+	return errs
+}
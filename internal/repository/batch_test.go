@@ -0,0 +1,91 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"order-service/internal/models"
+	"order-service/internal/repository"
+
+	apperrors "order-service/internal/errors" // кастомные ошибки
+)
+
+// Если пачка не вставилась целиком, заказы сохраняются по одному: плохой заказ и повтор
+// order_uid внутри пачки отклоняются, остальные заказы сохраняются вместе со сведениями
+// о сообщении и событиями
+func TestCreateOrdersIsolatesBadOrders(t *testing.T) {
+	ctx := context.Background()
+	db := openDB(t)
+	repo := repository.NewOrderRepository(db, 0)
+
+	negative := testOrder("batch-negative")
+	negative.Payment.Amount = -1
+
+	orders := []*models.Order{
+		testOrder("batch-1"),
+		negative,
+		testOrder("batch-1"),
+		testOrder("batch-2"),
+	}
+	ingestions := make([]*models.OrderIngestion, len(orders))
+	for i := range ingestions {
+		ingestions[i] = &models.OrderIngestion{Topic: "orders", Offset: int64(i), ContentType: "application/json"}
+	}
+
+	errs := repo.CreateOrders(ctx, orders, ingestions)
+	if len(errs) != len(orders) {
+		t.Fatalf("%d errors for %d orders", len(errs), len(orders))
+	}
+	if errs[0] != nil || errs[3] != nil {
+		t.Errorf("valid orders rejected: %v, %v", errs[0], errs[3])
+	}
+	if errs[1] == nil || errors.Is(errs[1], apperrors.ErrOrderExists) || apperrors.IsRetryable(errs[1]) {
+		t.Errorf("invalid order: expected permanent error, got %v", errs[1])
+	}
+	if !errors.Is(errs[2], apperrors.ErrOrderExists) {
+		t.Errorf("duplicate order_uid: expected ErrOrderExists, got %v", errs[2])
+	}
+
+	for _, uid := range []string{"batch-1", "batch-2"} {
+		order, err := repo.GetOrder(ctx, uid)
+		if err != nil {
+			t.Errorf("GetOrder(%s): %v", uid, err)
+			continue
+		}
+		if len(order.Items) != 1 || order.Payment.Transaction != uid {
+			t.Errorf("order %s saved partially: %+v", uid, order)
+		}
+	}
+	if _, err := repo.GetOrder(ctx, "batch-negative"); !errors.Is(err, apperrors.ErrOrderNotFound) {
+		t.Errorf("rejected order: expected ErrOrderNotFound, got %v", err)
+	}
+
+	// Отклоненные заказы не оставляют строк: откат до SAVEPOINT убирает их целиком
+	for table, want := range map[string]int{"orders": 2, "deliveries": 2, "payments": 2, "items": 2, "order_ingestion": 2} {
+		var n int
+		if err := db.Get(&n, `SELECT count(*) FROM `+table+` WHERE order_uid LIKE 'batch-%'`); err != nil {
+			t.Fatalf("count %s: %v", table, err)
+		}
+		if n != want {
+			t.Errorf("%s: %d rows, want %d", table, n, want)
+		}
+	}
+
+	// Сведения о сообщении принадлежат сохраненному заказу, а не повтору
+	var offset int64
+	if err := db.Get(&offset, `SELECT source_offset FROM order_ingestion WHERE order_uid = 'batch-1'`); err != nil {
+		t.Fatalf("read ingestion: %v", err)
+	}
+	if offset != 0 {
+		t.Errorf("batch-1 ingestion offset %d, want 0", offset)
+	}
+
+	var events []string
+	if err := db.Select(&events, `SELECT aggregate_id FROM outbox WHERE aggregate_id LIKE 'batch-%' ORDER BY aggregate_id`); err != nil {
+		t.Fatalf("read outbox: %v", err)
+	}
+	if len(events) != 2 || events[0] != "batch-1" || events[1] != "batch-2" {
+		t.Errorf("outbox events for %v, want batch-1 and batch-2", events)
+	}
+}
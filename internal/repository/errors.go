@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+
+	"github.com/lib/pq"
+
+	apperrors "order-service/internal/errors" // кастомные ошибки
+)
+
+// Коды ошибок PostgreSQL, после которых запрос можно повторить
+var transientCodes = map[pq.ErrorCode]bool{
+	"40001": true, // serialization_failure
+	"40P01": true, // deadlock_detected
+	"55P03": true, // lock_not_available
+	"57014": true, // query_canceled (statement_timeout)
+	"57P01": true, // admin_shutdown
+	"57P02": true, // crash_shutdown
+	"57P03": true, // cannot_connect_now
+}
+
+const uniqueViolation = pq.ErrorCode("23505")
+
+// wrapError помечает ошибки БД: временные - как apperrors.ErrTemporary,
+// нарушение уникальности - как apperrors.ErrOrderExists
+func wrapError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+		return fmt.Errorf("%w: %w", apperrors.ErrOrderExists, err)
+	}
+
+	if isTransient(err) {
+		return fmt.Errorf("%w: %w", apperrors.ErrTemporary, err)
+	}
+
+	return err
+}
+
+// isTransient определяет, вызвана ли ошибка недоступностью БД, таймаутом или отменой
+// запроса: отмененный при остановке сервиса запрос можно повторить после перезапуска
+func isTransient(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, context.Canceled) ||
+		errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, sql.ErrConnDone) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		// Класс 08 - ошибки соединения, класс 53 - нехватка ресурсов
+		code := string(pqErr.Code)
+		return transientCodes[pqErr.Code] ||
+			strings.HasPrefix(code, "08") ||
+			strings.HasPrefix(code, "53")
+	}
+
+	return false
+}
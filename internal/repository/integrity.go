@@ -0,0 +1,189 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+
+	"order-service/internal/interfaces"
+	"order-service/internal/models"
+)
+
+// Проверка соответствия интерфейсу
+var _ interfaces.IntegrityRepository = (*OrderRepository)(nil)
+
+// integrityCheck - запрос, возвращающий по строке на нарушение: order_uid заказа
+// или, для строк без order_uid, id строки
+type integrityCheck struct {
+	kind  string
+	table string
+	query string
+}
+
+var integrityChecks = []integrityCheck{
+	{models.IntegrityMissingDelivery, "orders", missingQuery("deliveries")},
+	{models.IntegrityMissingPayment, "orders", missingQuery("payments")},
+	{models.IntegrityMissingItems, "orders", missingQuery("items")},
+	{models.IntegrityOrphaned, "deliveries", orphanedQuery("deliveries")},
+	{models.IntegrityOrphaned, "payments", orphanedQuery("payments")},
+	{models.IntegrityOrphaned, "items", orphanedQuery("items")},
+	{models.IntegrityDuplicate, "deliveries", duplicateQuery("deliveries")},
+	{models.IntegrityDuplicate, "payments", duplicateQuery("payments")},
+}
+
+// Таблицы со строками заказа, которые переносятся в карантин вместе с неполным заказом
+var orderTables = []string{"orders", "deliveries", "payments", "items", "order_ingestion"}
+
+func missingQuery(table string) string {
+	return fmt.Sprintf(`SELECT o.order_uid FROM orders o
+        WHERE NOT EXISTS (SELECT 1 FROM %s t WHERE t.order_uid = o.order_uid)`, table)
+}
+
+func orphanedCondition(table string) string {
+	return fmt.Sprintf(`%[1]s.order_uid IS NULL
+        OR NOT EXISTS (SELECT 1 FROM orders o WHERE o.order_uid = %[1]s.order_uid)`, table)
+}
+
+func orphanedQuery(table string) string {
+	return fmt.Sprintf(`SELECT COALESCE(%[1]s.order_uid, 'id=' || %[1]s.id) FROM %[1]s WHERE `, table) +
+		orphanedCondition(table)
+}
+
+// Дубликат - любая доставка (платеж) заказа, кроме первой сохраненной
+func duplicateCondition(table string) string {
+	return fmt.Sprintf(`%[1]s.order_uid IS NOT NULL
+        AND %[1]s.id > (SELECT MIN(k.id) FROM %[1]s k WHERE k.order_uid = %[1]s.order_uid)`, table)
+}
+
+func duplicateQuery(table string) string {
+	return fmt.Sprintf(`SELECT DISTINCT %[1]s.order_uid FROM %[1]s WHERE `, table) + duplicateCondition(table)
+}
+
+// CheckIntegrity ищет заказы без доставки, платежа или товаров, строки без заказа
+// и повторные доставки и платежи. Данные не изменяются
+func (r *OrderRepository) CheckIntegrity(ctx context.Context) (*models.IntegrityReport, error) {
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{ReadOnly: true, Isolation: sql.LevelRepeatableRead})
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	defer tx.Rollback()
+
+	report := &models.IntegrityReport{CheckedAt: time.Now().UTC(), Issues: []models.IntegrityIssue{}}
+	if err := tx.GetContext(ctx, &report.Orders, `SELECT COUNT(*) FROM orders`); err != nil {
+		return nil, wrapError(err)
+	}
+
+	for _, check := range integrityChecks {
+		issue, err := countIssue(ctx, tx, check.kind, check.table, check.query)
+		if err != nil {
+			return nil, fmt.Errorf("integrity check %s in %s failed: %w", check.kind, check.table, err)
+		}
+		if issue.Count > 0 {
+			report.Issues = append(report.Issues, issue)
+		}
+	}
+
+	return report, nil
+}
+
+// countIssue считает строки запроса и возвращает первые по порядку как примеры
+func countIssue(ctx context.Context, tx *sqlx.Tx, kind, table, query string) (models.IntegrityIssue, error) {
+	issue := models.IntegrityIssue{Kind: kind, Table: table}
+
+	var samples pq.StringArray
+	row := tx.QueryRowxContext(ctx, `
+        SELECT COUNT(*), COALESCE((array_agg(key ORDER BY key))[1:$1::int], '{}')
+        FROM (`+query+`) s(key)`, models.IntegritySampleSize)
+	if err := row.Scan(&issue.Count, &samples); err != nil {
+		return issue, wrapError(err)
+	}
+	issue.Samples = samples
+	return issue, nil
+}
+
+// Quarantine переносит нарушения целостности в таблицу quarantine одной транзакцией:
+// неполные заказы удаляются вместе со всеми своими строками, строки без заказа и
+// повторные доставки и платежи удаляются (у заказа остается первая сохраненная)
+func (r *OrderRepository) Quarantine(ctx context.Context) (*models.QuarantineReport, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	defer tx.Rollback()
+
+	report := &models.QuarantineReport{QuarantinedAt: time.Now().UTC(), Issues: []models.IntegrityIssue{}}
+
+	// Неполные заказы с причиной
+	_, err = tx.ExecContext(ctx, `
+        CREATE TEMP TABLE incomplete_orders ON COMMIT DROP AS
+        SELECT o.order_uid,
+               CASE WHEN NOT EXISTS (SELECT 1 FROM deliveries t WHERE t.order_uid = o.order_uid) THEN $1::varchar
+                    WHEN NOT EXISTS (SELECT 1 FROM payments t WHERE t.order_uid = o.order_uid) THEN $2::varchar
+                    ELSE $3::varchar END AS reason
+        FROM orders o
+        WHERE NOT EXISTS (SELECT 1 FROM deliveries t WHERE t.order_uid = o.order_uid)
+           OR NOT EXISTS (SELECT 1 FROM payments t WHERE t.order_uid = o.order_uid)
+           OR NOT EXISTS (SELECT 1 FROM items t WHERE t.order_uid = o.order_uid)`,
+		models.IntegrityMissingDelivery, models.IntegrityMissingPayment, models.IntegrityMissingItems)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+
+	for _, kind := range []string{models.IntegrityMissingDelivery, models.IntegrityMissingPayment, models.IntegrityMissingItems} {
+		issue, err := countIssue(ctx, tx, kind, "orders",
+			`SELECT order_uid FROM incomplete_orders WHERE reason = '`+kind+`'`)
+		if err != nil {
+			return nil, err
+		}
+		if issue.Count > 0 {
+			report.Issues = append(report.Issues, issue)
+		}
+	}
+
+	for _, table := range orderTables {
+		_, err := tx.ExecContext(ctx, fmt.Sprintf(`
+            INSERT INTO quarantine (source_table, order_uid, reason, data)
+            SELECT '%[1]s', t.order_uid, b.reason, to_jsonb(t)
+            FROM %[1]s t JOIN incomplete_orders b ON b.order_uid = t.order_uid`, table))
+		if err != nil {
+			return nil, wrapError(err)
+		}
+	}
+
+	// Строки заказа удаляются каскадно
+	result, err := tx.ExecContext(ctx, `DELETE FROM orders WHERE order_uid IN (SELECT order_uid FROM incomplete_orders)`)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	orders, _ := result.RowsAffected()
+	report.Orders = int(orders)
+
+	moves := []struct{ kind, table, condition string }{
+		{models.IntegrityOrphaned, "deliveries", orphanedCondition("deliveries")},
+		{models.IntegrityOrphaned, "payments", orphanedCondition("payments")},
+		{models.IntegrityOrphaned, "items", orphanedCondition("items")},
+		{models.IntegrityDuplicate, "deliveries", duplicateCondition("deliveries")},
+		{models.IntegrityDuplicate, "payments", duplicateCondition("payments")},
+	}
+	for _, m := range moves {
+		result, err := tx.ExecContext(ctx, fmt.Sprintf(`
+            WITH moved AS (DELETE FROM %[1]s WHERE %[2]s RETURNING *)
+            INSERT INTO quarantine (source_table, order_uid, reason, data)
+            SELECT '%[1]s', moved.order_uid, $1::varchar, to_jsonb(moved) FROM moved`, m.table, m.condition), m.kind)
+		if err != nil {
+			return nil, fmt.Errorf("failed to quarantine %s rows of %s: %w", m.kind, m.table, wrapError(err))
+		}
+		if n, _ := result.RowsAffected(); n > 0 {
+			report.Issues = append(report.Issues, models.IntegrityIssue{Kind: m.kind, Table: m.table, Count: int(n)})
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, wrapError(err)
+	}
+	return report, nil
+}
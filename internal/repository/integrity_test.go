@@ -0,0 +1,140 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"order-service/internal/app"
+	"order-service/internal/cache"
+	"order-service/internal/codec"
+	"order-service/internal/config"
+	"order-service/internal/models"
+	"order-service/internal/repository"
+	"order-service/internal/service"
+	"order-service/internal/testdb"
+	"order-service/internal/transport/http/handlers"
+
+	apperrors "order-service/internal/errors" // кастомные ошибки
+)
+
+// Нарушения целостности остаются от данных, сохраненных до миграции 000007: схема
+// поднимается до версии 6, в которой строки без заказа и повторные платежи еще допустимы.
+// Тестовый заказ миграции 000001 удаляется, как это делает миграция 000008
+func TestIntegrityCheckAndQuarantine(t *testing.T) {
+	ctx := context.Background()
+	db := testdb.Open(t, "repository_integrity_test")
+	_, err := app.Migrate(ctx, db, config.MigrationsConfig{LockTimeout: time.Minute},
+		app.MigrationCommand{Name: "goto", N: 6})
+	if err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	repo := repository.NewOrderRepository(db, 0)
+
+	for _, uid := range []string{"complete", "no-delivery"} {
+		if err := repo.CreateOrder(ctx, testOrder(uid), nil); err != nil {
+			t.Fatalf("CreateOrder: %v", err)
+		}
+	}
+	_, err = db.Exec(`
+        DELETE FROM orders WHERE order_uid = 'b563feb7b2b84b6test';
+        DELETE FROM deliveries WHERE order_uid = 'no-delivery';
+        INSERT INTO payments (order_uid, transaction, amount) VALUES ('complete', 'second', 1);
+    `)
+	if err != nil {
+		t.Fatalf("break orders: %v", err)
+	}
+	var orphanedID int
+	err = db.QueryRow(`INSERT INTO items (order_uid, name) VALUES (NULL, 'orphaned') RETURNING id`).Scan(&orphanedID)
+	if err != nil {
+		t.Fatalf("insert orphaned item: %v", err)
+	}
+
+	report, err := repo.CheckIntegrity(ctx)
+	if err != nil {
+		t.Fatalf("CheckIntegrity: %v", err)
+	}
+	wantIssues := []models.IntegrityIssue{
+		{Kind: models.IntegrityMissingDelivery, Table: "orders", Count: 1, Samples: []string{"no-delivery"}},
+		{Kind: models.IntegrityOrphaned, Table: "items", Count: 1, Samples: []string{"id=" + strconv.Itoa(orphanedID)}},
+		{Kind: models.IntegrityDuplicate, Table: "payments", Count: 1, Samples: []string{"complete"}},
+	}
+	if report.Orders != 2 || !reflect.DeepEqual(report.Issues, wantIssues) {
+		t.Errorf("CheckIntegrity: %d orders, issues %+v, want 2 and %+v", report.Orders, report.Issues, wantIssues)
+	}
+
+	// Неполный заказ не отдается частично
+	if _, err := repo.GetOrder(ctx, "no-delivery"); !errors.Is(err, apperrors.ErrOrderIncomplete) {
+		t.Errorf("GetOrder: expected ErrOrderIncomplete, got %v", err)
+	}
+	if code := getOrderStatus(t, repo, "no-delivery"); code != http.StatusUnprocessableEntity {
+		t.Errorf("GET /order/no-delivery: %d, want 422", code)
+	}
+
+	quarantined, err := repo.Quarantine(ctx)
+	if err != nil {
+		t.Fatalf("Quarantine: %v", err)
+	}
+	wantMoved := []models.IntegrityIssue{
+		{Kind: models.IntegrityMissingDelivery, Table: "orders", Count: 1, Samples: []string{"no-delivery"}},
+		{Kind: models.IntegrityOrphaned, Table: "items", Count: 1},
+		{Kind: models.IntegrityDuplicate, Table: "payments", Count: 1},
+	}
+	if quarantined.Orders != 1 || !reflect.DeepEqual(quarantined.Issues, wantMoved) {
+		t.Errorf("Quarantine: %d orders, issues %+v, want 1 and %+v", quarantined.Orders, quarantined.Issues, wantMoved)
+	}
+
+	// Неполный заказ переносится со всеми строками, у полного остается первый платеж
+	var rows []string
+	err = db.Select(&rows, `
+        SELECT source_table || ':' || COALESCE(order_uid, '-') || ':' || reason
+        FROM quarantine ORDER BY 1
+    `)
+	if err != nil {
+		t.Fatalf("read quarantine: %v", err)
+	}
+	wantRows := []string{
+		"items:-:orphaned",
+		"items:no-delivery:missing_delivery",
+		"orders:no-delivery:missing_delivery",
+		"payments:complete:duplicate",
+		"payments:no-delivery:missing_delivery",
+	}
+	if strings.Join(rows, ",") != strings.Join(wantRows, ",") {
+		t.Errorf("quarantined %v, want %v", rows, wantRows)
+	}
+
+	order, err := repo.GetOrder(ctx, "complete")
+	if err != nil || order.Payment.Transaction != "complete" {
+		t.Errorf("complete order after quarantine: %+v, %v", order, err)
+	}
+	if _, err := repo.GetOrder(ctx, "no-delivery"); !errors.Is(err, apperrors.ErrOrderNotFound) {
+		t.Errorf("quarantined order: expected ErrOrderNotFound, got %v", err)
+	}
+
+	report, err = repo.CheckIntegrity(ctx)
+	if err != nil || report.Orders != 1 || len(report.Issues) != 0 {
+		t.Errorf("CheckIntegrity after quarantine: %+v, %v", report, err)
+	}
+}
+
+// getOrderStatus возвращает код ответа GET /order/{order_uid}
+func getOrderStatus(t *testing.T, repo *repository.OrderRepository, uid string) int {
+	t.Helper()
+
+	svc := service.NewOrderService(repo, cache.NewMemoryCache(), codec.New(nil))
+	router := mux.NewRouter()
+	router.HandleFunc("/order/{order_uid}", handlers.NewOrderHandler(svc).GetOrder)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/order/"+uid, nil))
+	return rec.Code
+}
@@ -1,32 +1,70 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
+	"log"
 	"order-service/internal/models"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
 
-	apperrors "order-service/internal/errors" // кастомнаые ошибки
+	apperrors "order-service/internal/errors" // кастомные ошибки
 )
 
 type OrderRepository struct {
 	db *sqlx.DB
+
+	// queryTimeout ограничивает один вызов метода репозитория (0 - без ограничения)
+	queryTimeout time.Duration
 }
 
-func NewOrderRepository(db *sqlx.DB) *OrderRepository {
-	return &OrderRepository{db: db}
+func NewOrderRepository(db *sqlx.DB, queryTimeout time.Duration) *OrderRepository {
+	return &OrderRepository{db: db, queryTimeout: queryTimeout}
 }
 
-func (r *OrderRepository) CreateOrder(order *models.Order) error {
-	tx, err := r.db.Beginx()
+// withTimeout добавляет к контексту вызова таймаут запроса. Более ранний срок
+// из контекста (HTTP-запрос, остановка consumer'а) сохраняется
+func (r *OrderRepository) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if r.queryTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, r.queryTimeout)
+}
+
+// CreateOrder сохраняет заказ. ingestion - сообщение, из которого получен заказ (может быть nil)
+func (r *OrderRepository) CreateOrder(ctx context.Context, order *models.Order, ingestion *models.OrderIngestion) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	return wrapError(r.createOrder(ctx, order, ingestion))
+}
+
+func (r *OrderRepository) createOrder(ctx context.Context, order *models.Order, ingestion *models.OrderIngestion) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback()
 
+	if err := insertOrder(ctx, tx, order, ingestion); err != nil {
+		return err
+	}
+
+	// События о заказе отправятся через outbox и webhook после фиксации транзакции
+	if err := insertEvents(ctx, tx, models.NewOrderCreatedEvent(order)); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// insertOrder вставляет заказ со всеми связанными записями в рамках транзакции
+func insertOrder(ctx context.Context, tx *sqlx.Tx, order *models.Order, ingestion *models.OrderIngestion) error {
 	// Вставка основного заказа
-	_, err = tx.NamedExec(`
+	_, err := tx.NamedExecContext(ctx, `
         INSERT INTO orders (order_uid, track_number, entry, locale, 
                           internal_signature, customer_id, delivery_service, 
                           shardkey, sm_id, date_created, oof_shard)
@@ -44,7 +82,7 @@ func (r *OrderRepository) CreateOrder(order *models.Order) error {
         INSERT INTO deliveries (order_uid, name, phone, zip, city, address, region, email)
         VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
     `
-	_, err = tx.Exec(deliveryQuery, order.OrderUID, order.Delivery.Name,
+	_, err = tx.ExecContext(ctx, deliveryQuery, order.OrderUID, order.Delivery.Name,
 		order.Delivery.Phone, order.Delivery.Zip, order.Delivery.City,
 		order.Delivery.Address, order.Delivery.Region, order.Delivery.Email)
 	if err != nil {
@@ -57,7 +95,7 @@ func (r *OrderRepository) CreateOrder(order *models.Order) error {
                             amount, payment_dt, bank, delivery_cost, goods_total, custom_fee)
         VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
     `
-	_, err = tx.Exec(paymentQuery, order.OrderUID, order.Payment.Transaction,
+	_, err = tx.ExecContext(ctx, paymentQuery, order.OrderUID, order.Payment.Transaction,
 		order.Payment.RequestID, order.Payment.Currency, order.Payment.Provider,
 		order.Payment.Amount, order.Payment.PaymentDt, order.Payment.Bank,
 		order.Payment.DeliveryCost, order.Payment.GoodsTotal, order.Payment.CustomFee)
@@ -72,7 +110,7 @@ func (r *OrderRepository) CreateOrder(order *models.Order) error {
                              sale, size, total_price, nm_id, brand, status)
             VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
         `
-		_, err = tx.Exec(itemQuery, order.OrderUID, item.ChrtID, item.TrackNumber,
+		_, err = tx.ExecContext(ctx, itemQuery, order.OrderUID, item.ChrtID, item.TrackNumber,
 			item.Price, item.Rid, item.Name, item.Sale, item.Size,
 			item.TotalPrice, item.NmID, item.Brand, item.Status)
 		if err != nil {
@@ -80,16 +118,48 @@ func (r *OrderRepository) CreateOrder(order *models.Order) error {
 		}
 	}
 
-	return tx.Commit()
+	// Сведения о сообщении, из которого получен заказ
+	if ingestion != nil {
+		row := ingestionRow(order.OrderUID, ingestion)
+		if err := bulkInsert(ctx, tx, "order_ingestion", ingestionColumns, [][]interface{}{row}); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
-func (r *OrderRepository) GetOrder(orderUID string) (*models.Order, error) {
+// GetOrderIngestion возвращает сведения о сообщении, из которого получен заказ.
+// Для заказов, сохраненных не из брокера, возвращает nil
+func (r *OrderRepository) GetOrderIngestion(ctx context.Context, orderUID string) (*models.OrderIngestion, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	var ingestion models.OrderIngestion
+	err := r.db.GetContext(ctx, &ingestion, `
+        SELECT source_topic, source_partition, source_offset, message_key, message_time,
+               content_type, trace_id, producer_id, schema_version, ingested_at
+        FROM order_ingestion WHERE order_uid = $1
+    `, orderUID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	return &ingestion, nil
+}
+
+func (r *OrderRepository) GetOrder(ctx context.Context, orderUID string) (*models.Order, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
 	var order models.Order
 
 	// Получаем основную информацию о заказе
-	err := r.db.Get(&order, `
+	err := r.db.GetContext(ctx, &order, `
         SELECT order_uid, track_number, entry, locale, internal_signature,
-               customer_id, delivery_service, shardkey, sm_id, date_created, oof_shard
+               customer_id, delivery_service, shardkey, sm_id, date_created, oof_shard, status
         FROM orders WHERE order_uid = $1
     `, orderUID)
 
@@ -98,67 +168,75 @@ func (r *OrderRepository) GetOrder(orderUID string) (*models.Order, error) {
 			return nil, apperrors.ErrOrderNotFound
 		}
 
-		return nil, err
+		return nil, wrapError(err)
 	}
 
 	// Получаем информацию о доставке
-	err = r.db.Get(&order.Delivery, `
+	err = r.db.GetContext(ctx, &order.Delivery, `
         SELECT name, phone, zip, city, address, region, email
         FROM deliveries WHERE order_uid = $1
     `, orderUID)
 
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: order %s has no delivery", apperrors.ErrOrderIncomplete, orderUID)
+	}
 	if err != nil {
-		return nil, err
+		return nil, wrapError(err)
 	}
 
 	// Получаем информацию о платеже
-	err = r.db.Get(&order.Payment, `
+	err = r.db.GetContext(ctx, &order.Payment, `
         SELECT transaction, request_id, currency, provider, amount,
-               payment_dt, bank, delivery_cost, goods_total, custom_fee
+               payment_dt, bank, delivery_cost, goods_total, custom_fee, status
         FROM payments WHERE order_uid = $1
     `, orderUID)
 
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: order %s has no payment", apperrors.ErrOrderIncomplete, orderUID)
+	}
 	if err != nil {
-		return nil, err
+		return nil, wrapError(err)
 	}
 
 	// Получаем товары
-	err = r.db.Select(&order.Items, `
+	err = r.db.SelectContext(ctx, &order.Items, `
         SELECT chrt_id, track_number, price, rid, name, sale, size,
                total_price, nm_id, brand, status
         FROM items WHERE order_uid = $1
     `, orderUID)
 
 	if err != nil {
-		return nil, err
+		return nil, wrapError(err)
+	}
+	if len(order.Items) == 0 {
+		return nil, fmt.Errorf("%w: order %s has no items", apperrors.ErrOrderIncomplete, orderUID)
 	}
 
 	return &order, nil
 }
 
-func (r *OrderRepository) GetAllOrders() ([]models.Order, error) {
+// GetAllOrders загружает все заказы. Таймаут запроса действует на каждое обращение к БД
+// отдельно: на чтение списка order_uid и на чтение каждого заказа
+func (r *OrderRepository) GetAllOrders(ctx context.Context) ([]models.Order, error) {
 	var orders []models.Order
 
-	rows, err := r.db.Query(`
+	var orderUIDs []string
+	listCtx, cancel := r.withTimeout(ctx)
+	defer cancel()
+	if err := r.db.SelectContext(listCtx, &orderUIDs, `
         SELECT DISTINCT order_uid FROM orders
-    `)
-	if err != nil {
-		return nil, err
+    `); err != nil {
+		return nil, wrapError(err)
 	}
-	defer rows.Close()
 
-	var orderUIDs []string
-	for rows.Next() {
-		var uid string
-		if err := rows.Scan(&uid); err != nil {
-			return nil, err
+	for _, uid := range orderUIDs {
+		if err := ctx.Err(); err != nil {
+			return nil, wrapError(err)
 		}
-		orderUIDs = append(orderUIDs, uid)
-	}
 
-	for _, uid := range orderUIDs {
-		order, err := r.GetOrder(uid)
+		order, err := r.GetOrder(ctx, uid)
 		if err != nil {
+			log.Printf("Skipping order %s: %v", uid, err)
 			continue // Пропускаем поврежденные записи
 		}
 		orders = append(orders, *order)
@@ -166,3 +244,64 @@ func (r *OrderRepository) GetAllOrders() ([]models.Order, error) {
 
 	return orders, nil
 }
+
+// UpdateOrderStatus меняет статус заказа и сохраняет событие order.updated
+func (r *OrderRepository) UpdateOrderStatus(ctx context.Context, orderUID, status, reason string, updatedAt time.Time) error {
+	event := models.NewOrderUpdatedEvent(orderUID)
+	event.Status, event.Reason, event.OccurredAt = status, reason, updatedAt
+
+	return wrapError(r.updateWithEvent(ctx, event, `
+        UPDATE orders SET status = $2, status_reason = $3, status_updated_at = $4
+        WHERE order_uid = $1
+    `, orderUID, status, reason, updatedAt))
+}
+
+// UpdatePaymentStatus меняет статус платежа заказа и сохраняет событие order.updated
+func (r *OrderRepository) UpdatePaymentStatus(ctx context.Context, orderUID, status string, paymentDt int64) error {
+	event := models.NewOrderUpdatedEvent(orderUID)
+	event.PaymentStatus = status
+
+	return wrapError(r.updateWithEvent(ctx, event, `
+        UPDATE payments SET status = $2, payment_dt = COALESCE(NULLIF($3, 0), payment_dt)
+        WHERE order_uid = $1
+    `, orderUID, status, paymentDt))
+}
+
+// updateWithEvent выполняет изменение заказа и сохраняет событие в outbox
+// и очередь доставки webhook одной транзакцией
+func (r *OrderRepository) updateWithEvent(ctx context.Context, event models.OrderEvent, query string, args ...interface{}) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	if err := requireAffected(result); err != nil {
+		return err
+	}
+
+	if err := insertEvents(ctx, tx, event); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// requireAffected возвращает ErrOrderNotFound, если запрос не затронул ни одной строки
+func requireAffected(result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return apperrors.ErrOrderNotFound
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+
+	"order-service/internal/interfaces"
+	"order-service/internal/models"
+)
+
+// Проверка соответствия интерфейсу
+var _ interfaces.OutboxRepository = (*OutboxRepository)(nil)
+
+var outboxColumns = []string{"aggregate_id", "event_type", "payload"}
+
+// OutboxRepository выбирает события outbox для отправки и удаляет отправленные
+type OutboxRepository struct {
+	db *sqlx.DB
+}
+
+func NewOutboxRepository(db *sqlx.DB) *OutboxRepository {
+	return &OutboxRepository{db: db}
+}
+
+// PublishPending блокирует до limit событий, готовых к отправке, передает их publish
+// одной пачкой и в той же транзакции отмечает результат. Если отправить пачку не удалось,
+// каждое ее событие откладывается на retryDelay(attempts). Блокировки строк (SKIP LOCKED)
+// позволяют запускать отправку на нескольких репликах: каждое событие достается одной из них.
+// Событие не выбирается, пока не отправлены предыдущие события того же заказа,
+// поэтому порядок событий заказа сохраняется. Транзакция открыта, пока выполняется publish,
+// поэтому publish должен ограничивать время отправки.
+// Возвращает число отправленных и неудачных событий
+func (r *OutboxRepository) PublishPending(ctx context.Context, limit int,
+	publish func([]models.OutboxEvent) error, retryDelay func(attempts int) time.Duration) (int, int, error) {
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, 0, wrapError(err)
+	}
+	defer tx.Rollback()
+
+	var events []models.OutboxEvent
+	err = tx.SelectContext(ctx, &events, `
+        SELECT id, aggregate_id, event_type, payload, created_at, attempts
+        FROM outbox o
+        WHERE published_at IS NULL AND next_attempt_at <= now()
+          AND NOT EXISTS (
+              SELECT 1 FROM outbox p
+              WHERE p.aggregate_id = o.aggregate_id AND p.published_at IS NULL AND p.id < o.id
+          )
+        ORDER BY id
+        LIMIT $1
+        FOR UPDATE SKIP LOCKED
+    `, limit)
+	if err != nil || len(events) == 0 {
+		return 0, 0, wrapError(err)
+	}
+
+	ids := make([]int64, len(events))
+	for i, event := range events {
+		ids[i] = event.ID
+	}
+
+	published, failed := len(events), 0
+	if publishErr := publish(events); publishErr != nil {
+		published, failed = 0, len(events)
+
+		delays := make([]int64, len(events))
+		for i, event := range events {
+			delays[i] = retryDelay(event.Attempts + 1).Milliseconds()
+		}
+
+		_, err = tx.ExecContext(ctx, `
+            UPDATE outbox o
+            SET attempts = attempts + 1, last_error = $3,
+                next_attempt_at = now() + d.delay * interval '1 millisecond'
+            FROM unnest($1::bigint[], $2::float8[]) AS d(id, delay)
+            WHERE o.id = d.id
+        `, pq.Array(ids), pq.Array(delays), publishErr.Error())
+	} else {
+		_, err = tx.ExecContext(ctx, `
+            UPDATE outbox SET attempts = attempts + 1, published_at = now()
+            WHERE id = ANY($1)
+        `, pq.Array(ids))
+	}
+	if err != nil {
+		return 0, 0, wrapError(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, wrapError(err)
+	}
+	return published, failed, nil
+}
+
+// DeletePublished удаляет события, отправленные больше retention назад
+func (r *OutboxRepository) DeletePublished(ctx context.Context, retention time.Duration) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `
+        DELETE FROM outbox
+        WHERE published_at IS NOT NULL
+          AND published_at < now() - $1::float8 * interval '1 millisecond'
+    `, retention.Milliseconds())
+	if err != nil {
+		return 0, wrapError(err)
+	}
+	return result.RowsAffected()
+}
+
+// insertEvents сохраняет события заказов в рамках транзакции их изменения: в outbox
+// для отправки в Kafka и в очередь доставки партнерам.
+// Если транзакция откатится, события не уйдут ни в Kafka, ни партнерам
+func insertEvents(ctx context.Context, tx *sqlx.Tx, events ...models.OrderEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	if err := insertOutboxEvents(ctx, tx, events...); err != nil {
+		return err
+	}
+	return enqueueDeliveries(ctx, tx, events...)
+}
+
+// insertOutboxEvents сохраняет события в outbox в рамках транзакции изменения заказа
+func insertOutboxEvents(ctx context.Context, tx *sqlx.Tx, events ...models.OrderEvent) error {
+	rows := make([][]interface{}, 0, len(events))
+	for _, event := range events {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		rows = append(rows, []interface{}{event.OrderUID, event.Type, string(payload)})
+	}
+	return bulkInsert(ctx, tx, "outbox", outboxColumns, rows)
+}
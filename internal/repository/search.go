@@ -0,0 +1,190 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"order-service/internal/models"
+)
+
+// ListOrders возвращает краткие сведения о заказах по фильтру, новые первыми
+func (r *OrderRepository) ListOrders(ctx context.Context, filter models.OrderFilter) ([]models.OrderSummary, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	where, args := orderConditions(filter)
+
+	query := `
+        SELECT o.order_uid, COALESCE(o.track_number, '') AS track_number,
+               COALESCE(o.customer_id, '') AS customer_id, o.status,
+               COALESCE(o.locale, '') AS locale, COALESCE(o.delivery_service, '') AS delivery_service,
+               o.date_created, COALESCE(p.currency, '') AS currency, COALESCE(p.amount, 0) AS amount,
+               (SELECT count(*) FROM items i WHERE i.order_uid = o.order_uid) AS items
+        FROM orders o
+        LEFT JOIN LATERAL (
+            SELECT currency, amount FROM payments WHERE order_uid = o.order_uid LIMIT 1
+        ) p ON true` + where + `
+        ORDER BY o.date_created DESC, o.order_uid`
+
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+	if filter.Offset > 0 {
+		args = append(args, filter.Offset)
+		query += fmt.Sprintf(" OFFSET $%d", len(args))
+	}
+
+	var summaries []models.OrderSummary
+	if err := r.db.SelectContext(ctx, &summaries, query, args...); err != nil {
+		return nil, wrapError(err)
+	}
+	return summaries, nil
+}
+
+// orderConditions строит условие WHERE по фильтру для таблицы orders с псевдонимом o
+func orderConditions(filter models.OrderFilter) (string, []interface{}) {
+	var (
+		conds []string
+		args  []interface{}
+	)
+	add := func(cond string, arg interface{}) {
+		args = append(args, arg)
+		conds = append(conds, strings.ReplaceAll(cond, "?", fmt.Sprintf("$%d", len(args))))
+	}
+
+	if filter.Query != "" {
+		add(`(o.order_uid ILIKE ? OR o.track_number ILIKE ? OR o.customer_id ILIKE ?
+            OR EXISTS (SELECT 1 FROM deliveries d WHERE d.order_uid = o.order_uid
+                       AND (d.name ILIKE ? OR d.email ILIKE ?)))`, "%"+escapeLike(filter.Query)+"%")
+	}
+	if filter.CustomerID != "" {
+		add("o.customer_id = ?", filter.CustomerID)
+	}
+	if filter.TrackNumber != "" {
+		add("o.track_number = ?", filter.TrackNumber)
+	}
+	if filter.Status != "" {
+		add("o.status = ?", filter.Status)
+	}
+	if filter.From != nil {
+		add("o.date_created >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		add("o.date_created < ?", *filter.To)
+	}
+
+	if len(conds) == 0 {
+		return "", args
+	}
+	return "\n        WHERE " + strings.Join(conds, " AND "), args
+}
+
+// escapeLike экранирует спецсимволы шаблона LIKE
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// Сколько заказов читается из курсора за один FETCH
+const streamFetchSize = 500
+
+// streamRecord - строка курсора: заказ с доставкой и оплатой, товары - JSON массивом
+type streamRecord struct {
+	models.Order
+	ItemsJSON []byte `db:"items_json"`
+}
+
+// StreamOrders читает заказы по фильтру серверным курсором, пачками по streamFetchSize,
+// и передает их fn по одному в порядке date_created. Ошибка fn прекращает чтение.
+// Отсутствующие доставка и оплата возвращаются пустыми
+func (r *OrderRepository) StreamOrders(ctx context.Context, filter models.OrderFilter, fn func(*models.Order) error) error {
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return wrapError(err)
+	}
+	defer tx.Rollback()
+
+	where, args := orderConditions(filter)
+	query := `
+        DECLARE orders_stream NO SCROLL CURSOR FOR
+        SELECT o.order_uid, COALESCE(o.track_number, '') AS track_number, COALESCE(o.entry, '') AS entry,
+               COALESCE(o.locale, '') AS locale, COALESCE(o.internal_signature, '') AS internal_signature,
+               COALESCE(o.customer_id, '') AS customer_id, COALESCE(o.delivery_service, '') AS delivery_service,
+               COALESCE(o.shardkey, '') AS shardkey, COALESCE(o.sm_id, 0) AS sm_id, o.date_created,
+               COALESCE(o.oof_shard, '') AS oof_shard, o.status,
+               COALESCE(d.name, '') AS "delivery.name", COALESCE(d.phone, '') AS "delivery.phone",
+               COALESCE(d.zip, '') AS "delivery.zip", COALESCE(d.city, '') AS "delivery.city",
+               COALESCE(d.address, '') AS "delivery.address", COALESCE(d.region, '') AS "delivery.region",
+               COALESCE(d.email, '') AS "delivery.email",
+               COALESCE(p.transaction, '') AS "payment.transaction", COALESCE(p.request_id, '') AS "payment.request_id",
+               COALESCE(p.currency, '') AS "payment.currency", COALESCE(p.provider, '') AS "payment.provider",
+               COALESCE(p.amount, 0) AS "payment.amount", COALESCE(p.payment_dt, 0) AS "payment.payment_dt",
+               COALESCE(p.bank, '') AS "payment.bank", COALESCE(p.delivery_cost, 0) AS "payment.delivery_cost",
+               COALESCE(p.goods_total, 0) AS "payment.goods_total", COALESCE(p.custom_fee, 0) AS "payment.custom_fee",
+               COALESCE(p.status, '') AS "payment.status",
+               COALESCE((
+                   SELECT json_agg(json_build_object(
+                       'chrt_id', i.chrt_id, 'track_number', COALESCE(i.track_number, ''),
+                       'price', COALESCE(i.price, 0), 'rid', COALESCE(i.rid, ''), 'name', COALESCE(i.name, ''),
+                       'sale', COALESCE(i.sale, 0), 'size', COALESCE(i.size, ''),
+                       'total_price', COALESCE(i.total_price, 0), 'nm_id', COALESCE(i.nm_id, 0),
+                       'brand', COALESCE(i.brand, ''), 'status', COALESCE(i.status, 0)) ORDER BY i.id)
+                   FROM items i WHERE i.order_uid = o.order_uid
+               ), '[]') AS items_json
+        FROM orders o
+        LEFT JOIN LATERAL (SELECT * FROM deliveries WHERE order_uid = o.order_uid LIMIT 1) d ON true
+        LEFT JOIN LATERAL (SELECT * FROM payments WHERE order_uid = o.order_uid LIMIT 1) p ON true` + where + `
+        ORDER BY o.date_created, o.order_uid`
+
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+	if filter.Offset > 0 {
+		args = append(args, filter.Offset)
+		query += fmt.Sprintf(" OFFSET $%d", len(args))
+	}
+
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return wrapError(err)
+	}
+
+	fetch := fmt.Sprintf("FETCH %d FROM orders_stream", streamFetchSize)
+	for {
+		rows, err := tx.QueryxContext(ctx, fetch)
+		if err != nil {
+			return wrapError(err)
+		}
+
+		fetched := 0
+		for rows.Next() {
+			fetched++
+
+			var rec streamRecord
+			if err := rows.StructScan(&rec); err != nil {
+				rows.Close()
+				return err
+			}
+			if err := json.Unmarshal(rec.ItemsJSON, &rec.Items); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to decode items of order %s: %w", rec.OrderUID, err)
+			}
+			if err := fn(&rec.Order); err != nil {
+				rows.Close()
+				return err
+			}
+		}
+		if err := rows.Err(); err != nil {
+			rows.Close()
+			return wrapError(err)
+		}
+		rows.Close()
+
+		if fetched < streamFetchSize {
+			return nil
+		}
+	}
+}
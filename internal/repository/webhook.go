@@ -0,0 +1,240 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+
+	"order-service/internal/interfaces"
+	"order-service/internal/models"
+
+	apperrors "order-service/internal/errors" // кастомные ошибки
+)
+
+// Проверка соответствия интерфейсу
+var _ interfaces.WebhookRepository = (*WebhookRepository)(nil)
+
+// WebhookRepository хранит подписки на webhook и журнал доставок
+type WebhookRepository struct {
+	db *sqlx.DB
+}
+
+func NewWebhookRepository(db *sqlx.DB) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+// subscriptionRow - строка подписки: массив типов событий читается через pq.StringArray
+type subscriptionRow struct {
+	models.WebhookSubscription
+	EventTypes pq.StringArray `db:"event_types"`
+}
+
+func (row subscriptionRow) toModel() models.WebhookSubscription {
+	sub := row.WebhookSubscription
+	sub.EventTypes = row.EventTypes
+	return sub
+}
+
+const subscriptionColumns = `id, url, event_types, secret, active, consecutive_failures, disabled_at, created_at`
+
+func (r *WebhookRepository) CreateSubscription(ctx context.Context, sub *models.WebhookSubscription) error {
+	err := r.db.QueryRowxContext(ctx, `
+        INSERT INTO webhook_subscriptions (url, event_types, secret)
+        VALUES ($1, $2, $3)
+        RETURNING id, active, created_at
+    `, sub.URL, pq.Array(sub.EventTypes), sub.Secret).Scan(&sub.ID, &sub.Active, &sub.CreatedAt)
+	return wrapError(err)
+}
+
+func (r *WebhookRepository) GetSubscription(ctx context.Context, id int64) (*models.WebhookSubscription, error) {
+	var row subscriptionRow
+	err := r.db.GetContext(ctx, &row, `SELECT `+subscriptionColumns+` FROM webhook_subscriptions WHERE id = $1`, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, apperrors.ErrSubscriptionNotFound
+	}
+	if err != nil {
+		return nil, wrapError(err)
+	}
+
+	sub := row.toModel()
+	return &sub, nil
+}
+
+func (r *WebhookRepository) ListSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error) {
+	var rows []subscriptionRow
+	err := r.db.SelectContext(ctx, &rows, `SELECT `+subscriptionColumns+` FROM webhook_subscriptions ORDER BY id`)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+
+	subs := make([]models.WebhookSubscription, 0, len(rows))
+	for _, row := range rows {
+		subs = append(subs, row.toModel())
+	}
+	return subs, nil
+}
+
+func (r *WebhookRepository) DeleteSubscription(ctx context.Context, id int64) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM webhook_subscriptions WHERE id = $1`, id)
+	if err != nil {
+		return wrapError(err)
+	}
+	return requireSubscription(result)
+}
+
+// EnableSubscription снова включает подписку и сбрасывает счетчик ошибок
+func (r *WebhookRepository) EnableSubscription(ctx context.Context, id int64) error {
+	result, err := r.db.ExecContext(ctx, `
+        UPDATE webhook_subscriptions
+        SET active = TRUE, consecutive_failures = 0, disabled_at = NULL
+        WHERE id = $1
+    `, id)
+	if err != nil {
+		return wrapError(err)
+	}
+	return requireSubscription(result)
+}
+
+// ListDeliveries возвращает последние доставки по подписке, новые первыми
+func (r *WebhookRepository) ListDeliveries(ctx context.Context, subscriptionID int64, limit int) ([]models.WebhookDelivery, error) {
+	deliveries := []models.WebhookDelivery{}
+	err := r.db.SelectContext(ctx, &deliveries, `
+        SELECT id, subscription_id, event_type, order_uid, status, attempts, last_status_code,
+               last_error, next_attempt_at, created_at, delivered_at
+        FROM webhook_deliveries
+        WHERE subscription_id = $1
+        ORDER BY id DESC
+        LIMIT $2
+    `, subscriptionID, limit)
+	return deliveries, wrapError(err)
+}
+
+// enqueueDeliveries создает доставки событий для всех активных подписок на их тип
+// в рамках транзакции изменения заказа. Доставки одного заказа создаются в порядке событий
+func enqueueDeliveries(ctx context.Context, tx *sqlx.Tx, events ...models.OrderEvent) error {
+	types := make([]string, len(events))
+	uids := make([]string, len(events))
+	payloads := make([]string, len(events))
+	for i, event := range events {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		types[i], uids[i], payloads[i] = event.Type, event.OrderUID, string(payload)
+	}
+
+	_, err := tx.ExecContext(ctx, `
+        INSERT INTO webhook_deliveries (subscription_id, event_type, order_uid, payload)
+        SELECT s.id, e.event_type, e.order_uid, e.payload::jsonb
+        FROM unnest($1::text[], $2::text[], $3::text[]) WITH ORDINALITY AS e(event_type, order_uid, payload, n)
+        JOIN webhook_subscriptions s ON s.active AND e.event_type = ANY(s.event_types)
+        ORDER BY e.n, s.id
+    `, pq.Array(types), pq.Array(uids), pq.Array(payloads))
+	return err
+}
+
+// ClaimDeliveries выбирает до limit доставок, которые пора отправить, и откладывает их
+// на lease, чтобы другие реплики не отправили их одновременно. Если реплика не успеет
+// записать результат, доставка снова станет доступной после lease
+func (r *WebhookRepository) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookTask, error) {
+	var tasks []models.WebhookTask
+	err := r.db.SelectContext(ctx, &tasks, `
+        WITH claimed AS (
+            UPDATE webhook_deliveries
+            SET next_attempt_at = now() + $2::float8 * interval '1 millisecond'
+            WHERE id IN (
+                SELECT d.id FROM webhook_deliveries d
+                JOIN webhook_subscriptions s ON s.id = d.subscription_id
+                WHERE d.status = 'pending' AND d.next_attempt_at <= now() AND s.active
+                ORDER BY d.next_attempt_at, d.id
+                LIMIT $1
+                FOR UPDATE OF d SKIP LOCKED
+            )
+            RETURNING id, subscription_id, event_type, payload, attempts
+        )
+        SELECT c.id, c.subscription_id, c.event_type, c.payload, c.attempts, s.url, s.secret
+        FROM claimed c JOIN webhook_subscriptions s ON s.id = c.subscription_id
+        ORDER BY c.id
+    `, limit, lease.Milliseconds())
+	return tasks, wrapError(err)
+}
+
+// RecordResult записывает результат попытки доставки. Неудачная доставка повторяется
+// через retryDelay, пока не исчерпано maxAttempts попыток. Подписка отключается после
+// disableAfter ошибок подряд. Возвращает false, если подписка была отключена
+func (r *WebhookRepository) RecordResult(ctx context.Context, result models.WebhookResult,
+	retryDelay time.Duration, maxAttempts, disableAfter int) (bool, error) {
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, wrapError(err)
+	}
+	defer tx.Rollback()
+
+	if result.Err == nil {
+		_, err = tx.ExecContext(ctx, `
+            UPDATE webhook_deliveries
+            SET status = 'delivered', attempts = attempts + 1, last_status_code = $2,
+                last_error = '', delivered_at = now()
+            WHERE id = $1
+        `, result.DeliveryID, result.StatusCode)
+		if err != nil {
+			return false, wrapError(err)
+		}
+
+		_, err = tx.ExecContext(ctx, `
+            UPDATE webhook_subscriptions SET consecutive_failures = 0 WHERE id = $1
+        `, result.SubscriptionID)
+		if err != nil {
+			return false, wrapError(err)
+		}
+		return true, wrapError(tx.Commit())
+	}
+
+	_, err = tx.ExecContext(ctx, `
+        UPDATE webhook_deliveries
+        SET attempts = attempts + 1, last_status_code = $2, last_error = $3,
+            status = CASE WHEN attempts + 1 >= $4 THEN 'failed' ELSE 'pending' END,
+            next_attempt_at = now() + $5::float8 * interval '1 millisecond'
+        WHERE id = $1
+    `, result.DeliveryID, result.StatusCode, result.Err.Error(), maxAttempts, retryDelay.Milliseconds())
+	if err != nil {
+		return false, wrapError(err)
+	}
+
+	var active bool
+	err = tx.QueryRowxContext(ctx, `
+        UPDATE webhook_subscriptions
+        SET consecutive_failures = consecutive_failures + 1,
+            active = active AND consecutive_failures + 1 < $2,
+            disabled_at = CASE WHEN active AND consecutive_failures + 1 >= $2 THEN now() ELSE disabled_at END
+        WHERE id = $1
+        RETURNING active
+    `, result.SubscriptionID, disableAfter).Scan(&active)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Подписку удалили во время доставки
+		return false, wrapError(tx.Commit())
+	}
+	if err != nil {
+		return false, wrapError(err)
+	}
+
+	return active, wrapError(tx.Commit())
+}
+
+// requireSubscription возвращает ErrSubscriptionNotFound, если запрос не затронул ни одной строки
+func requireSubscription(result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return apperrors.ErrSubscriptionNotFound
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"order-service/internal/app"
+	"order-service/internal/config"
+	"order-service/internal/models"
+	"order-service/internal/repository"
+	"order-service/internal/testdb"
+)
+
+// Доставки webhook создаются в транзакции заказа: для нового заказа и изменения статуса -
+// по каждой активной подписке на событие, для импортированного и несохраненного - нет.
+// Импортированный заказ не создает и события outbox
+func TestWebhookDeliveriesInOrderTransaction(t *testing.T) {
+	ctx := context.Background()
+	db := openDB(t)
+	orders := repository.NewOrderRepository(db, 0)
+	webhooks := repository.NewWebhookRepository(db)
+
+	all := subscribe(t, webhooks, models.EventOrderCreated, models.EventOrderUpdated)
+	created := subscribe(t, webhooks, models.EventOrderCreated)
+	disabled := subscribe(t, webhooks, models.EventOrderCreated)
+	if _, err := db.Exec(`UPDATE webhook_subscriptions SET active = FALSE WHERE id = $1`, disabled.ID); err != nil {
+		t.Fatalf("disable subscription: %v", err)
+	}
+
+	if err := orders.CreateOrder(ctx, testOrder("order-new"), nil); err != nil {
+		t.Fatalf("CreateOrder: %v", err)
+	}
+	if err := orders.CreateOrder(ctx, testOrder("order-new"), nil); err == nil {
+		t.Fatal("expected duplicate order to be rejected")
+	}
+	if err := orders.UpdateOrderStatus(ctx, "order-new", models.OrderStatusCancelled, "test", time.Now()); err != nil {
+		t.Fatalf("UpdateOrderStatus: %v", err)
+	}
+	for i, err := range orders.ImportOrders(ctx, []*models.Order{testOrder("order-imported")}, nil) {
+		if err != nil {
+			t.Fatalf("ImportOrders[%d]: %v", i, err)
+		}
+	}
+
+	var outboxEvents int
+	if err := db.Get(&outboxEvents, `SELECT count(*) FROM outbox WHERE aggregate_id = 'order-imported'`); err != nil {
+		t.Fatalf("count outbox events: %v", err)
+	}
+	if outboxEvents != 0 {
+		t.Errorf("imported order wrote %d outbox events, want 0", outboxEvents)
+	}
+
+	want := map[int64][]string{
+		all.ID:      {models.EventOrderCreated, models.EventOrderUpdated},
+		created.ID:  {models.EventOrderCreated},
+		disabled.ID: nil,
+	}
+	for id, events := range want {
+		deliveries, err := webhooks.ListDeliveries(ctx, id, 10)
+		if err != nil {
+			t.Fatalf("ListDeliveries: %v", err)
+		}
+		if len(deliveries) != len(events) {
+			t.Errorf("subscription %d: %d deliveries, want %d", id, len(deliveries), len(events))
+			continue
+		}
+		// ListDeliveries возвращает новые доставки первыми
+		for i, d := range deliveries {
+			if d.OrderUID != "order-new" || d.EventType != events[len(events)-1-i] {
+				t.Errorf("subscription %d: unexpected delivery %s %s", id, d.EventType, d.OrderUID)
+			}
+		}
+	}
+}
+
+func TestWebhookRecordResultDisablesSubscription(t *testing.T) {
+	const disableAfter = 3
+
+	ctx := context.Background()
+	db := openDB(t)
+	orders := repository.NewOrderRepository(db, 0)
+	webhooks := repository.NewWebhookRepository(db)
+
+	sub := subscribe(t, webhooks, models.EventOrderCreated)
+	for _, uid := range []string{"order-1", "order-2", "order-3", "order-4"} {
+		if err := orders.CreateOrder(ctx, testOrder(uid), nil); err != nil {
+			t.Fatalf("CreateOrder: %v", err)
+		}
+	}
+
+	for i := 1; i <= disableAfter; i++ {
+		tasks, err := webhooks.ClaimDeliveries(ctx, 1, time.Minute)
+		if err != nil || len(tasks) != 1 {
+			t.Fatalf("attempt %d: claimed %d deliveries: %v", i, len(tasks), err)
+		}
+
+		result := models.WebhookResult{DeliveryID: tasks[0].DeliveryID, SubscriptionID: sub.ID,
+			StatusCode: 500, Err: errors.New("receiver responded 500")}
+		active, err := webhooks.RecordResult(ctx, result, 0, 10, disableAfter)
+		if err != nil {
+			t.Fatalf("RecordResult: %v", err)
+		}
+		if want := i < disableAfter; active != want {
+			t.Fatalf("attempt %d: active = %t, want %t", i, active, want)
+		}
+	}
+
+	got, err := webhooks.GetSubscription(ctx, sub.ID)
+	if err != nil {
+		t.Fatalf("GetSubscription: %v", err)
+	}
+	if got.Active || got.DisabledAt == nil || got.ConsecutiveFailures != disableAfter {
+		t.Errorf("subscription not disabled: %+v", got)
+	}
+
+	// Доставки отключенной подписки не выбираются
+	if tasks, err := webhooks.ClaimDeliveries(ctx, 10, time.Minute); err != nil || len(tasks) != 0 {
+		t.Errorf("claimed %d deliveries of disabled subscription: %v", len(tasks), err)
+	}
+
+	// После включения доставки продолжаются, успешная сбрасывает счетчик ошибок
+	if err := webhooks.EnableSubscription(ctx, sub.ID); err != nil {
+		t.Fatalf("EnableSubscription: %v", err)
+	}
+	if _, err := db.Exec(`UPDATE webhook_deliveries SET next_attempt_at = now()`); err != nil {
+		t.Fatalf("reset next attempt: %v", err)
+	}
+	tasks, err := webhooks.ClaimDeliveries(ctx, 1, time.Minute)
+	if err != nil || len(tasks) != 1 {
+		t.Fatalf("claimed %d deliveries after enable: %v", len(tasks), err)
+	}
+	result := models.WebhookResult{DeliveryID: tasks[0].DeliveryID, SubscriptionID: sub.ID, StatusCode: 200}
+	if active, err := webhooks.RecordResult(ctx, result, 0, 10, disableAfter); err != nil || !active {
+		t.Fatalf("RecordResult success: active = %t, err = %v", active, err)
+	}
+	if got, _ := webhooks.GetSubscription(ctx, sub.ID); got.ConsecutiveFailures != 0 {
+		t.Errorf("consecutive failures not reset: %d", got.ConsecutiveFailures)
+	}
+}
+
+// openDB подключается к тестовой базе со схемой после всех миграций
+func openDB(t *testing.T) *sqlx.DB {
+	t.Helper()
+
+	db := testdb.Open(t, "repository_test")
+	if err := app.RunMigrations(db, config.MigrationsConfig{LockTimeout: time.Minute}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+func subscribe(t *testing.T, repo *repository.WebhookRepository, events ...string) *models.WebhookSubscription {
+	t.Helper()
+
+	sub := &models.WebhookSubscription{URL: "https://partner.example/hooks", EventTypes: events, Secret: "whsec_test"}
+	if err := repo.CreateSubscription(context.Background(), sub); err != nil {
+		t.Fatalf("CreateSubscription: %v", err)
+	}
+	return sub
+}
+
+func testOrder(uid string) *models.Order {
+	return &models.Order{
+		OrderUID:    uid,
+		TrackNumber: "TRACK-" + uid,
+		Entry:       "WBIL",
+		Delivery:    models.Delivery{Name: "Test Testov", Phone: "+9720000000", City: "Kiryat Mozkin"},
+		Payment: models.Payment{Transaction: uid, Currency: "USD", Provider: "wbpay",
+			Amount: 1817, DeliveryCost: 1500, GoodsTotal: 317},
+		Items: []models.Item{
+			{ChrtID: 9934930, TrackNumber: "TRACK-" + uid, Price: 453, Rid: uid, Name: "Mascaras",
+				Sale: 30, TotalPrice: 317, NmID: 2389212, Brand: "Vivienne Sabo", Status: 202},
+		},
+		DateCreated: time.Now().UTC(),
+	}
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"order-service/internal/models"
+
+	apperrors "order-service/internal/errors" // кастомные ошибки
+)
+
+// изменение статуса заказа
+func (s *orderService) UpdateOrderStatus(ctx context.Context, update models.OrderStatusUpdate) error {
+	if update.OrderUID == "" || update.Status == "" {
+		return apperrors.NewProcessingError(apperrors.StageValidate,
+			fmt.Errorf("invalid status update: order_uid and status are required"))
+	}
+
+	if update.UpdatedAt.IsZero() {
+		update.UpdatedAt = time.Now().UTC()
+	}
+
+	if err := s.repo.UpdateOrderStatus(ctx, update.OrderUID, update.Status, update.Reason, update.UpdatedAt); err != nil {
+		return apperrors.NewProcessingError(apperrors.StagePersist,
+			fmt.Errorf("failed to update order status: %w", err))
+	}
+
+	log.Printf("Order %s status changed to %s", update.OrderUID, update.Status)
+	s.refreshCache(ctx, update.OrderUID)
+	return nil
+}
+
+// обработка события платежной системы
+func (s *orderService) ApplyPaymentEvent(ctx context.Context, event models.PaymentEvent) error {
+	if event.OrderUID == "" || event.Status == "" {
+		return apperrors.NewProcessingError(apperrors.StageValidate,
+			fmt.Errorf("invalid payment event: order_uid and status are required"))
+	}
+
+	if err := s.repo.UpdatePaymentStatus(ctx, event.OrderUID, event.Status, event.PaymentDt); err != nil {
+		return apperrors.NewProcessingError(apperrors.StagePersist,
+			fmt.Errorf("failed to update payment status: %w", err))
+	}
+
+	log.Printf("Order %s payment status changed to %s", event.OrderUID, event.Status)
+	s.refreshCache(ctx, event.OrderUID)
+	return nil
+}
+
+// отмена заказа
+func (s *orderService) CancelOrder(ctx context.Context, cancellation models.OrderCancellation) error {
+	return s.UpdateOrderStatus(ctx, models.OrderStatusUpdate{
+		OrderUID:  cancellation.OrderUID,
+		Status:    models.OrderStatusCancelled,
+		Reason:    cancellation.Reason,
+		UpdatedAt: cancellation.CancelledAt,
+	})
+}
+
+// refreshCache перечитывает заказ из БД после изменения. Если перечитать не удалось,
+// заказ удаляется из кеша, чтобы не отдавать устаревшую версию: он загрузится при следующем запросе
+func (s *orderService) refreshCache(ctx context.Context, orderUID string) {
+	order, err := s.repo.GetOrder(ctx, orderUID)
+	if err != nil {
+		log.Printf("Failed to refresh cached order %s, evicting it: %v", orderUID, err)
+		s.cache.Delete(orderUID)
+		return
+	}
+	s.cache.Set(orderUID, order)
+}
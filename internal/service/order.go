@@ -1,58 +1,161 @@
 package service
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
 	"log"
 
 	"order-service/internal/interfaces"
 
 	"order-service/internal/models"
+
+	apperrors "order-service/internal/errors" // кастомные ошибки
 )
 
 type orderService struct {
 	repo  interfaces.OrderRepository
 	cache interfaces.Cache
+	codec interfaces.OrderCodec
 }
 
 // Проверка соответствия интерфейсу
 var _ interfaces.OrderService = (*orderService)(nil)
 
-func NewOrderService(r interfaces.OrderRepository, c interfaces.Cache) interfaces.OrderService {
+func NewOrderService(r interfaces.OrderRepository, c interfaces.Cache, codec interfaces.OrderCodec) interfaces.OrderService {
 	return &orderService{
 		repo:  r,
 		cache: c,
+		codec: codec,
 	}
 }
 
 // обработка заказа из Kafka
-func (s *orderService) ProcessOrder(data []byte) error {
-	// Парсинг JSON
-	var order models.Order
-	if err := json.Unmarshal(data, &order); err != nil {
-		return fmt.Errorf("failed to unmarshal order: %w", err)
+func (s *orderService) ProcessOrder(ctx context.Context, payload models.OrderPayload) error {
+	// Декодирование по content-type
+	order, err := s.decodeOrder(payload)
+	if err != nil {
+		return err
 	}
 
 	// Валидация данных
-	if err := s.validateOrder(&order); err != nil {
+	if err := s.validateOrder(order); err != nil {
 		log.Printf("Invalid order data: %v", err)
-		return fmt.Errorf("invalid order data: %w", err)
+		return apperrors.NewProcessingError(apperrors.StageValidate,
+			fmt.Errorf("invalid order data: %w", err))
 	}
 
 	// Сохранение в БД
-	if err := s.repo.CreateOrder(&order); err != nil {
-		return fmt.Errorf("failed to save order to database: %w", err)
+	if err := s.repo.CreateOrder(ctx, order, payload.Ingestion); err != nil {
+		return apperrors.NewProcessingError(apperrors.StagePersist,
+			fmt.Errorf("failed to save order to database: %w", err))
 	}
 
 	//  Обновление кеша
-	s.cache.Set(order.OrderUID, &order)
+	s.cache.Set(order.OrderUID, order)
 
 	log.Printf("Order %s processed successfully", order.OrderUID)
 	return nil
 }
 
+// повторная обработка заказа (идемпотентная): уже сохраненный заказ не считается ошибкой.
+// Возвращает false, если заказ уже был сохранен и пропущен
+func (s *orderService) ReplayOrder(ctx context.Context, payload models.OrderPayload) (bool, error) {
+	err := s.ProcessOrder(ctx, payload)
+	if errors.Is(err, apperrors.ErrOrderExists) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// обработка пачки заказов из Kafka: невалидные заказы отсеиваются,
+// остальные сохраняются в БД одной транзакцией. Возвращает ошибку для каждого сообщения
+func (s *orderService) ProcessOrders(ctx context.Context, batch []models.OrderPayload) []error {
+	errs := make([]error, len(batch))
+
+	orders := make([]*models.Order, 0, len(batch))
+	ingestions := make([]*models.OrderIngestion, 0, len(batch))
+	positions := make([]int, 0, len(batch))
+
+	for i, payload := range batch {
+		order, err := s.decodeOrder(payload)
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+
+		if err := s.validateOrder(order); err != nil {
+			log.Printf("Invalid order data: %v", err)
+			errs[i] = apperrors.NewProcessingError(apperrors.StageValidate,
+				fmt.Errorf("invalid order data: %w", err))
+			continue
+		}
+
+		orders = append(orders, order)
+		ingestions = append(ingestions, payload.Ingestion)
+		positions = append(positions, i)
+	}
+
+	// Сохранение в БД и обновление кеша только для сохраненных заказов
+	for j, err := range s.repo.CreateOrders(ctx, orders, ingestions) {
+		if err != nil {
+			errs[positions[j]] = apperrors.NewProcessingError(apperrors.StagePersist,
+				fmt.Errorf("failed to save order to database: %w", err))
+			continue
+		}
+
+		s.cache.Set(orders[j].OrderUID, orders[j])
+	}
+
+	log.Printf("Batch of %d orders processed, %d saved", len(batch), countNil(errs))
+	return errs
+}
+
+// импорт уже разобранных заказов из архива: валидация и сохранение пачкой, как в ProcessOrders.
+// События в outbox и партнерам не отправляются - это исторические заказы, а не новые.
+// Возвращает ошибку для каждого заказа
+func (s *orderService) ImportOrders(ctx context.Context, orders []*models.Order, ingestions []*models.OrderIngestion) []error {
+	errs := make([]error, len(orders))
+
+	valid := make([]*models.Order, 0, len(orders))
+	validIngestions := make([]*models.OrderIngestion, 0, len(orders))
+	positions := make([]int, 0, len(orders))
+
+	for i, order := range orders {
+		if err := s.validateOrder(order); err != nil {
+			errs[i] = apperrors.NewProcessingError(apperrors.StageValidate,
+				fmt.Errorf("invalid order data: %w", err))
+			continue
+		}
+
+		valid = append(valid, order)
+		if i < len(ingestions) {
+			validIngestions = append(validIngestions, ingestions[i])
+		} else {
+			validIngestions = append(validIngestions, nil)
+		}
+		positions = append(positions, i)
+	}
+
+	for j, err := range s.repo.ImportOrders(ctx, valid, validIngestions) {
+		if err != nil {
+			errs[positions[j]] = apperrors.NewProcessingError(apperrors.StagePersist,
+				fmt.Errorf("failed to save order to database: %w", err))
+			continue
+		}
+
+		s.cache.Set(valid[j].OrderUID, valid[j])
+	}
+
+	log.Printf("Imported batch of %d orders, %d saved", len(orders), countNil(errs))
+	return errs
+}
+
 // получение заказа (кеш + БД)
-func (s *orderService) GetOrder(orderUID string) (*models.Order, error) {
+func (s *orderService) GetOrder(ctx context.Context, orderUID string) (*models.Order, error) {
 	// Проверяем кеш
 	if order, exists := s.cache.Get(orderUID); exists {
 		log.Printf("Cache hit for order: %s", orderUID)
@@ -62,7 +165,7 @@ func (s *orderService) GetOrder(orderUID string) (*models.Order, error) {
 	// Если в кеше нет, обращаемся к БД
 	log.Printf("Cache miss for order: %s, fetching from database", orderUID)
 
-	order, err := s.repo.GetOrder(orderUID)
+	order, err := s.repo.GetOrder(ctx, orderUID)
 	if err != nil {
 		return nil, err
 	}
@@ -73,11 +176,16 @@ func (s *orderService) GetOrder(orderUID string) (*models.Order, error) {
 	return order, nil
 }
 
+// сведения о сообщении, из которого получен заказ. Для заказа без таких сведений - nil
+func (s *orderService) GetOrderIngestion(ctx context.Context, orderUID string) (*models.OrderIngestion, error) {
+	return s.repo.GetOrderIngestion(ctx, orderUID)
+}
+
 // восстановление кеша при старте
-func (s *orderService) LoadCacheFromDB() error {
+func (s *orderService) LoadCacheFromDB(ctx context.Context) error {
 	log.Println("Loading cache from database...")
 
-	orders, err := s.repo.GetAllOrders()
+	orders, err := s.repo.GetAllOrders(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to load orders from database: %w", err)
 	}
@@ -88,6 +196,16 @@ func (s *orderService) LoadCacheFromDB() error {
 	return nil
 }
 
+// decodeOrder декодирует заказ, помечая ошибку этапом decode
+func (s *orderService) decodeOrder(payload models.OrderPayload) (*models.Order, error) {
+	order, err := s.codec.Decode(payload.ContentType, payload.Data)
+	if err != nil {
+		return nil, apperrors.NewProcessingError(apperrors.StageDecode,
+			fmt.Errorf("failed to decode order: %w", err))
+	}
+	return order, nil
+}
+
 // Валидация заказа
 func (s *orderService) validateOrder(order *models.Order) error {
 	if order.OrderUID == "" {
@@ -112,3 +230,20 @@ func (s *orderService) GetCacheMetrics() interfaces.CacheMetrics {
 func (s *orderService) GetCacheSize() int {
 	return s.cache.Size()
 }
+
+// FlushCache очищает кеш. Заказы загружаются из БД при следующем обращении
+func (s *orderService) FlushCache() int {
+	n := s.cache.Clear()
+	log.Printf("Cache flushed: %d orders removed", n)
+	return n
+}
+
+func countNil(errs []error) int {
+	count := 0
+	for _, err := range errs {
+		if err == nil {
+			count++
+		}
+	}
+	return count
+}
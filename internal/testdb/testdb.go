@@ -0,0 +1,62 @@
+// Package testdb подключает тесты к настоящему PostgreSQL. Адрес базы задается
+// переменной TEST_DATABASE_DSN, без нее тесты с базой пропускаются
+package testdb
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+)
+
+// EnvDSN - переменная окружения с адресом тестовой базы
+const EnvDSN = "TEST_DATABASE_DSN"
+
+// Open создает пустую схему schema и возвращает подключение, в котором она
+// выбрана в search_path. Тесты разных пакетов запускаются параллельно, поэтому
+// каждый пакет работает в своей схеме. Схема удаляется после теста
+func Open(t *testing.T, schema string) *sqlx.DB {
+	t.Helper()
+
+	dsn := os.Getenv(EnvDSN)
+	if dsn == "" {
+		t.Skipf("%s is not set, skipping test with database", EnvDSN)
+	}
+
+	admin, err := sqlx.Connect("postgres", dsn)
+	if err != nil {
+		t.Fatalf("connect to test database: %v", err)
+	}
+	t.Cleanup(func() { admin.Close() })
+
+	if _, err := admin.Exec(fmt.Sprintf(`DROP SCHEMA IF EXISTS %[1]s CASCADE; CREATE SCHEMA %[1]s`, schema)); err != nil {
+		t.Fatalf("create schema %s: %v", schema, err)
+	}
+
+	db, err := sqlx.Connect("postgres", withSearchPath(dsn, schema))
+	if err != nil {
+		t.Fatalf("connect to test database: %v", err)
+	}
+
+	t.Cleanup(func() {
+		db.Close()
+		if _, err := admin.Exec(fmt.Sprintf(`DROP SCHEMA IF EXISTS %s CASCADE`, schema)); err != nil {
+			t.Logf("drop schema %s: %v", schema, err)
+		}
+	})
+	return db
+}
+
+// withSearchPath добавляет search_path к DSN в формате URL или key=value
+func withSearchPath(dsn, schema string) string {
+	if !strings.Contains(dsn, "://") {
+		return dsn + " search_path=" + schema
+	}
+	if strings.Contains(dsn, "?") {
+		return dsn + "&search_path=" + schema
+	}
+	return dsn + "?search_path=" + schema
+}
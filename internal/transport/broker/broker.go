@@ -0,0 +1,32 @@
+package broker
+
+import (
+	"fmt"
+
+	"order-service/internal/config"
+	"order-service/internal/interfaces"
+	"order-service/internal/transport/kafka"
+	"order-service/internal/transport/memory"
+	"order-service/internal/transport/nats"
+)
+
+// Поддерживаемые брокеры сообщений
+const (
+	TypeKafka  = "kafka"
+	TypeNATS   = "nats"
+	TypeMemory = "memory"
+)
+
+// New создает брокер сообщений, выбранный в конфигурации
+func New(cfg *config.Config) (interfaces.MessageBroker, error) {
+	switch cfg.Broker.Type {
+	case TypeKafka:
+		return kafka.NewConnection(cfg.Kafka)
+	case TypeNATS:
+		return nats.NewBroker(cfg.Broker.NATS, cfg.Kafka.ClientID)
+	case TypeMemory:
+		return memory.NewBroker(), nil
+	default:
+		return nil, fmt.Errorf("unsupported message broker %q", cfg.Broker.Type)
+	}
+}
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"order-service/internal/interfaces"
+	"order-service/internal/models"
+
+	apperrors "order-service/internal/errors" // кастомные ошибки
+)
+
+const defaultDLQLimit = 100
+
+type AdminHandler struct {
+	dlq      interfaces.DeadLetterQueue
+	consumer interfaces.ConsumerController
+}
+
+func NewAdminHandler(dlq interfaces.DeadLetterQueue, consumer interfaces.ConsumerController) *AdminHandler {
+	return &AdminHandler{dlq: dlq, consumer: consumer}
+}
+
+// обработка GET /admin/consumer - состояние Kafka consumer'а
+func (h *AdminHandler) ConsumerStatus(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, h.consumer.Status())
+}
+
+// обработка POST /admin/consumer/pause - приостановка чтения из Kafka
+func (h *AdminHandler) PauseConsumer(w http.ResponseWriter, r *http.Request) {
+	h.consumer.Pause()
+	writeJSON(w, h.consumer.Status())
+}
+
+// обработка POST /admin/consumer/resume - возобновление чтения из Kafka
+func (h *AdminHandler) ResumeConsumer(w http.ResponseWriter, r *http.Request) {
+	h.consumer.Resume()
+	writeJSON(w, h.consumer.Status())
+}
+
+// обработка POST /admin/consumer/seek - перемещение по офсетам или времени.
+// Тело: [{"partition": 0, "offset": 42}, {"partition": 1, "timestamp": "2024-01-15T10:00:00Z"}]
+func (h *AdminHandler) SeekConsumer(w http.ResponseWriter, r *http.Request) {
+	var positions []models.SeekPosition
+	if err := json.NewDecoder(r.Body).Decode(&positions); err != nil || len(positions) == 0 {
+		writeError(w, "request body must be a non-empty list of positions", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.consumer.Seek(r.Context(), positions); err != nil {
+		writeError(w, err.Error(), adminErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, h.consumer.Status())
+}
+
+// обработка POST /admin/consumer/replay - запуск повторной обработки диапазона сообщений
+func (h *AdminHandler) StartReplay(w http.ResponseWriter, r *http.Request) {
+	var req models.ReplayRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "invalid replay request", http.StatusBadRequest)
+		return
+	}
+
+	job, err := h.consumer.StartReplay(r.Context(), req)
+	if err != nil {
+		writeError(w, err.Error(), adminErrorStatus(err))
+		return
+	}
+
+	writeJSONStatus(w, http.StatusAccepted, job)
+}
+
+// обработка GET /admin/consumer/replay/{id} - состояние повторной обработки
+func (h *AdminHandler) GetReplay(w http.ResponseWriter, r *http.Request) {
+	job, ok := h.consumer.ReplayJob(mux.Vars(r)["id"])
+	if !ok {
+		writeError(w, "Replay job not found", http.StatusNotFound)
+		return
+	}
+
+	writeJSON(w, job)
+}
+
+// обработка GET /admin/dlq?topic=T&limit=N - просмотр сообщений в DLQ (без topic - во всех DLQ)
+func (h *AdminHandler) ListDLQ(w http.ResponseWriter, r *http.Request) {
+	if h.dlq == nil {
+		writeError(w, apperrors.ErrUnsupported.Error(), http.StatusNotImplemented)
+		return
+	}
+
+	limit, ok := parseLimit(w, r)
+	if !ok {
+		return
+	}
+
+	letters, err := h.dlq.List(r.Context(), r.URL.Query().Get("topic"), limit)
+	if err != nil {
+		writeError(w, err.Error(), dlqErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, map[string]interface{}{
+		"count":    len(letters),
+		"messages": letters,
+	})
+}
+
+// обработка POST /admin/dlq/redrive?topic=T&limit=N - возврат сообщений из DLQ в исходные топики
+func (h *AdminHandler) RedriveDLQ(w http.ResponseWriter, r *http.Request) {
+	if h.dlq == nil {
+		writeError(w, apperrors.ErrUnsupported.Error(), http.StatusNotImplemented)
+		return
+	}
+
+	limit, ok := parseLimit(w, r)
+	if !ok {
+		return
+	}
+
+	redriven, err := h.dlq.Redrive(r.Context(), r.URL.Query().Get("topic"), limit)
+	if err != nil {
+		writeError(w, err.Error(), dlqErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, map[string]int{"redriven": redriven})
+}
+
+// adminErrorStatus возвращает HTTP статус для ошибки управления consumer'ом
+func adminErrorStatus(err error) int {
+	if errors.Is(err, apperrors.ErrUnsupported) {
+		return http.StatusNotImplemented
+	}
+	return http.StatusBadGateway
+}
+
+// dlqErrorStatus возвращает HTTP статус для ошибки просмотра или повторной отправки DLQ
+func dlqErrorStatus(err error) int {
+	if errors.Is(err, apperrors.ErrUnknownTopic) {
+		return http.StatusBadRequest
+	}
+	return http.StatusBadGateway
+}
+
+// parseLimit читает параметр limit из запроса
+func parseLimit(w http.ResponseWriter, r *http.Request) (int, bool) {
+	raw := r.URL.Query().Get("limit")
+	if raw == "" {
+		return defaultDLQLimit, true
+	}
+
+	limit, err := strconv.Atoi(raw)
+	if err != nil || limit <= 0 {
+		writeError(w, "limit must be a positive integer", http.StatusBadRequest)
+		return 0, false
+	}
+
+	return limit, true
+}
@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"net/http"
+
+	"order-service/internal/models"
+)
+
+// обработка GET /admin/cache - размер кеша и статистика обращений
+func (h *OrderHandler) CacheStats(w http.ResponseWriter, r *http.Request) {
+	metrics := h.service.GetCacheMetrics()
+
+	stats := models.CacheStats{
+		Size:   h.service.GetCacheSize(),
+		Hits:   metrics.Hits,
+		Misses: metrics.Misses,
+	}
+	if total := metrics.Hits + metrics.Misses; total > 0 {
+		stats.HitRatio = float64(metrics.Hits) / float64(total)
+	}
+
+	writeJSON(w, stats)
+}
+
+// обработка POST /admin/cache/flush - очистка кеша
+func (h *OrderHandler) FlushCache(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, map[string]interface{}{
+		"flushed": h.service.FlushCache(),
+	})
+}
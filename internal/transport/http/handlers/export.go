@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"order-service/internal/export"
+	"order-service/internal/models"
+)
+
+type ExportHandler struct {
+	exporter *export.Exporter
+}
+
+func NewExportHandler(exporter *export.Exporter) *ExportHandler {
+	return &ExportHandler{exporter: exporter}
+}
+
+// обработка GET /orders/export?format=csv|ndjson|parquet - потоковая выгрузка заказов.
+// Фильтры: q, customer_id, track_number, status, from, to (RFC3339), limit.
+// CSV и Parquet содержат одну строку на товар, NDJSON - один заказ на строку
+func (h *ExportHandler) Export(w http.ResponseWriter, r *http.Request) {
+	format, err := export.ParseFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	filter, err := parseOrderFilter(r)
+	if err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Выгрузка может идти дольше WriteTimeout сервера
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+		log.Printf("Failed to disable write deadline for export: %v", err)
+	}
+
+	w.Header().Set("Content-Type", export.ContentType(format))
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="orders-%s.%s"`,
+		time.Now().UTC().Format("20060102-150405"), format))
+
+	count, err := h.exporter.Export(r.Context(), filter, format, w)
+	if err != nil {
+		// Заголовки уже отправлены: обрываем соединение, чтобы клиент не принял
+		// неполную выгрузку за успешную
+		log.Printf("Order export failed after %d orders: %v", count, err)
+		panic(http.ErrAbortHandler)
+	}
+
+	log.Printf("Exported %d orders as %s", count, format)
+}
+
+// parseOrderFilter читает условия выборки заказов из параметров запроса
+func parseOrderFilter(r *http.Request) (models.OrderFilter, error) {
+	q := r.URL.Query()
+	filter := models.OrderFilter{
+		Query:       q.Get("q"),
+		CustomerID:  q.Get("customer_id"),
+		TrackNumber: q.Get("track_number"),
+		Status:      q.Get("status"),
+	}
+
+	for name, dst := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		if raw := q.Get(name); raw != "" {
+			t, err := time.Parse(time.RFC3339, raw)
+			if err != nil {
+				return filter, fmt.Errorf("%s must be an RFC3339 time", name)
+			}
+			*dst = &t
+		}
+	}
+
+	if raw := q.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit <= 0 {
+			return filter, fmt.Errorf("limit must be a positive integer")
+		}
+		filter.Limit = limit
+	}
+
+	return filter, nil
+}
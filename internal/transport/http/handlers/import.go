@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"order-service/internal/importer"
+
+	apperrors "order-service/internal/errors" // кастомные ошибки
+)
+
+// Максимальное количество отклоненных записей в ответе на импорт
+const maxImportRejects = 1000
+
+type ImportHandler struct {
+	importer *importer.Importer
+}
+
+func NewImportHandler(importer *importer.Importer) *ImportHandler {
+	return &ImportHandler{importer: importer}
+}
+
+// importResult - итог импорта с отклоненными записями для ответа API
+type importResult struct {
+	importer.Report
+	Rejects          []importer.Reject `json:"rejects"`
+	RejectsTruncated bool              `json:"rejects_truncated,omitempty"`
+	Error            string            `json:"error,omitempty"`
+}
+
+func (r *importResult) Reject(reject importer.Reject) error {
+	if len(r.Rejects) < maxImportRejects {
+		r.Rejects = append(r.Rejects, reject)
+	} else {
+		r.RejectsTruncated = true
+	}
+	return nil
+}
+
+func (r *importResult) Checkpoint(report importer.Report) error {
+	r.Report = report
+	return nil
+}
+
+// обработка POST /admin/orders/import?format=csv|ndjson - импорт заказов из архива в теле запроса.
+// Параметры: source - имя архива, columns - переименование колонок CSV (old=new,...),
+// batch_size, resume - номер записи, после которой продолжить прерванный импорт.
+// Ответ содержит итог и отклоненные записи с причинами (не более maxImportRejects)
+func (h *ImportHandler) Import(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	opts := importer.Options{Source: q.Get("source")}
+	if opts.Source == "" {
+		opts.Source = "api"
+	}
+
+	var err error
+	if opts.Format, err = importer.ParseFormat(q.Get("format")); err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if opts.Columns, err = importer.ParseColumns(q.Get("columns")); err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	for name, dst := range map[string]*int{"batch_size": &opts.BatchSize, "resume": &opts.Resume.Position} {
+		if raw := q.Get(name); raw != "" {
+			if *dst, err = strconv.Atoi(raw); err != nil || *dst < 0 {
+				writeError(w, name+" must be a non-negative integer", http.StatusBadRequest)
+				return
+			}
+		}
+	}
+
+	// Архив может загружаться и обрабатываться дольше таймаутов сервера
+	rc := http.NewResponseController(w)
+	if err := rc.SetReadDeadline(time.Time{}); err != nil {
+		log.Printf("Failed to disable read deadline for import: %v", err)
+	}
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		log.Printf("Failed to disable write deadline for import: %v", err)
+	}
+
+	result := &importResult{Report: opts.Resume, Rejects: []importer.Reject{}}
+	report, err := h.importer.Import(r.Context(), r.Body, opts, result)
+	result.Report = report
+	if err != nil {
+		// Итог указывает, с какой записи продолжить импорт (resume)
+		log.Printf("Import of %s stopped at record %d: %v", opts.Source, report.Position, err)
+		result.Error = err.Error()
+		writeJSONStatus(w, importErrorStatus(err), result)
+		return
+	}
+
+	writeJSON(w, result)
+}
+
+// importErrorStatus возвращает HTTP статус для прерванного импорта: временная ошибка БД -
+// 503, остальные ошибки вызваны содержимым архива (заголовок CSV, обрыв загрузки) - 400
+func importErrorStatus(err error) int {
+	if apperrors.IsRetryable(err) {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusBadRequest
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"order-service/internal/interfaces"
+)
+
+type IntegrityHandler struct {
+	repo interfaces.IntegrityRepository
+}
+
+func NewIntegrityHandler(repo interfaces.IntegrityRepository) *IntegrityHandler {
+	return &IntegrityHandler{repo: repo}
+}
+
+// обработка GET /admin/integrity - поиск неполных заказов, строк без заказа и дубликатов
+func (h *IntegrityHandler) Check(w http.ResponseWriter, r *http.Request) {
+	http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	report, err := h.repo.CheckIntegrity(r.Context())
+	if err != nil {
+		log.Printf("Integrity check failed: %v", err)
+		writeError(w, "Integrity check failed", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, report)
+}
+
+// обработка POST /admin/integrity/quarantine - перенос нарушений в таблицу quarantine
+func (h *IntegrityHandler) Quarantine(w http.ResponseWriter, r *http.Request) {
+	http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	report, err := h.repo.Quarantine(r.Context())
+	if err != nil {
+		log.Printf("Quarantine failed: %v", err)
+		writeError(w, "Quarantine failed", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("Quarantined %d incomplete orders, issues: %+v", report.Orders, report.Issues)
+	writeJSON(w, report)
+}
@@ -1,7 +1,6 @@
 package handlers
 
 import (
-	"encoding/json"
 	"errors"
 	"net/http"
 	"time"
@@ -9,8 +8,9 @@ import (
 	"github.com/gorilla/mux"
 
 	"order-service/internal/interfaces"
+	"order-service/internal/models"
 
-	apperrors "order-service/internal/errors" // кастомнаые ошибки
+	apperrors "order-service/internal/errors" // кастомные ошибки
 )
 
 type OrderHandler struct {
@@ -21,32 +21,54 @@ func NewOrderHandler(service interfaces.OrderService) *OrderHandler {
 	return &OrderHandler{service: service}
 }
 
-// обработк GET /order/{order_uid}
+// orderWithIngestion - заказ вместе со сведениями о сообщении, из которого он получен
+type orderWithIngestion struct {
+	*models.Order
+	Ingestion *models.OrderIngestion `json:"ingestion"`
+}
+
+// обработк GET /order/{order_uid}. С ?include=ingestion в ответ добавляется
+// сообщение, из которого получен заказ (null, если заказ получен не из брокера)
 func (h *OrderHandler) GetOrder(w http.ResponseWriter, r *http.Request) {
 	// Извлекаем параметр из URL
 	vars := mux.Vars(r)
 	orderUID := vars["order_uid"]
 
 	if orderUID == "" {
-		h.writeError(w, "order_uid is required", http.StatusBadRequest)
+		writeError(w, "order_uid is required", http.StatusBadRequest)
 		return
 	}
 
-	//  Получаем заказ
-	order, err := h.service.GetOrder(orderUID)
+	//  Получаем заказ. Запрос к БД прерывается, если клиент отключился
+	order, err := h.service.GetOrder(r.Context(), orderUID)
 	if err != nil {
 		switch {
 		case errors.Is(err, apperrors.ErrOrderNotFound):
-			h.writeError(w, "Order not found", http.StatusNotFound)
+			writeError(w, "Order not found", http.StatusNotFound)
 		case errors.Is(err, apperrors.ErrInvalidOrderUID):
-			h.writeError(w, "Invalid order UID", http.StatusBadRequest)
+			writeError(w, "Invalid order UID", http.StatusBadRequest)
+		case errors.Is(err, apperrors.ErrOrderIncomplete):
+			writeError(w, "Order data is incomplete", http.StatusUnprocessableEntity)
+		case errors.Is(err, apperrors.ErrTemporary):
+			writeError(w, "Service temporarily unavailable", http.StatusServiceUnavailable)
 		default:
-			h.writeError(w, "Internal server error", http.StatusInternalServerError)
+			writeError(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if r.URL.Query().Get("include") == "ingestion" {
+		ingestion, err := h.service.GetOrderIngestion(r.Context(), orderUID)
+		if err != nil {
+			writeError(w, "Internal server error", http.StatusInternalServerError)
+			return
 		}
+
+		writeJSON(w, orderWithIngestion{Order: order, Ingestion: ingestion})
 		return
 	}
 
-	h.writeJSON(w, order)
+	writeJSON(w, order)
 }
 
 // Health check endpoint - Автоматическая проверка доступности :8081/health
@@ -57,23 +79,5 @@ func (h *OrderHandler) Health(w http.ResponseWriter, r *http.Request) {
 		"service":   "order-service",
 	}
 
-	h.writeJSON(w, response)
-}
-
-// Служебные методы для HTTP ответов
-func (h *OrderHandler) writeJSON(w http.ResponseWriter, data interface{}) {
-	w.Header().Set("Content-Type", "application/json")
-
-	if err := json.NewEncoder(w).Encode(data); err != nil {
-		h.writeError(w, "Error encoding response", http.StatusInternalServerError)
-		return
-	}
-}
-
-func (h *OrderHandler) writeError(w http.ResponseWriter, message string, status int) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-
-	response := map[string]string{"error": message}
-	json.NewEncoder(w).Encode(response)
+	writeJSON(w, response)
 }
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"order-service/internal/reconcile"
+)
+
+type ReconcileHandler struct {
+	reconciler *reconcile.Reconciler
+}
+
+func NewReconcileHandler(reconciler *reconcile.Reconciler) *ReconcileHandler {
+	return &ReconcileHandler{reconciler: reconciler}
+}
+
+// обработка POST /admin/cache/reconcile?repair=true - сверка кеша с БД.
+// С repair=true кеш приводится к состоянию БД
+func (h *ReconcileHandler) Reconcile(w http.ResponseWriter, r *http.Request) {
+	repair := false
+	if raw := r.URL.Query().Get("repair"); raw != "" {
+		var err error
+		if repair, err = strconv.ParseBool(raw); err != nil {
+			writeError(w, "repair must be a boolean", http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Сверка читает все заказы из БД и может идти дольше WriteTimeout сервера
+	http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	report, err := h.reconciler.Reconcile(r.Context(), repair)
+	switch {
+	case errors.Is(err, reconcile.ErrRunning):
+		writeError(w, err.Error(), http.StatusConflict)
+	case err != nil:
+		writeJSONStatus(w, http.StatusInternalServerError, report)
+	default:
+		writeJSON(w, report)
+	}
+}
+
+// обработка GET /admin/cache/reconcile - отчет последней сверки
+func (h *ReconcileHandler) LastReport(w http.ResponseWriter, r *http.Request) {
+	report := h.reconciler.Last()
+	if report == nil {
+		writeError(w, "cache reconciliation has not run yet", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, report)
+}
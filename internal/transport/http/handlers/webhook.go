@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"order-service/internal/interfaces"
+	"order-service/internal/models"
+
+	apperrors "order-service/internal/errors" // кастомные ошибки
+)
+
+type WebhookHandler struct {
+	webhooks interfaces.WebhookManager
+}
+
+func NewWebhookHandler(webhooks interfaces.WebhookManager) *WebhookHandler {
+	return &WebhookHandler{webhooks: webhooks}
+}
+
+// обработка POST /admin/webhooks - создание подписки.
+// Тело: {"url": "https://partner.example/hooks", "event_types": ["order.created"], "secret": "..."}.
+// Секрет для проверки подписи возвращается только в ответе на этот запрос
+func (h *WebhookHandler) Subscribe(w http.ResponseWriter, r *http.Request) {
+	var req models.WebhookSubscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "invalid subscription request", http.StatusBadRequest)
+		return
+	}
+
+	sub, err := h.webhooks.Subscribe(r.Context(), req)
+	if err != nil {
+		writeError(w, err.Error(), webhookErrorStatus(err))
+		return
+	}
+
+	writeJSONStatus(w, http.StatusCreated, sub)
+}
+
+// обработка GET /admin/webhooks - список подписок
+func (h *WebhookHandler) ListSubscriptions(w http.ResponseWriter, r *http.Request) {
+	subs, err := h.webhooks.Subscriptions(r.Context())
+	if err != nil {
+		writeError(w, err.Error(), webhookErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, map[string]interface{}{
+		"count":         len(subs),
+		"subscriptions": subs,
+	})
+}
+
+// обработка GET /admin/webhooks/{id} - подписка
+func (h *WebhookHandler) GetSubscription(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseSubscriptionID(w, r)
+	if !ok {
+		return
+	}
+
+	sub, err := h.webhooks.Subscription(r.Context(), id)
+	if err != nil {
+		writeError(w, err.Error(), webhookErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, sub)
+}
+
+// обработка DELETE /admin/webhooks/{id} - удаление подписки вместе с журналом доставок
+func (h *WebhookHandler) Unsubscribe(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseSubscriptionID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.webhooks.Unsubscribe(r.Context(), id); err != nil {
+		writeError(w, err.Error(), webhookErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// обработка POST /admin/webhooks/{id}/enable - включение подписки, отключенной после ошибок
+func (h *WebhookHandler) EnableSubscription(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseSubscriptionID(w, r)
+	if !ok {
+		return
+	}
+
+	sub, err := h.webhooks.Enable(r.Context(), id)
+	if err != nil {
+		writeError(w, err.Error(), webhookErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, sub)
+}
+
+// обработка GET /admin/webhooks/{id}/deliveries?limit=N - журнал доставок, новые первыми
+func (h *WebhookHandler) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseSubscriptionID(w, r)
+	if !ok {
+		return
+	}
+
+	limit, ok := parseLimit(w, r)
+	if !ok {
+		return
+	}
+
+	deliveries, err := h.webhooks.Deliveries(r.Context(), id, limit)
+	if err != nil {
+		writeError(w, err.Error(), webhookErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, map[string]interface{}{
+		"count":      len(deliveries),
+		"deliveries": deliveries,
+	})
+}
+
+// webhookErrorStatus возвращает HTTP статус для ошибки управления подписками
+func webhookErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, apperrors.ErrSubscriptionNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, apperrors.ErrInvalidSubscription):
+		return http.StatusBadRequest
+	case errors.Is(err, apperrors.ErrTemporary):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// parseSubscriptionID читает id подписки из пути
+func parseSubscriptionID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil || id <= 0 {
+		writeError(w, "Invalid subscription id", http.StatusBadRequest)
+		return 0, false
+	}
+	return id, true
+}
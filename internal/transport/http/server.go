@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 
 	"order-service/internal/transport/http/handlers"
 	"order-service/internal/transport/http/middleware"
@@ -15,19 +16,56 @@ type Server struct {
 	server *http.Server
 }
 
-func NewServer(port string, orderHandler *handlers.OrderHandler) *Server {
+func NewServer(port, adminToken string, orderHandler *handlers.OrderHandler, adminHandler *handlers.AdminHandler,
+	webhookHandler *handlers.WebhookHandler, exportHandler *handlers.ExportHandler,
+	importHandler *handlers.ImportHandler, reconcileHandler *handlers.ReconcileHandler,
+	integrityHandler *handlers.IntegrityHandler) *Server {
 	r := mux.NewRouter()
+	r.Use(middleware.LoggingMiddleware) // Логируем запросы
+
+	// Администрирование: только с токеном ADMIN_TOKEN и без CORS -
+	// браузер на чужом сайте не должен вызывать эти запросы
+	admin := r.PathPrefix("/admin").Subrouter()
+	admin.Use(middleware.AdminAuth(adminToken))
+
+	admin.HandleFunc("/consumer", adminHandler.ConsumerStatus).Methods("GET")
+	admin.HandleFunc("/consumer/pause", adminHandler.PauseConsumer).Methods("POST")
+	admin.HandleFunc("/consumer/resume", adminHandler.ResumeConsumer).Methods("POST")
+	admin.HandleFunc("/consumer/seek", adminHandler.SeekConsumer).Methods("POST")
+	admin.HandleFunc("/consumer/replay", adminHandler.StartReplay).Methods("POST")
+	admin.HandleFunc("/consumer/replay/{id}", adminHandler.GetReplay).Methods("GET")
+	admin.HandleFunc("/dlq", adminHandler.ListDLQ).Methods("GET")
+	admin.HandleFunc("/dlq/redrive", adminHandler.RedriveDLQ).Methods("POST")
+	admin.HandleFunc("/cache", orderHandler.CacheStats).Methods("GET")
+	admin.HandleFunc("/cache/flush", orderHandler.FlushCache).Methods("POST")
+	admin.HandleFunc("/cache/reconcile", reconcileHandler.Reconcile).Methods("POST")
+	admin.HandleFunc("/cache/reconcile", reconcileHandler.LastReport).Methods("GET")
+	admin.HandleFunc("/orders/import", importHandler.Import).Methods("POST")
+	admin.HandleFunc("/integrity", integrityHandler.Check).Methods("GET")
+	admin.HandleFunc("/integrity/quarantine", integrityHandler.Quarantine).Methods("POST")
+
+	// Подписки партнеров на webhook: события содержат персональные данные покупателей
+	admin.HandleFunc("/webhooks", webhookHandler.Subscribe).Methods("POST")
+	admin.HandleFunc("/webhooks", webhookHandler.ListSubscriptions).Methods("GET")
+	admin.HandleFunc("/webhooks/{id}", webhookHandler.GetSubscription).Methods("GET")
+	admin.HandleFunc("/webhooks/{id}", webhookHandler.Unsubscribe).Methods("DELETE")
+	admin.HandleFunc("/webhooks/{id}/enable", webhookHandler.EnableSubscription).Methods("POST")
+	admin.HandleFunc("/webhooks/{id}/deliveries", webhookHandler.ListDeliveries).Methods("GET")
+
+	// Публичные страницы и API
+	public := r.NewRoute().Subrouter()
+	public.Use(middleware.CorsMiddleware) // CORS policy (Разрешаем CORS JavaScript запрос)
 
 	// Web pages
-	r.HandleFunc("/health", orderHandler.Health).Methods("GET")
-	r.HandleFunc("/order/{order_uid}", orderHandler.GetOrder).Methods("GET")
+	public.HandleFunc("/health", orderHandler.Health).Methods("GET")
+	public.HandleFunc("/order/{order_uid}", orderHandler.GetOrder).Methods("GET")
+	public.HandleFunc("/orders/export", exportHandler.Export).Methods("GET")
 
-	// Главная страница
-	r.HandleFunc("/", serveHome).Methods("GET")
+	// Метрики Prometheus
+	public.Handle("/metrics", promhttp.Handler()).Methods("GET")
 
-	// Middleware
-	r.Use(middleware.LoggingMiddleware) // Логируем запросы
-	r.Use(middleware.CorsMiddleware)    // CORS policy (Разрешаем CORS JavaScript запрос)
+	// Главная страница
+	public.HandleFunc("/", serveHome).Methods("GET")
 
 	// Настройка CORS
 	srv := &http.Server{
@@ -0,0 +1,46 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// Административные запросы без токена отклоняются до обработчиков (в тесте их нет)
+// и не получают CORS-заголовков, публичные - получают
+func TestAdminRoutesRequireToken(t *testing.T) {
+	handler := NewServer("0", "secret", nil, nil, nil, nil, nil, nil, nil).server.Handler
+
+	for _, route := range []struct{ method, path string }{
+		{http.MethodGet, "/admin/consumer"},
+		{http.MethodPost, "/admin/consumer/pause"},
+		{http.MethodPost, "/admin/consumer/seek"},
+		{http.MethodPost, "/admin/consumer/replay"},
+		{http.MethodPost, "/admin/dlq/redrive"},
+		{http.MethodPost, "/admin/cache/flush"},
+		{http.MethodPost, "/admin/orders/import"},
+		{http.MethodPost, "/admin/integrity/quarantine"},
+		{http.MethodPost, "/admin/webhooks"},
+		{http.MethodGet, "/admin/webhooks"},
+		{http.MethodDelete, "/admin/webhooks/1"},
+		{http.MethodPost, "/admin/webhooks/1/enable"},
+	} {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(route.method, route.path, nil)
+		req.Header.Set("Origin", "https://attacker.example")
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("%s %s: status %d, want 401", route.method, route.path, rec.Code)
+		}
+		if origin := rec.Header().Get("Access-Control-Allow-Origin"); origin != "" {
+			t.Errorf("%s %s: CORS allowed for %q", route.method, route.path, origin)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("Access-Control-Allow-Origin") != "*" {
+		t.Errorf("public route: status %d, CORS %q", rec.Code, rec.Header().Get("Access-Control-Allow-Origin"))
+	}
+}
@@ -0,0 +1,151 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/segmentio/kafka-go"
+
+	"order-service/internal/interfaces"
+	"order-service/internal/models"
+)
+
+// Проверка соответствия интерфейсам
+var (
+	_ interfaces.MessageBroker = (*Connection)(nil)
+	_ interfaces.MessageSource = (*Source)(nil)
+	_ interfaces.MessageSink   = (*Sink)(nil)
+)
+
+// Source - подписка группы на топики Kafka
+type Source struct {
+	reader *kafka.Reader
+}
+
+// Source создает подписку группы groupID на топики с параметрами чтения из конфигурации
+func (c *Connection) Source(groupID string, topics ...string) (interfaces.MessageSource, error) {
+	return &Source{reader: c.NewReader(kafka.ReaderConfig{
+		GroupTopics: topics,
+		GroupID:     groupID,
+		MinBytes:    c.minBytes,
+		MaxBytes:    c.maxBytes,
+		MaxWait:     c.maxWait,
+		StartOffset: c.startOffset,
+	})}, nil
+}
+
+// Sink создает отправителя в топик. Топик создается автоматически, если его нет
+func (c *Connection) Sink(topic string) (interfaces.MessageSink, error) {
+	sink := &Sink{writer: c.NewWriter(topic, true), single: c.NewWriter(topic, true)}
+	sink.single.BatchSize = 1
+	sink.single.Completion = func(msgs []kafka.Message, err error) {
+		if err == nil && len(msgs) > 0 {
+			sink.sent = msgs[0]
+		}
+	}
+	return sink, nil
+}
+
+// Close ничего не делает: соединения принадлежат reader'ам и writer'ам
+func (c *Connection) Close() error {
+	return nil
+}
+
+func (s *Source) Fetch(ctx context.Context) (models.Message, error) {
+	msg, err := s.reader.FetchMessage(ctx)
+	if err != nil {
+		return models.Message{}, err
+	}
+	return toMessage(msg), nil
+}
+
+// Commit фиксирует офсеты группы. Для фиксации достаточно топика, партиции и офсета
+func (s *Source) Commit(ctx context.Context, msgs ...models.Message) error {
+	kafkaMsgs := make([]kafka.Message, len(msgs))
+	for i, msg := range msgs {
+		kafkaMsgs[i] = fromMessage(msg)
+	}
+	return s.reader.CommitMessages(ctx, kafkaMsgs...)
+}
+
+func (s *Source) Close() error {
+	return s.reader.Close()
+}
+
+// Sink отправляет сообщения в топик Kafka
+type Sink struct {
+	writer *kafka.Writer
+
+	// Writer для Send: сообщения отправляются по одному, положение
+	// отправленного сообщения writer передает в Completion
+	mu     sync.Mutex
+	single *kafka.Writer
+	sent   kafka.Message
+}
+
+func (s *Sink) Publish(ctx context.Context, msgs ...models.Message) error {
+	kafkaMsgs := make([]kafka.Message, len(msgs))
+	for i, msg := range msgs {
+		kafkaMsgs[i] = kafka.Message{Key: msg.Key, Value: msg.Value, Headers: toKafkaHeaders(msg.Headers)}
+	}
+	return s.writer.WriteMessages(ctx, kafkaMsgs...)
+}
+
+// Send отправляет одно сообщение и возвращает его с партицией и офсетом
+func (s *Sink) Send(ctx context.Context, msg models.Message) (models.Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sent = kafka.Message{}
+	err := s.single.WriteMessages(ctx, kafka.Message{Key: msg.Key, Value: msg.Value, Headers: toKafkaHeaders(msg.Headers)})
+	if err != nil {
+		return models.Message{}, err
+	}
+	return toMessage(s.sent), nil
+}
+
+func (s *Sink) Close() error {
+	return errors.Join(s.writer.Close(), s.single.Close())
+}
+
+// toMessage преобразует сообщение Kafka в сообщение брокера
+func toMessage(msg kafka.Message) models.Message {
+	headers := make([]models.MessageHeader, len(msg.Headers))
+	for i, h := range msg.Headers {
+		headers[i] = models.MessageHeader{Key: h.Key, Value: h.Value}
+	}
+
+	return models.Message{
+		Topic:         msg.Topic,
+		Partition:     msg.Partition,
+		Offset:        msg.Offset,
+		HighWaterMark: msg.HighWaterMark,
+		Key:           msg.Key,
+		Value:         msg.Value,
+		Headers:       headers,
+		Time:          msg.Time,
+	}
+}
+
+// fromMessage преобразует сообщение брокера в сообщение Kafka
+func fromMessage(msg models.Message) kafka.Message {
+	return kafka.Message{
+		Topic:         msg.Topic,
+		Partition:     msg.Partition,
+		Offset:        msg.Offset,
+		HighWaterMark: msg.HighWaterMark,
+		Key:           msg.Key,
+		Value:         msg.Value,
+		Headers:       toKafkaHeaders(msg.Headers),
+		Time:          msg.Time,
+	}
+}
+
+func toKafkaHeaders(headers []models.MessageHeader) []kafka.Header {
+	result := make([]kafka.Header, len(headers))
+	for i, h := range headers {
+		result[i] = kafka.Header{Key: h.Key, Value: h.Value}
+	}
+	return result
+}
@@ -0,0 +1,234 @@
+package kafka
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+
+	"order-service/internal/config"
+)
+
+// Таймаут установки соединения с брокером
+const dialTimeout = 10 * time.Second
+
+// Connection - параметры подключения к кластеру Kafka (брокеры, TLS, SASL, client id),
+// общие для всех reader'ов, writer'ов и служебных соединений сервиса
+type Connection struct {
+	brokers     []string
+	rack        string
+	dialer      *kafka.Dialer
+	transport   *kafka.Transport
+	compression kafka.Compression
+	linger      time.Duration
+
+	// Параметры чтения для подписок групп
+	minBytes    int
+	maxBytes    int
+	maxWait     time.Duration
+	startOffset int64
+}
+
+// NewConnection проверяет настройки безопасности и готовит параметры подключения
+func NewConnection(cfg config.KafkaConfig) (*Connection, error) {
+	if len(cfg.Brokers) == 0 {
+		return nil, errors.New("kafka brokers are not configured")
+	}
+
+	tlsConfig, err := newTLSConfig(cfg.TLS)
+	if err != nil {
+		return nil, err
+	}
+
+	mechanism, err := newSASLMechanism(cfg.SASL)
+	if err != nil {
+		return nil, err
+	}
+
+	compression, err := parseCompression(cfg.Compression)
+	if err != nil {
+		return nil, err
+	}
+
+	startOffset, err := parseStartOffset(cfg.StartOffset)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Connection{
+		brokers: cfg.Brokers,
+		rack:    cfg.Rack,
+		dialer: &kafka.Dialer{
+			ClientID:      cfg.ClientID,
+			Timeout:       dialTimeout,
+			DualStack:     true,
+			TLS:           tlsConfig,
+			SASLMechanism: mechanism,
+		},
+		transport: &kafka.Transport{
+			ClientID:    cfg.ClientID,
+			DialTimeout: dialTimeout,
+			TLS:         tlsConfig,
+			SASL:        mechanism,
+		},
+		compression: compression,
+		linger:      cfg.Linger,
+		minBytes:    cfg.MinBytes,
+		maxBytes:    cfg.MaxBytes,
+		maxWait:     cfg.MaxWait,
+		startOffset: startOffset,
+	}, nil
+}
+
+// Brokers возвращает список брокеров
+func (c *Connection) Brokers() []string {
+	return c.brokers
+}
+
+// NewReader создает reader с параметрами подключения. Для групп с заданной
+// стойкой партиции распределяются с учетом расположения реплик
+func (c *Connection) NewReader(cfg kafka.ReaderConfig) *kafka.Reader {
+	cfg.Brokers = c.brokers
+	cfg.Dialer = c.dialer
+	if cfg.GroupID != "" && c.rack != "" {
+		cfg.GroupBalancers = []kafka.GroupBalancer{
+			kafka.RackAffinityGroupBalancer{Rack: c.rack},
+			kafka.RangeGroupBalancer{},
+		}
+	}
+	return kafka.NewReader(cfg)
+}
+
+// NewWriter создает writer в топик с параметрами подключения и сжатием
+func (c *Connection) NewWriter(topic string, autoCreate bool) *kafka.Writer {
+	return &kafka.Writer{
+		Addr:                   kafka.TCP(c.brokers...),
+		Topic:                  topic,
+		Balancer:               &kafka.Hash{},
+		RequiredAcks:           kafka.RequireAll,
+		Compression:            c.compression,
+		BatchTimeout:           c.linger,
+		Transport:              c.transport,
+		AllowAutoTopicCreation: autoCreate,
+	}
+}
+
+// Client возвращает клиент для административных запросов к кластеру
+func (c *Connection) Client() *kafka.Client {
+	return &kafka.Client{Addr: kafka.TCP(c.brokers...), Transport: c.transport}
+}
+
+// Dial подключается к первому доступному брокеру
+func (c *Connection) Dial(ctx context.Context) (*kafka.Conn, error) {
+	var err error
+	for _, broker := range c.brokers {
+		var conn *kafka.Conn
+		if conn, err = c.dialer.DialContext(ctx, "tcp", broker); err == nil {
+			return conn, nil
+		}
+	}
+	return nil, fmt.Errorf("failed to connect to kafka: %w", err)
+}
+
+// DialLeader подключается к лидеру партиции
+func (c *Connection) DialLeader(ctx context.Context, topic string, partition int) (*kafka.Conn, error) {
+	var err error
+	for _, broker := range c.brokers {
+		var conn *kafka.Conn
+		if conn, err = c.dialer.DialLeader(ctx, "tcp", broker, topic, partition); err == nil {
+			return conn, nil
+		}
+	}
+	return nil, fmt.Errorf("failed to connect to partition leader: %w", err)
+}
+
+// newTLSConfig загружает CA и клиентский сертификат. Возвращает nil, если TLS выключен
+func newTLSConfig(cfg config.TLSConfig) (*tls.Config, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         cfg.ServerName,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+	}
+
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read kafka CA file: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", cfg.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if cfg.CertFile != "" || cfg.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load kafka client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// newSASLMechanism создает механизм аутентификации. Возвращает nil, если SASL не настроен
+func newSASLMechanism(cfg config.SASLConfig) (sasl.Mechanism, error) {
+	switch strings.ToLower(cfg.Mechanism) {
+	case "":
+		return nil, nil
+	case "plain":
+		return plain.Mechanism{Username: cfg.Username, Password: cfg.Password}, nil
+	case "scram-sha-256":
+		return scram.Mechanism(scram.SHA256, cfg.Username, cfg.Password)
+	case "scram-sha-512":
+		return scram.Mechanism(scram.SHA512, cfg.Username, cfg.Password)
+	default:
+		return nil, fmt.Errorf("unsupported SASL mechanism %q", cfg.Mechanism)
+	}
+}
+
+// parseCompression возвращает кодек сжатия по имени
+func parseCompression(name string) (kafka.Compression, error) {
+	switch strings.ToLower(name) {
+	case "", "none":
+		return 0, nil
+	case "gzip":
+		return kafka.Gzip, nil
+	case "snappy":
+		return kafka.Snappy, nil
+	case "lz4":
+		return kafka.Lz4, nil
+	case "zstd":
+		return kafka.Zstd, nil
+	default:
+		return 0, fmt.Errorf("unsupported compression %q", name)
+	}
+}
+
+// parseStartOffset возвращает офсет, с которого новая группа начинает чтение
+func parseStartOffset(name string) (int64, error) {
+	switch strings.ToLower(name) {
+	case "", "first", "earliest":
+		return kafka.FirstOffset, nil
+	case "last", "latest":
+		return kafka.LastOffset, nil
+	default:
+		return 0, fmt.Errorf("unsupported start offset %q, expected first or last", name)
+	}
+}
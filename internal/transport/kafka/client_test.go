@@ -0,0 +1,83 @@
+package kafka
+
+import (
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+
+	"order-service/internal/config"
+)
+
+func TestParseCompression(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    kafka.Compression
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"none", 0, false},
+		{"gzip", kafka.Gzip, false},
+		{"Snappy", kafka.Snappy, false},
+		{"lz4", kafka.Lz4, false},
+		{"ZSTD", kafka.Zstd, false},
+		{"brotli", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseCompression(tt.name)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseCompression(%q) = %v, %v; want %v, error %t", tt.name, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestParseStartOffset(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    int64
+		wantErr bool
+	}{
+		{"", kafka.FirstOffset, false},
+		{"first", kafka.FirstOffset, false},
+		{"Earliest", kafka.FirstOffset, false},
+		{"last", kafka.LastOffset, false},
+		{"LATEST", kafka.LastOffset, false},
+		{"42", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseStartOffset(tt.name)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseStartOffset(%q) = %d, %v; want %d, error %t", tt.name, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestNewSASLMechanism(t *testing.T) {
+	tests := []struct {
+		mechanism string
+		want      string // имя механизма, пустое - SASL выключен
+		wantErr   bool
+	}{
+		{"", "", false},
+		{"plain", "PLAIN", false},
+		{"SCRAM-SHA-256", "SCRAM-SHA-256", false},
+		{"scram-sha-512", "SCRAM-SHA-512", false},
+		{"gssapi", "", true},
+	}
+
+	for _, tt := range tests {
+		m, err := newSASLMechanism(config.SASLConfig{Mechanism: tt.mechanism, Username: "user", Password: "secret"})
+		if (err != nil) != tt.wantErr {
+			t.Errorf("newSASLMechanism(%q): unexpected error %v", tt.mechanism, err)
+			continue
+		}
+		var got string
+		if m != nil {
+			got = m.Name()
+		}
+		if got != tt.want {
+			t.Errorf("newSASLMechanism(%q) = %q, want %q", tt.mechanism, got, tt.want)
+		}
+	}
+}
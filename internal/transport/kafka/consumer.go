@@ -2,64 +2,487 @@ package kafka
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
+	"sync"
 	"time"
 
 	"github.com/segmentio/kafka-go"
 
+	"order-service/internal/config"
 	"order-service/internal/interfaces"
+	"order-service/internal/models"
+
+	apperrors "order-service/internal/errors" // кастомные ошибки
 )
 
+// mainTier - номер уровня для сообщений из основного топика
+const mainTier = -1
+
+// Consumer читает сообщения через interfaces.MessageBroker (Kafka, NATS или память).
+// Seek и повторная обработка диапазона доступны только для Kafka
 type Consumer struct {
-	reader  *kafka.Reader
-	service interfaces.OrderService
+	source    interfaces.MessageSource
+	broker    interfaces.MessageBroker
+	conn      *Connection   // nil, если брокер - не Kafka
+	client    *kafka.Client // nil, если брокер - не Kafka
+	groupID   string
+	topics    []string
+	service   interfaces.OrderService
+	mainTopic string
+	routes    map[string]*route // обработчики по топикам
+	retry     config.RetryConfig
+	tiers     []*retryTier
+
+	commitBatchSize int
+	commitInterval  time.Duration
+
+	workers   int
+	queueSize int
+	ordering  string
+
+	batchSize    int
+	batchTimeout time.Duration
+
+	metrics *consumerMetrics
+
+	// Административное управление
+	gate      *pauseGate
+	seekCh    chan seekCommand
+	replayMu  sync.Mutex
+	replays   map[string]*models.ReplayJob
+	replaySeq int
+
+	// Задачи повторной обработки выполняются в replayCtx: он отменяется вместе
+	// с контекстом Start или при закрытии consumer'а, replayWG ждет их завершения
+	replayCtx   context.Context
+	stopReplays context.CancelFunc
+	replayWG    sync.WaitGroup
 }
 
-func NewConsumer(brokers []string, topic, groupID string, service interfaces.OrderService) *Consumer {
-	reader := kafka.NewReader(kafka.ReaderConfig{
-		Brokers:     brokers,
-		Topic:       topic,
-		GroupID:     groupID,
-		MinBytes:    10e3, // 10KB
-		MaxBytes:    10e6, // 10MB
-		MaxWait:     1 * time.Second,
-		StartOffset: kafka.LastOffset,
-	})
-
-	return &Consumer{
-		reader:  reader,
-		service: service,
+// Проверка соответствия интерфейсу
+var _ interfaces.ConsumerController = (*Consumer)(nil)
+
+// NewConsumer создает consumer, подписанный на все топики из cfg.Topics.
+// Сообщения каждого топика обрабатываются обработчиком из registry
+func NewConsumer(broker interfaces.MessageBroker, cfg config.KafkaConfig, service interfaces.OrderService,
+	registry *Registry) (*Consumer, error) {
+	routes, err := newRoutes(broker, cfg.Topics, registry)
+	if err != nil {
+		return nil, err
 	}
+
+	topics := make([]string, 0, len(cfg.Topics))
+	for _, t := range cfg.Topics {
+		topics = append(topics, t.Topic)
+	}
+
+	source, err := broker.Source(cfg.GroupID, topics...)
+	if err != nil {
+		closeRoutes(routes)
+		return nil, fmt.Errorf("failed to subscribe to topics: %w", err)
+	}
+
+	replayCtx, stopReplays := context.WithCancel(context.Background())
+	consumer := &Consumer{
+		source:    source,
+		broker:    broker,
+		groupID:   cfg.GroupID,
+		topics:    topics,
+		service:   service,
+		mainTopic: cfg.Topic,
+		routes:    routes,
+		retry:     cfg.Retry,
+
+		commitBatchSize: cfg.CommitBatchSize,
+		commitInterval:  cfg.CommitInterval,
+
+		workers:   cfg.Workers,
+		queueSize: cfg.WorkerQueueSize,
+		ordering:  cfg.Ordering,
+
+		batchSize:    cfg.BatchSize,
+		batchTimeout: cfg.BatchTimeout,
+
+		metrics: newConsumerMetrics(topics, cfg.GroupID),
+
+		gate:    newPauseGate(),
+		seekCh:  make(chan seekCommand),
+		replays: make(map[string]*models.ReplayJob),
+
+		replayCtx:   replayCtx,
+		stopReplays: stopReplays,
+	}
+
+	if conn, ok := broker.(*Connection); ok {
+		consumer.conn = conn
+		consumer.client = conn.Client()
+	}
+
+	for i, tier := range cfg.Retry.Tiers {
+		groupID := fmt.Sprintf("%s-retry-%d", cfg.GroupID, i)
+		t, err := newRetryTier(broker, groupID, tier)
+		if err != nil {
+			consumer.close()
+			return nil, err
+		}
+		consumer.tiers = append(consumer.tiers, t)
+	}
+
+	return consumer, nil
 }
 
 func (c *Consumer) Start(ctx context.Context) error {
 	log.Println("Starting Kafka consumer...")
 
+	// Повторная обработка останавливается вместе с consumer'ом
+	stop := context.AfterFunc(ctx, c.stopReplays)
+	defer stop()
+
+	var wg sync.WaitGroup
+	for i, tier := range c.tiers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			log.Printf("Starting retry consumer for %s (delay %s)", tier.topic, tier.delay)
+			c.consume(ctx, tier.source, i)
+		}()
+	}
+
+	c.run(ctx)
+	wg.Wait()
+
+	log.Println("Stopping Kafka consumer...")
+	return c.close()
+}
+
+// run читает топики до отмены контекста. По команде Seek чтение
+// останавливается, офсеты группы перезаписываются, и чтение начинается заново
+func (c *Consumer) run(ctx context.Context) {
+	for {
+		runCtx, cancel := context.WithCancel(ctx)
+
+		commands := make(chan seekCommand, 1)
+		watcherDone := make(chan struct{})
+		go func() {
+			defer close(watcherDone)
+			select {
+			case cmd := <-c.seekCh:
+				commands <- cmd
+				cancel()
+			case <-runCtx.Done():
+			}
+		}()
+
+		c.consume(runCtx, c.source, mainTier)
+		cancel()
+		<-watcherDone
+
+		select {
+		case cmd := <-commands:
+			cmd.result <- c.applySeek(ctx, cmd.offsets)
+		default:
+			return
+		}
+
+		// Без подписки читать нечего: пересоздаем ее, пока не удастся
+		for attempt := 1; c.source == nil; attempt++ {
+			if !sleepContext(ctx, backoff(c.retry, attempt)) {
+				return
+			}
+			if err := c.resubscribe(); err != nil {
+				log.Printf("Error resubscribing after seek (attempt %d): %v", attempt, err)
+			}
+		}
+	}
+}
+
+// consume читает сообщения из source до отмены контекста и раздает их пулу обработчиков.
+// Офсет сообщения фиксируется только после того, как оно сохранено в БД,
+// отправлено в DLQ или отложено в топик повторной обработки (at-least-once)
+func (c *Consumer) consume(ctx context.Context, source interfaces.MessageSource, tier int) {
+	committer := newOffsetCommitter(source, c.commitBatchSize, c.commitInterval)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		committer.Run(ctx)
+	}()
+
+	pool := newWorkerPool(ctx, c.workers, c.queueSize, c.batchSize, c.batchTimeout, c.ordering,
+		func(batch []models.Message) {
+			// Отложенные сообщения обрабатываем не раньше назначенного времени.
+			// Задержка в топике одинаковая, поэтому достаточно дождаться последнего
+			if tier != mainTier && !sleepContext(ctx, time.Until(retryNotBefore(batch[len(batch)-1]))) {
+				return
+			}
+
+			for _, msg := range c.handleBatch(ctx, batch, tier) {
+				committer.MarkDone(ctx, msg)
+			}
+		})
+
+	c.fetchLoop(ctx, source, committer, pool)
+
+	// Дожидаемся обработчиков и фиксируем то, что успели обработать
+	pool.Stop()
+	<-done
+	committer.Close()
+}
+
+// fetchLoop читает сообщения без автоматической фиксации офсета и передает их в пул.
+// После ошибки чтения следующая попытка делается с паузой, растущей до MaxBackoff
+func (c *Consumer) fetchLoop(ctx context.Context, source interfaces.MessageSource, committer *offsetCommitter, pool *workerPool) {
+	failures := 0
 	for {
 		select {
 		case <-ctx.Done():
-			log.Println("Stopping Kafka consumer...")
-			return c.reader.Close()
+			return
 		default:
-			// Чтение сообщения с таймаутом
-			msg, err := c.reader.ReadMessage(ctx)
+			// Чтение приостановлено администратором
+			if !c.gate.Wait(ctx) {
+				return
+			}
+
+			msg, err := source.Fetch(ctx)
 			if err != nil {
-				log.Printf("Error reading message: %v", err)
+				if ctx.Err() != nil {
+					return
+				}
+				failures++
+				log.Printf("Error reading message (attempt %d): %v", failures, err)
+				if !sleepContext(ctx, backoff(c.retry, failures)) {
+					return
+				}
 				continue
 			}
+			failures = 0
 
-			// обработка сообщения
-			if err := c.processMessage(msg); err != nil {
-				log.Printf("Error processing message: %v", err)
-				continue
+			c.metrics.observeFetch(msg)
+			committer.Track(msg)
+			if !pool.Submit(ctx, msg) {
+				return
+			}
+		}
+	}
+}
+
+// handleBatch сохраняет заказы из пачки одной транзакцией, остальные сообщения
+// (события других топиков) обрабатываются по одному. Сообщения с ошибками
+// обрабатываются по отдельности, как в handleMessage.
+// Возвращает сообщения, офсеты которых можно фиксировать
+func (c *Consumer) handleBatch(ctx context.Context, batch []models.Message, tier int) []models.Message {
+	handled := make([]models.Message, 0, len(batch))
+
+	var orders []models.Message
+	for _, msg := range batch {
+		if r, ok := c.routeFor(msg); ok && r.handler == HandlerOrders {
+			orders = append(orders, msg)
+			continue
+		}
+		if c.handleMessage(ctx, msg, tier) {
+			handled = append(handled, msg)
+		}
+	}
+
+	if len(orders) == 1 {
+		if c.handleMessage(ctx, orders[0], tier) {
+			handled = append(handled, orders[0])
+		}
+		return handled
+	}
+	if len(orders) == 0 {
+		return handled
+	}
+
+	log.Printf("Received batch of %d orders", len(orders))
+
+	payloads := make([]models.OrderPayload, len(orders))
+	for i, msg := range orders {
+		r, _ := c.routeFor(msg)
+		payloads[i] = orderPayload(msg, r.contentTypeOf(msg))
+	}
+
+	start := time.Now()
+	for i, err := range c.service.ProcessOrders(ctx, payloads) {
+		msg := orders[i]
+
+		switch {
+		case err == nil:
+			c.finish(msg, resultProcessed, start, true)
+			handled = append(handled, msg)
+		case errors.Is(err, apperrors.ErrOrderExists):
+			c.finish(msg, resultDuplicate, start, true)
+			handled = append(handled, msg)
+		case !apperrors.IsRetryable(err):
+			log.Printf("Error processing message: partition=%d, offset=%d: %v",
+				msg.Partition, msg.Offset, err)
+			c.metrics.observeError(msg, err)
+			if c.finish(msg, resultDLQ, start, c.deadLetter(ctx, msg, err, retryAttempts(msg)+1)) {
+				handled = append(handled, msg)
+			}
+		default:
+			c.metrics.observeError(msg, err)
+			// Временная ошибка - повторяем сообщение отдельно
+			if c.handleMessage(ctx, msg, tier) {
+				handled = append(handled, msg)
 			}
 		}
 	}
+
+	return handled
 }
 
-// processMessage обрабатывает полученное сообщение из Kafka.
-func (c *Consumer) processMessage(msg kafka.Message) error {
-	log.Printf("Received message: offset=%d, key=%s", msg.Offset, string(msg.Key))
+// handleMessage обрабатывает сообщение с повторами при временных ошибках.
+// Постоянные ошибки отправляют сообщение в DLQ, а временные после исчерпания
+// попыток - в следующий топик повторной обработки или, если его нет, тоже в DLQ.
+// Возвращает false, если обработка прервана остановкой consumer'а и офсет фиксировать нельзя
+func (c *Consumer) handleMessage(ctx context.Context, msg models.Message, tier int) bool {
+	start := time.Now()
+	attempts := retryAttempts(msg)
+
+	for attempt := 1; ; attempt++ {
+		err := c.processMessage(ctx, msg)
+		attempts++
+		if err == nil {
+			return c.finish(msg, resultProcessed, start, true)
+		}
+
+		// Заказ уже сохранен: сообщение пришло повторно после перезапуска
+		// до фиксации офсета или продюсер отправил его дважды
+		if errors.Is(err, apperrors.ErrOrderExists) {
+			log.Printf("Order already exists, skipping message: partition=%d, offset=%d",
+				msg.Partition, msg.Offset)
+			return c.finish(msg, resultDuplicate, start, true)
+		}
+
+		// Запрос прерван остановкой consumer'а: сообщение обработается после перезапуска
+		if ctx.Err() != nil {
+			return false
+		}
+
+		c.metrics.observeError(msg, err)
+
+		if !apperrors.IsRetryable(err) {
+			log.Printf("Error processing message: %v", err)
+			return c.finish(msg, resultDLQ, start, c.deadLetter(ctx, msg, err, attempts))
+		}
+
+		log.Printf("Retryable error processing message (attempt %d): %v", attempts, err)
+
+		// Исчерпали попытки на этом уровне - откладываем сообщение в следующий топик
+		// повторной обработки, а после последнего уровня отправляем в DLQ
+		if attempt >= c.retry.MaxAttempts {
+			if tier+1 < len(c.tiers) {
+				return c.finish(msg, resultDeferred, start, c.deferMessage(ctx, msg, err, attempts, tier+1))
+			}
+			log.Printf("Retry attempts exhausted: partition=%d, offset=%d", msg.Partition, msg.Offset)
+			return c.finish(msg, resultDLQ, start, c.deadLetter(ctx, msg, err, attempts))
+		}
+
+		if !sleepContext(ctx, backoff(c.retry, attempt)) {
+			return false
+		}
+	}
+}
 
-	return c.service.ProcessOrder(msg.Value)
+// finish учитывает в метриках результат обработки сообщения, если оно обработано
+func (c *Consumer) finish(msg models.Message, result string, start time.Time, handled bool) bool {
+	if handled {
+		c.metrics.observeHandled(msg, result, time.Since(start))
+	}
+	return handled
+}
+
+// Status возвращает сводку о работе consumer'а: отставание, скорость и ошибки
+func (c *Consumer) Status() models.ConsumerStatus {
+	status := c.metrics.Status()
+	status.Paused = c.gate.Paused()
+	return status
+}
+
+// processMessage обрабатывает полученное сообщение обработчиком его топика.
+func (c *Consumer) processMessage(ctx context.Context, msg models.Message) error {
+	log.Printf("Received message: topic=%s, partition=%d, offset=%d, key=%s",
+		msg.Topic, msg.Partition, msg.Offset, string(msg.Key))
+
+	r, ok := c.routeFor(msg)
+	if !ok {
+		return apperrors.NewProcessingError(apperrors.StageDecode,
+			fmt.Errorf("no handler for topic %s", msg.Topic))
+	}
+
+	return r.handle(ctx, msg, r.contentTypeOf(msg))
+}
+
+// routeFor возвращает подписку исходного топика сообщения
+// (для отложенных сообщений - топика, из которого они пришли)
+func (c *Consumer) routeFor(msg models.Message) (*route, bool) {
+	topic, _, _ := messageSource(msg)
+	r, ok := c.routes[topic]
+	return r, ok
+}
+
+// deferMessage отправляет сообщение в топик повторной обработки уровня tier
+func (c *Consumer) deferMessage(ctx context.Context, msg models.Message, procErr error, attempts, tier int) bool {
+	for attempt := 1; ; attempt++ {
+		err := c.tiers[tier].publish(ctx, msg, procErr, attempts)
+		if err == nil {
+			log.Printf("Message deferred to %s: partition=%d, offset=%d",
+				c.tiers[tier].topic, msg.Partition, msg.Offset)
+			return true
+		}
+
+		log.Printf("Error deferring message: %v", err)
+		if !sleepContext(ctx, backoff(c.retry, attempt)) {
+			return false
+		}
+	}
+}
+
+// deadLetter отправляет сообщение, которое не удалось обработать, в DLQ.
+// Отправка повторяется, пока не удастся, чтобы офсет не был зафиксирован раньше времени
+func (c *Consumer) deadLetter(ctx context.Context, msg models.Message, procErr error, attempts int) bool {
+	r, ok := c.routeFor(msg)
+	if !ok || r.dlq == nil {
+		return true
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := r.dlq.Publish(ctx, msg, procErr, attempts)
+		if err == nil {
+			log.Printf("Message sent to DLQ: partition=%d, offset=%d", msg.Partition, msg.Offset)
+			return true
+		}
+
+		log.Printf("Error sending message to DLQ: partition=%d, offset=%d: %v",
+			msg.Partition, msg.Offset, err)
+		if !sleepContext(ctx, backoff(c.retry, attempt)) {
+			return false
+		}
+	}
+}
+
+// close останавливает повторную обработку и закрывает подписку,
+// топики повторной обработки и DLQ publisher'ы
+func (c *Consumer) close() error {
+	c.replayMu.Lock()
+	c.stopReplays()
+	c.replayMu.Unlock()
+	c.replayWG.Wait()
+
+	for _, tier := range c.tiers {
+		if err := tier.close(); err != nil {
+			log.Printf("Error closing retry tier %s: %v", tier.topic, err)
+		}
+	}
+
+	closeRoutes(c.routes)
+
+	// Подписки нет, если ее не удалось пересоздать после Seek
+	if c.source == nil {
+		return nil
+	}
+	return c.source.Close()
 }
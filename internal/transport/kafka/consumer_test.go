@@ -0,0 +1,480 @@
+package kafka_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"order-service/internal/cache"
+	"order-service/internal/codec"
+	"order-service/internal/config"
+	"order-service/internal/interfaces"
+	"order-service/internal/models"
+	"order-service/internal/service"
+	"order-service/internal/transport/kafka"
+	"order-service/internal/transport/memory"
+
+	apperrors "order-service/internal/errors" // кастомные ошибки
+)
+
+const (
+	testTopic   = "orders"
+	testDLQ     = "orders.dlq"
+	testGroupID = "order-service-test"
+)
+
+// Перезапуск посреди пачки: заказы пачки уже сохранены, а офсеты еще не зафиксированы.
+// После перезапуска сообщения приходят повторно и должны быть пропущены без DLQ
+func TestConsumerRestartMidBatch(t *testing.T) {
+	const total = 20
+
+	broker := newRecordingBroker()
+	repo := newMemoryRepository()
+	uids := publishOrders(t, broker, total)
+
+	// Фиксируемые офсеты не должны опережать сохраненные заказы
+	broker.onCommit = func(msg models.Message) {
+		for _, uid := range uids[:msg.Offset+1] {
+			if repo.inserts(uid) == 0 {
+				t.Errorf("offset %d committed before order %s was saved", msg.Offset, uid)
+			}
+		}
+	}
+
+	// Первый запуск: вторая пачка сохраняется, после чего consumer останавливается,
+	// не успев зафиксировать ее офсеты
+	ctx, cancel := context.WithCancel(context.Background())
+	repo.afterBatch = func(call int) {
+		if call == 2 {
+			cancel()
+		}
+	}
+	stopped := startConsumer(t, ctx, broker, repo, testConfig())
+	<-ctx.Done()
+	waitStopped(t, stopped)
+
+	committed := broker.committedOffset()
+	if committed < 0 || committed >= total-1 {
+		t.Fatalf("expected consumer to stop mid-stream, committed offset %d", committed)
+	}
+	saved := repo.count()
+	if saved <= int(committed)+1 {
+		t.Fatalf("expected uncommitted orders to be saved before stop: saved %d, committed offset %d",
+			saved, committed)
+	}
+
+	// Второй запуск продолжает с зафиксированного офсета
+	repo.afterBatch = nil
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	stopped = startConsumer(t, ctx, broker, repo, testConfig())
+	waitFor(t, func() bool { return broker.committedOffset() == total-1 })
+	cancel()
+	waitStopped(t, stopped)
+
+	for _, uid := range uids {
+		if n := repo.inserts(uid); n != 1 {
+			t.Errorf("order %s saved %d times, want 1", uid, n)
+		}
+	}
+	if dlq := broker.Messages(testDLQ); len(dlq) != 0 {
+		t.Errorf("expected no messages in DLQ, got %d", len(dlq))
+	}
+
+	// Офсеты фиксируются по порядку, без пропусков и откатов назад
+	commits := broker.commitHistory()
+	for i := 1; i < len(commits); i++ {
+		if commits[i] < commits[i-1] {
+			t.Errorf("committed offsets went backwards: %v", commits)
+			break
+		}
+	}
+}
+
+// testConfig - consumer с одним обработчиком, пачками по 5 заказов
+// и фиксацией офсета после каждой пачки
+func testConfig() config.KafkaConfig {
+	return config.KafkaConfig{
+		Topic:   testTopic,
+		GroupID: testGroupID,
+		Topics: []config.TopicConfig{
+			{Topic: testTopic, Handler: kafka.HandlerOrders, Format: "json", DLQTopic: testDLQ},
+		},
+		Retry: config.RetryConfig{
+			MaxAttempts:    2,
+			InitialBackoff: time.Millisecond,
+			MaxBackoff:     10 * time.Millisecond,
+		},
+		CommitBatchSize: 1,
+		CommitInterval:  10 * time.Millisecond,
+		Workers:         1,
+		WorkerQueueSize: 10,
+		Ordering:        kafka.OrderingPartition,
+		BatchSize:       5,
+		BatchTimeout:    50 * time.Millisecond,
+	}
+}
+
+// startConsumer запускает consumer с настоящим сервисом заказов поверх repo.
+// Канал закрывается, когда consumer остановлен
+func startConsumer(t *testing.T, ctx context.Context, broker interfaces.MessageBroker,
+	repo interfaces.OrderRepository, cfg config.KafkaConfig) <-chan struct{} {
+	t.Helper()
+
+	svc := service.NewOrderService(repo, cache.NewMemoryCache(), codec.New(nil))
+	consumer, err := kafka.NewConsumer(broker, cfg, svc, kafka.NewOrderRegistry(svc))
+	if err != nil {
+		t.Fatalf("NewConsumer: %v", err)
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		if err := consumer.Start(ctx); err != nil {
+			t.Errorf("consumer stopped with error: %v", err)
+		}
+	}()
+	return stopped
+}
+
+func waitStopped(t *testing.T, stopped <-chan struct{}) {
+	t.Helper()
+
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("consumer did not stop")
+	}
+}
+
+// waitFor ждет выполнения условия не дольше 5 секунд
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// publishOrders публикует count валидных заказов в основной топик и возвращает их order_uid
+func publishOrders(t *testing.T, broker interfaces.MessageBroker, count int) []string {
+	t.Helper()
+
+	sink, err := broker.Sink(testTopic)
+	if err != nil {
+		t.Fatalf("Sink: %v", err)
+	}
+	defer sink.Close()
+
+	uids := make([]string, count)
+	for i := range uids {
+		uids[i] = fmt.Sprintf("order-%03d", i)
+		if err := sink.Publish(context.Background(), orderMessage(t, testOrder(uids[i]))); err != nil {
+			t.Fatalf("Publish: %v", err)
+		}
+	}
+	return uids
+}
+
+func orderMessage(t *testing.T, order *models.Order) models.Message {
+	t.Helper()
+
+	data, err := json.Marshal(order)
+	if err != nil {
+		t.Fatalf("marshal order: %v", err)
+	}
+	return models.Message{Key: []byte(order.OrderUID), Value: data}
+}
+
+func testOrder(uid string) *models.Order {
+	return &models.Order{
+		OrderUID:    uid,
+		TrackNumber: "TRACK-" + uid,
+		Entry:       "WBIL",
+		Items: []models.Item{
+			{ChrtID: 1, TrackNumber: "TRACK-" + uid, Price: 100, Name: "item", TotalPrice: 100},
+		},
+		DateCreated: time.Now().UTC(),
+	}
+}
+
+// recordingBroker - брокер в памяти, который запоминает фиксируемые офсеты основного топика
+type recordingBroker struct {
+	*memory.Broker
+
+	mu       sync.Mutex
+	commits  []int64
+	onCommit func(msg models.Message)
+}
+
+func newRecordingBroker() *recordingBroker {
+	return &recordingBroker{Broker: memory.NewBroker()}
+}
+
+func (b *recordingBroker) Source(groupID string, topics ...string) (interfaces.MessageSource, error) {
+	source, err := b.Broker.Source(groupID, topics...)
+	if err != nil {
+		return nil, err
+	}
+	return &recordingSource{MessageSource: source, broker: b}, nil
+}
+
+// committedOffset возвращает последний зафиксированный офсет основного топика (-1 - нет)
+func (b *recordingBroker) committedOffset() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.commits) == 0 {
+		return -1
+	}
+	return b.commits[len(b.commits)-1]
+}
+
+func (b *recordingBroker) commitHistory() []int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return append([]int64(nil), b.commits...)
+}
+
+type recordingSource struct {
+	interfaces.MessageSource
+	broker *recordingBroker
+}
+
+func (s *recordingSource) Commit(ctx context.Context, msgs ...models.Message) error {
+	for _, msg := range msgs {
+		if msg.Topic != testTopic {
+			continue
+		}
+		if s.broker.onCommit != nil {
+			s.broker.onCommit(msg)
+		}
+
+		s.broker.mu.Lock()
+		s.broker.commits = append(s.broker.commits, msg.Offset)
+		s.broker.mu.Unlock()
+	}
+	return s.MessageSource.Commit(ctx, msgs...)
+}
+
+// memoryRepository - хранилище заказов в памяти с уникальностью order_uid, как в БД
+type memoryRepository struct {
+	mu      sync.Mutex
+	orders  map[string]*models.Order
+	counts  map[string]int
+	batches int
+
+	// afterBatch вызывается после сохранения пачки с ее порядковым номером.
+	// Если контекст при этом отменен, пачка считается сохраненной, но ответ не получен
+	afterBatch func(call int)
+
+	// unavailable - заказы, сохранение которых всегда завершается временной ошибкой
+	unavailable map[string]bool
+}
+
+func newMemoryRepository() *memoryRepository {
+	return &memoryRepository{
+		orders: make(map[string]*models.Order),
+		counts: make(map[string]int),
+	}
+}
+
+// inserts возвращает, сколько раз заказ был сохранен
+func (r *memoryRepository) inserts(uid string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.counts[uid]
+}
+
+func (r *memoryRepository) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.orders)
+}
+
+func (r *memoryRepository) CreateOrder(ctx context.Context, order *models.Order, _ *models.OrderIngestion) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("%w: %w", apperrors.ErrTemporary, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.insert(order)
+}
+
+func (r *memoryRepository) CreateOrders(ctx context.Context, orders []*models.Order, _ []*models.OrderIngestion) []error {
+	errs := make([]error, len(orders))
+	if err := ctx.Err(); err != nil {
+		for i := range errs {
+			errs[i] = fmt.Errorf("%w: %w", apperrors.ErrTemporary, err)
+		}
+		return errs
+	}
+
+	r.mu.Lock()
+	for i, order := range orders {
+		errs[i] = r.insert(order)
+	}
+	r.batches++
+	call := r.batches
+	r.mu.Unlock()
+
+	if r.afterBatch != nil {
+		r.afterBatch(call)
+	}
+	if err := ctx.Err(); err != nil {
+		for i := range errs {
+			errs[i] = fmt.Errorf("%w: %w", apperrors.ErrTemporary, err)
+		}
+	}
+	return errs
+}
+
+func (r *memoryRepository) ImportOrders(ctx context.Context, orders []*models.Order, ingestions []*models.OrderIngestion) []error {
+	return r.CreateOrders(ctx, orders, ingestions)
+}
+
+func (r *memoryRepository) insert(order *models.Order) error {
+	if r.unavailable[order.OrderUID] {
+		return fmt.Errorf("%w: database is unavailable", apperrors.ErrTemporary)
+	}
+	if _, ok := r.orders[order.OrderUID]; ok {
+		return fmt.Errorf("%w: %s", apperrors.ErrOrderExists, order.OrderUID)
+	}
+	r.orders[order.OrderUID] = order
+	r.counts[order.OrderUID]++
+	return nil
+}
+
+func (r *memoryRepository) GetOrder(_ context.Context, orderUID string) (*models.Order, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	order, ok := r.orders[orderUID]
+	if !ok {
+		return nil, apperrors.ErrOrderNotFound
+	}
+	return order, nil
+}
+
+func (r *memoryRepository) GetOrderIngestion(context.Context, string) (*models.OrderIngestion, error) {
+	return nil, nil
+}
+
+func (r *memoryRepository) GetAllOrders(context.Context) ([]models.Order, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	orders := make([]models.Order, 0, len(r.orders))
+	for _, order := range r.orders {
+		orders = append(orders, *order)
+	}
+	return orders, nil
+}
+
+func (r *memoryRepository) ListOrders(context.Context, models.OrderFilter) ([]models.OrderSummary, error) {
+	return nil, nil
+}
+
+func (r *memoryRepository) StreamOrders(context.Context, models.OrderFilter, func(*models.Order) error) error {
+	return nil
+}
+
+func (r *memoryRepository) UpdateOrderStatus(context.Context, string, string, string, time.Time) error {
+	return nil
+}
+
+func (r *memoryRepository) UpdatePaymentStatus(context.Context, string, string, int64) error {
+	return nil
+}
+
+// Если consumer не удалось создать, уже созданные отправители в DLQ закрываются
+func TestNewConsumerClosesDLQOnError(t *testing.T) {
+	cfg := testConfig()
+	cfg.Topics = append(cfg.Topics,
+		config.TopicConfig{Topic: "order-status", Handler: kafka.HandlerOrderStatus, Format: "json", DLQTopic: "order-status.dlq"},
+		config.TopicConfig{Topic: "payments", Handler: kafka.HandlerPayment, Format: "json", DLQTopic: "payments.dlq"},
+	)
+
+	tests := []struct {
+		name       string
+		failSink   string
+		failSource bool
+		unknown    bool
+	}{
+		{"DLQ of a later topic", "payments.dlq", false, false},
+		{"unknown handler of a later topic", "", false, true},
+		{"subscription", "", true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			broker := &sinkCountingBroker{Broker: memory.NewBroker(), failSink: tt.failSink, failSource: tt.failSource}
+			cfg := cfg
+			cfg.Topics = append([]config.TopicConfig(nil), cfg.Topics...)
+			if tt.unknown {
+				cfg.Topics[2].Handler = "unknown"
+			}
+
+			svc := service.NewOrderService(newMemoryRepository(), cache.NewMemoryCache(), codec.New(nil))
+			if _, err := kafka.NewConsumer(broker, cfg, svc, kafka.NewOrderRegistry(svc)); err == nil {
+				t.Fatal("expected NewConsumer to fail")
+			}
+			if broker.created == 0 {
+				t.Fatal("no DLQ publishers were created")
+			}
+			if broker.open != 0 {
+				t.Errorf("%d of %d DLQ publishers left open", broker.open, broker.created)
+			}
+		})
+	}
+}
+
+// sinkCountingBroker считает открытых отправителей и может отказать в создании
+// отправителя в топик failSink или подписки
+type sinkCountingBroker struct {
+	*memory.Broker
+	failSink   string
+	failSource bool
+
+	created int
+	open    int
+}
+
+func (b *sinkCountingBroker) Sink(topic string) (interfaces.MessageSink, error) {
+	if topic == b.failSink {
+		return nil, errors.New("broker is unavailable")
+	}
+	sink, err := b.Broker.Sink(topic)
+	if err != nil {
+		return nil, err
+	}
+	b.created++
+	b.open++
+	return &countingSink{MessageSink: sink, broker: b}, nil
+}
+
+func (b *sinkCountingBroker) Source(groupID string, topics ...string) (interfaces.MessageSource, error) {
+	if b.failSource {
+		return nil, errors.New("broker is unavailable")
+	}
+	return b.Broker.Source(groupID, topics...)
+}
+
+type countingSink struct {
+	interfaces.MessageSink
+	broker *sinkCountingBroker
+}
+
+func (s *countingSink) Close() error {
+	s.broker.open--
+	return s.MessageSink.Close()
+}
@@ -0,0 +1,426 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+
+	"order-service/internal/models"
+
+	apperrors "order-service/internal/errors" // кастомные ошибки
+)
+
+// pauseGate приостанавливает чтение сообщений без остановки процесса.
+// Reader продолжает отправлять heartbeat'ы, поэтому партиции за consumer'ом сохраняются
+type pauseGate struct {
+	mu      sync.Mutex
+	paused  bool
+	resumed chan struct{}
+}
+
+func newPauseGate() *pauseGate {
+	return &pauseGate{resumed: make(chan struct{})}
+}
+
+func (g *pauseGate) Pause() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if !g.paused {
+		g.paused = true
+		g.resumed = make(chan struct{})
+	}
+}
+
+func (g *pauseGate) Resume() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.paused {
+		g.paused = false
+		close(g.resumed)
+	}
+}
+
+func (g *pauseGate) Paused() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.paused
+}
+
+// Wait блокируется, пока чтение приостановлено. Возвращает false, если контекст отменен
+func (g *pauseGate) Wait(ctx context.Context) bool {
+	g.mu.Lock()
+	paused, resumed := g.paused, g.resumed
+	g.mu.Unlock()
+
+	if !paused {
+		return true
+	}
+
+	select {
+	case <-resumed:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// seekCommand - запрос на перемещение consumer'а, выполняемый в цикле Start
+type seekCommand struct {
+	offsets map[string]map[int]int64 // топик -> партиция -> офсет
+	result  chan error
+}
+
+// Pause приостанавливает чтение новых сообщений. Сообщения в обработке дообрабатываются
+func (c *Consumer) Pause() {
+	c.gate.Pause()
+	log.Println("Kafka consumer paused")
+}
+
+// Resume возобновляет чтение сообщений
+func (c *Consumer) Resume() {
+	c.gate.Resume()
+	log.Println("Kafka consumer resumed")
+}
+
+// Seek перемещает consumer группы на заданные офсеты или моменты времени.
+// Reader закрывается, офсеты группы перезаписываются, и чтение начинается заново.
+// Перезаписать офсеты можно, только если других участников в группе нет.
+// Если топик не указан, используется основной топик заказов
+func (c *Consumer) Seek(ctx context.Context, positions []models.SeekPosition) error {
+	if c.conn == nil {
+		return apperrors.ErrUnsupported
+	}
+
+	offsets := make(map[string]map[int]int64)
+	for _, pos := range positions {
+		topic, err := c.topicOrDefault(pos.Topic)
+		if err != nil {
+			return err
+		}
+		if offsets[topic] == nil {
+			offsets[topic] = make(map[int]int64)
+		}
+
+		switch {
+		case pos.Offset != nil:
+			offsets[topic][pos.Partition] = *pos.Offset
+		case pos.Timestamp != nil:
+			offset, err := c.offsetAt(ctx, topic, pos.Partition, *pos.Timestamp)
+			if err != nil {
+				return err
+			}
+			offsets[topic][pos.Partition] = offset
+		default:
+			return fmt.Errorf("%s partition %d: offset or timestamp is required", topic, pos.Partition)
+		}
+	}
+
+	cmd := seekCommand{offsets: offsets, result: make(chan error, 1)}
+	select {
+	case c.seekCh <- cmd:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	select {
+	case err := <-cmd.result:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// applySeek перезаписывает офсеты группы и пересоздает подписку.
+// Если подписку пересоздать не удалось, c.source остается nil
+func (c *Consumer) applySeek(ctx context.Context, offsets map[string]map[int]int64) error {
+	if err := c.source.Close(); err != nil {
+		log.Printf("Error closing source before seek: %v", err)
+	}
+	c.source = nil
+
+	err := c.commitSeek(ctx, offsets)
+	if subErr := c.resubscribe(); subErr != nil {
+		return errors.Join(err, subErr)
+	}
+	return err
+}
+
+// resubscribe пересоздает подписку на топики consumer'а
+func (c *Consumer) resubscribe() error {
+	source, err := c.broker.Source(c.groupID, c.topics...)
+	if err != nil {
+		return fmt.Errorf("failed to resubscribe after seek: %w", err)
+	}
+	c.source = source
+	return nil
+}
+
+// commitSeek записывает офсеты группы
+func (c *Consumer) commitSeek(ctx context.Context, offsets map[string]map[int]int64) error {
+	topics := make(map[string][]kafka.OffsetCommit, len(offsets))
+	for topic, partitions := range offsets {
+		for partition, offset := range partitions {
+			topics[topic] = append(topics[topic], kafka.OffsetCommit{Partition: partition, Offset: offset})
+		}
+	}
+
+	resp, err := c.client.OffsetCommit(ctx, &kafka.OffsetCommitRequest{
+		GroupID:      c.groupID,
+		GenerationID: -1,
+		Topics:       topics,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to commit seek offsets: %w", err)
+	}
+
+	for topic, partitions := range resp.Topics {
+		for _, p := range partitions {
+			if p.Error != nil {
+				return fmt.Errorf("failed to commit seek offset for %s partition %d: %w", topic, p.Partition, p.Error)
+			}
+		}
+	}
+
+	log.Printf("Kafka consumer moved to offsets %v", offsets)
+	return nil
+}
+
+// offsetAt возвращает первый офсет партиции с временем сообщения не раньше t
+func (c *Consumer) offsetAt(ctx context.Context, topic string, partition int, t time.Time) (int64, error) {
+	conn, err := c.conn.DialLeader(ctx, topic, partition)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	offset, err := conn.ReadOffset(t)
+	if err != nil {
+		return 0, fmt.Errorf("failed to resolve offset for partition %d at %s: %w", partition, t, err)
+	}
+	return offset, nil
+}
+
+// StartReplay запускает повторную обработку диапазона сообщений в фоне.
+// Повторная обработка заказов идемпотентна: уже сохраненные заказы пропускаются.
+// События остальных топиков передаются обработчику топика повторно.
+// ctx ограничивает только подготовку задачи, сама задача прерывается остановкой consumer'а
+func (c *Consumer) StartReplay(ctx context.Context, req models.ReplayRequest) (models.ReplayJob, error) {
+	if c.conn == nil {
+		return models.ReplayJob{}, apperrors.ErrUnsupported
+	}
+
+	topic, err := c.topicOrDefault(req.Topic)
+	if err != nil {
+		return models.ReplayJob{}, err
+	}
+	req.Topic = topic
+
+	partitions := req.Partitions
+	if len(partitions) == 0 {
+		all, err := c.partitions(ctx, topic)
+		if err != nil {
+			return models.ReplayJob{}, err
+		}
+		partitions = all
+	}
+
+	c.replayMu.Lock()
+	if c.replayCtx.Err() != nil {
+		c.replayMu.Unlock()
+		return models.ReplayJob{}, errors.New("consumer is stopped")
+	}
+	c.replayWG.Add(1)
+	c.replaySeq++
+	job := &models.ReplayJob{
+		ID:        strconv.Itoa(c.replaySeq),
+		Status:    models.ReplayRunning,
+		Request:   req,
+		StartedAt: time.Now(),
+	}
+	c.replays[job.ID] = job
+	snapshot := *job
+	c.replayMu.Unlock()
+
+	go func() {
+		defer c.replayWG.Done()
+
+		var err error
+		for _, partition := range partitions {
+			if err = c.replayPartition(c.replayCtx, job, partition, req); err != nil {
+				break
+			}
+		}
+
+		c.replayMu.Lock()
+		defer c.replayMu.Unlock()
+
+		now := time.Now()
+		job.FinishedAt = &now
+		job.Status = models.ReplayCompleted
+		if err != nil {
+			job.Status = models.ReplayFailed
+			job.Error = err.Error()
+		}
+		log.Printf("Replay %s %s: processed=%d, skipped=%d, failed=%d",
+			job.ID, job.Status, job.Processed, job.Skipped, job.Failed)
+	}()
+
+	return snapshot, nil
+}
+
+// ReplayJob возвращает состояние задачи повторной обработки
+func (c *Consumer) ReplayJob(id string) (models.ReplayJob, bool) {
+	c.replayMu.Lock()
+	defer c.replayMu.Unlock()
+
+	job, ok := c.replays[id]
+	if !ok {
+		return models.ReplayJob{}, false
+	}
+	return *job, true
+}
+
+// replayPartition повторно обрабатывает диапазон сообщений одной партиции
+func (c *Consumer) replayPartition(ctx context.Context, job *models.ReplayJob, partition int, req models.ReplayRequest) error {
+	start, end, err := c.replayBounds(ctx, partition, req)
+	if err != nil {
+		return err
+	}
+	if start >= end {
+		return nil
+	}
+
+	r := c.routes[req.Topic]
+
+	reader := c.conn.NewReader(kafka.ReaderConfig{
+		Topic:     req.Topic,
+		Partition: partition,
+	})
+	defer reader.Close()
+
+	if err := reader.SetOffset(start); err != nil {
+		return err
+	}
+
+	for {
+		msg, err := reader.ReadMessage(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to read partition %d: %w", partition, err)
+		}
+		if msg.Offset >= end {
+			return nil
+		}
+
+		saved, err := c.replayMessage(ctx, r, toMessage(msg))
+
+		c.replayMu.Lock()
+		switch {
+		case err != nil:
+			job.Failed++
+			log.Printf("Replay %s: error processing partition=%d, offset=%d: %v",
+				job.ID, partition, msg.Offset, err)
+		case saved:
+			job.Processed++
+		default:
+			job.Skipped++
+		}
+		c.replayMu.Unlock()
+
+		if msg.Offset >= end-1 {
+			return nil
+		}
+	}
+}
+
+// replayMessage повторно обрабатывает одно сообщение.
+// Возвращает false, если заказ уже был сохранен и сообщение пропущено
+func (c *Consumer) replayMessage(ctx context.Context, r *route, msg models.Message) (bool, error) {
+	contentType := r.contentTypeOf(msg)
+	if r.handler == HandlerOrders {
+		return c.service.ReplayOrder(ctx, orderPayload(msg, contentType))
+	}
+
+	if err := r.handle(ctx, msg, contentType); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// replayBounds вычисляет диапазон офсетов [start, end) для повторной обработки
+func (c *Consumer) replayBounds(ctx context.Context, partition int, req models.ReplayRequest) (int64, int64, error) {
+	conn, err := c.conn.DialLeader(ctx, req.Topic, partition)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer conn.Close()
+
+	first, last, err := conn.ReadOffsets()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	start, end := first, last
+	switch {
+	case req.FromOffset != nil:
+		start = max(*req.FromOffset, first)
+	case req.From != nil:
+		if start, err = conn.ReadOffset(*req.From); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	switch {
+	case req.ToOffset != nil:
+		end = min(*req.ToOffset, last)
+	case req.To != nil:
+		if end, err = conn.ReadOffset(*req.To); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	return start, end, nil
+}
+
+// partitions возвращает список партиций топика
+func (c *Consumer) partitions(ctx context.Context, topic string) ([]int, error) {
+	conn, err := c.conn.Dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	list, err := conn.ReadPartitions(topic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read partitions: %w", err)
+	}
+	if len(list) == 0 {
+		return nil, errors.New("topic has no partitions")
+	}
+
+	partitions := make([]int, 0, len(list))
+	for _, p := range list {
+		partitions = append(partitions, p.ID)
+	}
+	return partitions, nil
+}
+
+// topicOrDefault проверяет, что consumer подписан на топик.
+// Пустой топик означает основной топик заказов
+func (c *Consumer) topicOrDefault(topic string) (string, error) {
+	if topic == "" {
+		return c.mainTopic, nil
+	}
+	if _, ok := c.routes[topic]; !ok {
+		return "", fmt.Errorf("consumer is not subscribed to topic %s", topic)
+	}
+	return topic, nil
+}
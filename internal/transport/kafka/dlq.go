@@ -0,0 +1,344 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+
+	"order-service/internal/config"
+	"order-service/internal/interfaces"
+	"order-service/internal/models"
+
+	apperrors "order-service/internal/errors" // кастомные ошибки
+)
+
+// Заголовки, которые добавляются к сообщению при отправке в DLQ
+const (
+	HeaderDLQError           = "x-dlq-error"
+	HeaderDLQStage           = "x-dlq-stage"
+	HeaderDLQAttempts        = "x-dlq-attempts"
+	HeaderDLQSourceTopic     = "x-dlq-source-topic"
+	HeaderDLQSourcePartition = "x-dlq-source-partition"
+	HeaderDLQSourceOffset    = "x-dlq-source-offset"
+	HeaderDLQFailedAt        = "x-dlq-failed-at"
+
+	dlqHeaderPrefix = "x-dlq-"
+
+	// Сколько ждать новых сообщений при повторной отправке, прежде чем считать DLQ пустой
+	redriveIdleTimeout = 5 * time.Second
+)
+
+// DLQPublisher отправляет необработанные сообщения в DLQ-топик
+type DLQPublisher struct {
+	sink interfaces.MessageSink
+}
+
+func NewDLQPublisher(sink interfaces.MessageSink) *DLQPublisher {
+	return &DLQPublisher{sink: sink}
+}
+
+// Publish отправляет сообщение в DLQ, сохраняя исходные ключ, значение и заголовки
+func (p *DLQPublisher) Publish(ctx context.Context, msg models.Message, procErr error, attempts int) error {
+	topic, partition, offset := messageSource(msg)
+
+	headers := withoutHeaders(msg.Headers, dlqHeaderPrefix, retryHeaderPrefix)
+	headers = append(headers,
+		models.MessageHeader{Key: HeaderDLQError, Value: []byte(procErr.Error())},
+		models.MessageHeader{Key: HeaderDLQStage, Value: []byte(apperrors.StageOf(procErr))},
+		models.MessageHeader{Key: HeaderDLQAttempts, Value: []byte(strconv.Itoa(attempts))},
+		models.MessageHeader{Key: HeaderDLQSourceTopic, Value: []byte(topic)},
+		models.MessageHeader{Key: HeaderDLQSourcePartition, Value: []byte(strconv.Itoa(partition))},
+		models.MessageHeader{Key: HeaderDLQSourceOffset, Value: []byte(strconv.FormatInt(offset, 10))},
+		models.MessageHeader{Key: HeaderDLQFailedAt, Value: []byte(time.Now().UTC().Format(time.RFC3339))},
+	)
+
+	err := p.sink.Publish(ctx, models.Message{
+		Key:     msg.Key,
+		Value:   msg.Value,
+		Headers: headers,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to publish message to DLQ: %w", err)
+	}
+
+	return nil
+}
+
+func (p *DLQPublisher) Close() error {
+	return p.sink.Close()
+}
+
+// DeadLetterQueue позволяет просматривать DLQ всех топиков consumer'а
+// и возвращать сообщения в топики, из которых они пришли
+type DeadLetterQueue struct {
+	conn     *Connection
+	topics   []string          // DLQ-топики в порядке конфигурации
+	sources  map[string]string // DLQ-топик -> топик, для которого он настроен
+	groupID  string
+	redriver *kafka.Writer // топик задается в каждом сообщении
+}
+
+// Проверка соответствия интерфейсу
+var _ interfaces.DeadLetterQueue = (*DeadLetterQueue)(nil)
+
+// NewDeadLetterQueue создает DLQ для всех топиков из topics, у которых задан DLQ-топик.
+// Прочитанные при повторной отправке офсеты фиксируются в группе groupID
+func NewDeadLetterQueue(conn *Connection, topics []config.TopicConfig, groupID string) *DeadLetterQueue {
+	q := &DeadLetterQueue{
+		conn:     conn,
+		sources:  make(map[string]string, len(topics)),
+		groupID:  groupID,
+		redriver: conn.NewWriter("", false),
+	}
+
+	for _, t := range topics {
+		if t.DLQTopic == "" {
+			continue
+		}
+		if _, ok := q.sources[t.DLQTopic]; !ok {
+			q.topics = append(q.topics, t.DLQTopic)
+			q.sources[t.DLQTopic] = t.Topic
+		}
+	}
+	return q
+}
+
+// List возвращает до limit сообщений из DLQ-топика topic (пустой - из всех DLQ), не сдвигая офсеты
+func (q *DeadLetterQueue) List(ctx context.Context, topic string, limit int) ([]models.DeadLetter, error) {
+	topics, err := q.selectTopics(topic)
+	if err != nil {
+		return nil, err
+	}
+
+	var letters []models.DeadLetter
+	for _, topic := range topics {
+		partitions, err := q.partitions(ctx, topic)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, partition := range partitions {
+			if len(letters) >= limit {
+				return letters, nil
+			}
+
+			read, err := q.readPartition(ctx, topic, partition, limit-len(letters))
+			if err != nil {
+				return nil, err
+			}
+			letters = append(letters, read...)
+		}
+	}
+
+	return letters, nil
+}
+
+// Redrive возвращает до limit сообщений из DLQ-топика topic (пустой - из всех DLQ)
+// в исходные топики из заголовка x-dlq-source-topic. Сообщения без заголовка
+// или с неизвестным топиком возвращаются в топик, для которого настроена DLQ.
+// Офсеты фиксируются в отдельной группе, поэтому одно сообщение не отправляется дважды
+func (q *DeadLetterQueue) Redrive(ctx context.Context, topic string, limit int) (int, error) {
+	topics, err := q.selectTopics(topic)
+	if err != nil {
+		return 0, err
+	}
+
+	redriven := 0
+	for _, topic := range topics {
+		if redriven >= limit {
+			break
+		}
+
+		n, err := q.redriveTopic(ctx, topic, limit-redriven)
+		redriven += n
+		if err != nil {
+			return redriven, err
+		}
+	}
+
+	return redriven, nil
+}
+
+// redriveTopic возвращает до limit сообщений из одного DLQ-топика
+func (q *DeadLetterQueue) redriveTopic(ctx context.Context, topic string, limit int) (int, error) {
+	reader := q.conn.NewReader(kafka.ReaderConfig{
+		Topic:       topic,
+		GroupID:     q.groupID,
+		StartOffset: kafka.FirstOffset,
+	})
+	defer reader.Close()
+
+	redriven := 0
+	for redriven < limit {
+		fetchCtx, cancel := context.WithTimeout(ctx, redriveIdleTimeout)
+		msg, err := reader.FetchMessage(fetchCtx)
+		cancel()
+		if err != nil {
+			if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+				break // DLQ пуста
+			}
+			return redriven, fmt.Errorf("failed to fetch message from DLQ %s: %w", topic, err)
+		}
+
+		letter := toMessage(msg)
+		err = q.redriver.WriteMessages(ctx, kafka.Message{
+			Topic:   q.redriveTarget(topic, letter),
+			Key:     msg.Key,
+			Value:   msg.Value,
+			Headers: toKafkaHeaders(withoutHeaders(letter.Headers, dlqHeaderPrefix)),
+		})
+		if err != nil {
+			return redriven, fmt.Errorf("failed to redrive message: %w", err)
+		}
+
+		if err := reader.CommitMessages(ctx, msg); err != nil {
+			return redriven, fmt.Errorf("failed to commit DLQ offset: %w", err)
+		}
+		redriven++
+	}
+
+	return redriven, nil
+}
+
+// redriveTarget выбирает топик для повторной отправки: исходный топик сообщения,
+// если consumer на него подписан, иначе топик, для которого настроена DLQ
+func (q *DeadLetterQueue) redriveTarget(dlqTopic string, msg models.Message) string {
+	source := headerValue(msg, HeaderDLQSourceTopic)
+	for _, topic := range q.sources {
+		if topic == source {
+			return source
+		}
+	}
+	return q.sources[dlqTopic]
+}
+
+// selectTopics возвращает DLQ-топики для запроса: один указанный или все
+func (q *DeadLetterQueue) selectTopics(topic string) ([]string, error) {
+	if topic == "" {
+		return q.topics, nil
+	}
+	if _, ok := q.sources[topic]; !ok {
+		return nil, fmt.Errorf("%w: %s is not a configured DLQ topic", apperrors.ErrUnknownTopic, topic)
+	}
+	return []string{topic}, nil
+}
+
+func (q *DeadLetterQueue) Close() error {
+	return q.redriver.Close()
+}
+
+// partitions возвращает список партиций DLQ-топика
+func (q *DeadLetterQueue) partitions(ctx context.Context, topic string) ([]int, error) {
+	conn, err := q.conn.Dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	list, err := conn.ReadPartitions(topic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read DLQ partitions: %w", err)
+	}
+
+	partitions := make([]int, 0, len(list))
+	for _, p := range list {
+		partitions = append(partitions, p.ID)
+	}
+	return partitions, nil
+}
+
+// readPartition читает до limit сообщений из начала партиции
+func (q *DeadLetterQueue) readPartition(ctx context.Context, topic string, partition, limit int) ([]models.DeadLetter, error) {
+	conn, err := q.conn.DialLeader(ctx, topic, partition)
+	if err != nil {
+		return nil, err
+	}
+	first, last, err := conn.ReadOffsets()
+	conn.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read DLQ offsets: %w", err)
+	}
+	if first >= last {
+		return nil, nil
+	}
+
+	reader := q.conn.NewReader(kafka.ReaderConfig{
+		Topic:     topic,
+		Partition: partition,
+	})
+	defer reader.Close()
+
+	if err := reader.SetOffset(first); err != nil {
+		return nil, err
+	}
+
+	var letters []models.DeadLetter
+	for len(letters) < limit {
+		msg, err := reader.ReadMessage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read DLQ message: %w", err)
+		}
+		letters = append(letters, toDeadLetter(toMessage(msg)))
+
+		if msg.Offset >= last-1 {
+			break
+		}
+	}
+
+	return letters, nil
+}
+
+// toDeadLetter преобразует сообщение в модель DLQ
+func toDeadLetter(msg models.Message) models.DeadLetter {
+	letter := models.DeadLetter{
+		Topic:     msg.Topic,
+		Partition: msg.Partition,
+		Offset:    msg.Offset,
+		Time:      msg.Time,
+		Key:       string(msg.Key),
+		Value:     string(msg.Value),
+		Headers:   make(map[string]string, len(msg.Headers)),
+	}
+
+	for _, h := range msg.Headers {
+		value := string(h.Value)
+		letter.Headers[h.Key] = value
+
+		switch h.Key {
+		case HeaderDLQError:
+			letter.Error = value
+		case HeaderDLQStage:
+			letter.Stage = value
+		case HeaderDLQAttempts:
+			letter.Attempts, _ = strconv.Atoi(value)
+		case HeaderDLQSourceTopic:
+			letter.SourceTopic = value
+		case HeaderDLQSourcePartition:
+			letter.SourcePartition, _ = strconv.Atoi(value)
+		case HeaderDLQSourceOffset:
+			letter.SourceOffset, _ = strconv.ParseInt(value, 10, 64)
+		}
+	}
+
+	return letter
+}
+
+// withoutHeaders возвращает копию заголовков без служебных заголовков с указанными префиксами
+func withoutHeaders(headers []models.MessageHeader, prefixes ...string) []models.MessageHeader {
+	result := make([]models.MessageHeader, 0, len(headers))
+next:
+	for _, h := range headers {
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(h.Key, prefix) {
+				continue next
+			}
+		}
+		result = append(result, h)
+	}
+	return result
+}
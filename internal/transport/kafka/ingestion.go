@@ -0,0 +1,52 @@
+package kafka
+
+import (
+	"strings"
+	"time"
+
+	"order-service/internal/models"
+)
+
+// Заголовки сообщения, которые сохраняются вместе с заказом
+const (
+	HeaderTraceID       = "x-trace-id"
+	HeaderTraceParent   = "traceparent" // W3C Trace Context: 00-<trace-id>-<span-id>-<flags>
+	HeaderProducerID    = "x-producer-id"
+	HeaderSchemaVersion = "x-schema-version"
+)
+
+// newIngestion собирает сведения о сообщении, из которого получен заказ.
+// Для отложенных сообщений указывается их исходное положение
+func newIngestion(msg models.Message, contentType string) *models.OrderIngestion {
+	topic, partition, offset := messageSource(msg)
+
+	ingestion := &models.OrderIngestion{
+		Topic:         topic,
+		Partition:     partition,
+		Offset:        offset,
+		Key:           string(msg.Key),
+		ContentType:   contentType,
+		TraceID:       traceID(msg),
+		ProducerID:    headerValue(msg, HeaderProducerID),
+		SchemaVersion: headerValue(msg, HeaderSchemaVersion),
+		IngestedAt:    time.Now().UTC(),
+	}
+	if !msg.Time.IsZero() {
+		t := msg.Time.UTC()
+		ingestion.MessageTime = &t
+	}
+	return ingestion
+}
+
+// traceID берет идентификатор трассировки из x-trace-id или из traceparent
+func traceID(msg models.Message) string {
+	if id := headerValue(msg, HeaderTraceID); id != "" {
+		return id
+	}
+
+	parts := strings.Split(headerValue(msg, HeaderTraceParent), "-")
+	if len(parts) == 4 {
+		return parts[1]
+	}
+	return ""
+}
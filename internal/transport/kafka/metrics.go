@@ -0,0 +1,214 @@
+package kafka
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"order-service/internal/models"
+
+	apperrors "order-service/internal/errors" // кастомные ошибки
+)
+
+// Окно для расчета скорости обработки, в секундах
+const rateWindow = 60
+
+// Метрики Prometheus, общие для всех consumer'ов процесса
+var (
+	messagesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "order_service",
+		Subsystem: "consumer",
+		Name:      "messages_total",
+		Help:      "Number of Kafka messages handled by the consumer.",
+	}, []string{"topic", "result"})
+
+	errorsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "order_service",
+		Subsystem: "consumer",
+		Name:      "errors_total",
+		Help:      "Number of message processing errors by stage and reason.",
+	}, []string{"topic", "stage", "reason"})
+
+	processingSeconds = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "order_service",
+		Subsystem: "consumer",
+		Name:      "processing_duration_seconds",
+		Help:      "Time spent processing a Kafka message.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"topic"})
+
+	lagGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "order_service",
+		Subsystem: "consumer",
+		Name:      "lag",
+		Help:      "Number of messages the consumer is behind the partition high water mark.",
+	}, []string{"topic", "partition"})
+)
+
+// Результаты обработки сообщения для метрик
+const (
+	resultProcessed = "processed"
+	resultDLQ       = "dlq"
+	resultDeferred  = "deferred"
+	resultDuplicate = "duplicate" // заказ уже сохранен, сообщение пропущено
+)
+
+// consumerMetrics публикует метрики Prometheus и хранит сводку для GET /admin/consumer
+type consumerMetrics struct {
+	topics    []string
+	groupID   string
+	startedAt time.Time
+
+	mu             sync.Mutex
+	processed      int64
+	failed         int64
+	latencyTotal   time.Duration
+	latencyCount   int64
+	partitions     map[string]*models.PartitionStatus
+	errorsByStage  map[string]int64
+	errorsByReason map[string]int64
+	buckets        [rateWindow]int64 // количество обработанных сообщений по секундам
+	bucketSecs     [rateWindow]int64
+}
+
+func newConsumerMetrics(topics []string, groupID string) *consumerMetrics {
+	return &consumerMetrics{
+		topics:         topics,
+		groupID:        groupID,
+		startedAt:      time.Now(),
+		partitions:     make(map[string]*models.PartitionStatus),
+		errorsByStage:  make(map[string]int64),
+		errorsByReason: make(map[string]int64),
+	}
+}
+
+// observeFetch обновляет отставание партиции по полученному сообщению
+func (m *consumerMetrics) observeFetch(msg models.Message) {
+	lag := max(msg.HighWaterMark-msg.Offset-1, 0)
+	lagGauge.WithLabelValues(msg.Topic, strconv.Itoa(msg.Partition)).Set(float64(lag))
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := msg.Topic + "/" + strconv.Itoa(msg.Partition)
+	m.partitions[key] = &models.PartitionStatus{
+		Topic:         msg.Topic,
+		Partition:     msg.Partition,
+		Offset:        msg.Offset,
+		HighWaterMark: msg.HighWaterMark,
+		Lag:           lag,
+		UpdatedAt:     time.Now(),
+	}
+}
+
+// observeHandled учитывает завершение обработки сообщения
+func (m *consumerMetrics) observeHandled(msg models.Message, result string, duration time.Duration) {
+	messagesTotal.WithLabelValues(msg.Topic, result).Inc()
+	processingSeconds.WithLabelValues(msg.Topic).Observe(duration.Seconds())
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if result == resultProcessed || result == resultDuplicate {
+		m.processed++
+	} else {
+		m.failed++
+	}
+	m.latencyTotal += duration
+	m.latencyCount++
+
+	now := time.Now().Unix()
+	i := now % rateWindow
+	if m.bucketSecs[i] != now {
+		m.bucketSecs[i] = now
+		m.buckets[i] = 0
+	}
+	m.buckets[i]++
+}
+
+// observeError учитывает ошибку обработки по этапу и причине
+func (m *consumerMetrics) observeError(msg models.Message, err error) {
+	stage := apperrors.StageOf(err)
+	reason := errorReason(err)
+	errorsTotal.WithLabelValues(msg.Topic, stage, reason).Inc()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.errorsByStage[stage]++
+	m.errorsByReason[reason]++
+}
+
+// Status возвращает сводку о работе consumer'а
+func (m *consumerMetrics) Status() models.ConsumerStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	status := models.ConsumerStatus{
+		Topics:         m.topics,
+		GroupID:        m.groupID,
+		StartedAt:      m.startedAt,
+		Processed:      m.processed,
+		Failed:         m.failed,
+		ErrorsByStage:  make(map[string]int64, len(m.errorsByStage)),
+		ErrorsByReason: make(map[string]int64, len(m.errorsByReason)),
+	}
+
+	if m.latencyCount > 0 {
+		status.AvgLatencyMs = float64(m.latencyTotal.Milliseconds()) / float64(m.latencyCount)
+	}
+
+	// Скорость считаем по последней минуте (или меньше, если consumer запущен недавно)
+	now := time.Now().Unix()
+	var recent int64
+	for i := range m.buckets {
+		if now-m.bucketSecs[i] < rateWindow {
+			recent += m.buckets[i]
+		}
+	}
+	window := min(time.Since(m.startedAt).Seconds(), rateWindow)
+	if window > 0 {
+		status.MessagesPerSec = float64(recent) / window
+	}
+
+	for _, p := range m.partitions {
+		status.Partitions = append(status.Partitions, *p)
+		status.TotalLag += p.Lag
+	}
+	sort.Slice(status.Partitions, func(i, j int) bool {
+		if status.Partitions[i].Topic != status.Partitions[j].Topic {
+			return status.Partitions[i].Topic < status.Partitions[j].Topic
+		}
+		return status.Partitions[i].Partition < status.Partitions[j].Partition
+	})
+
+	for stage, count := range m.errorsByStage {
+		status.ErrorsByStage[stage] = count
+	}
+	for reason, count := range m.errorsByReason {
+		status.ErrorsByReason[reason] = count
+	}
+
+	return status
+}
+
+// errorReason возвращает причину ошибки для метрик
+func errorReason(err error) string {
+	switch {
+	case errors.Is(err, apperrors.ErrTemporary):
+		return "temporary"
+	case apperrors.StageOf(err) == apperrors.StageDecode:
+		return "malformed"
+	case apperrors.StageOf(err) == apperrors.StageValidate:
+		return "invalid"
+	case errors.Is(err, apperrors.ErrOrderExists):
+		return "duplicate"
+	default:
+		return "internal"
+	}
+}
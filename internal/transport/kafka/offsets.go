@@ -0,0 +1,162 @@
+package kafka
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"order-service/internal/interfaces"
+	"order-service/internal/models"
+)
+
+// Сколько ждать финальной фиксации офсетов при остановке consumer'а
+const finalCommitTimeout = 10 * time.Second
+
+// partitionOffsets отслеживает сообщения одной партиции, которые еще обрабатываются.
+// Зафиксировать можно только офсет, перед которым все сообщения уже обработаны
+type partitionOffsets struct {
+	inflight    []int64 // офсеты в порядке получения
+	done        map[int64]models.Message
+	committable *models.Message
+}
+
+// topicPartition - партиция конкретного топика
+type topicPartition struct {
+	topic     string
+	partition int
+}
+
+// offsetCommitter накапливает обработанные сообщения и фиксирует офсеты пачками:
+// по одному офсету на партицию каждого топика, строго по порядку, даже если сообщения
+// обработаны параллельно и не по порядку
+type offsetCommitter struct {
+	source    interfaces.MessageSource
+	batchSize int
+	interval  time.Duration
+
+	mu         sync.Mutex
+	partitions map[topicPartition]*partitionOffsets
+	marked     int
+}
+
+func newOffsetCommitter(source interfaces.MessageSource, batchSize int, interval time.Duration) *offsetCommitter {
+	if batchSize < 1 {
+		batchSize = 1
+	}
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	return &offsetCommitter{
+		source:     source,
+		batchSize:  batchSize,
+		interval:   interval,
+		partitions: make(map[topicPartition]*partitionOffsets),
+	}
+}
+
+// Track регистрирует полученное сообщение до начала его обработки
+func (c *offsetCommitter) Track(msg models.Message) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	p := c.partition(msg)
+	p.inflight = append(p.inflight, msg.Offset)
+}
+
+// MarkDone отмечает сообщение как обработанное. При накоплении batchSize
+// сообщений офсеты фиксируются сразу
+func (c *offsetCommitter) MarkDone(ctx context.Context, msg models.Message) {
+	c.mu.Lock()
+	p := c.partition(msg)
+	p.done[msg.Offset] = msg
+
+	// Сдвигаем границу фиксации по непрерывному префиксу обработанных сообщений
+	for len(p.inflight) > 0 {
+		next, ok := p.done[p.inflight[0]]
+		if !ok {
+			break
+		}
+		delete(p.done, next.Offset)
+		p.inflight = p.inflight[1:]
+		p.committable = &next
+	}
+
+	c.marked++
+	full := c.marked >= c.batchSize
+	c.mu.Unlock()
+
+	if full {
+		c.Flush(ctx)
+	}
+}
+
+// Flush фиксирует офсеты всех сообщений, обработанных по порядку
+func (c *offsetCommitter) Flush(ctx context.Context) {
+	c.mu.Lock()
+	var msgs []models.Message
+	for _, p := range c.partitions {
+		if p.committable != nil {
+			msgs = append(msgs, *p.committable)
+			p.committable = nil
+		}
+	}
+	c.marked = 0
+	c.mu.Unlock()
+
+	if len(msgs) == 0 {
+		return
+	}
+
+	if err := c.source.Commit(ctx, msgs...); err != nil {
+		log.Printf("Error committing offsets: %v", err)
+		c.restore(msgs)
+	}
+}
+
+// Run периодически фиксирует офсеты до отмены контекста
+func (c *offsetCommitter) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.Flush(ctx)
+		}
+	}
+}
+
+// Close делает финальную фиксацию офсетов при остановке
+func (c *offsetCommitter) Close() {
+	ctx, cancel := context.WithTimeout(context.Background(), finalCommitTimeout)
+	defer cancel()
+
+	c.Flush(ctx)
+}
+
+// restore возвращает незафиксированные офсеты, чтобы попробовать еще раз
+func (c *offsetCommitter) restore(msgs []models.Message) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, msg := range msgs {
+		p := c.partition(msg)
+		if p.committable == nil || msg.Offset > p.committable.Offset {
+			p.committable = &msg
+		}
+	}
+}
+
+func (c *offsetCommitter) partition(msg models.Message) *partitionOffsets {
+	id := topicPartition{topic: msg.Topic, partition: msg.Partition}
+	p, ok := c.partitions[id]
+	if !ok {
+		p = &partitionOffsets{done: make(map[int64]models.Message)}
+		c.partitions[id] = p
+	}
+	return p
+}
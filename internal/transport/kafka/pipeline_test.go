@@ -0,0 +1,92 @@
+package kafka_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"order-service/internal/models"
+	"order-service/internal/transport/kafka"
+)
+
+// Сквозная обработка через брокер в памяти: валидные заказы сохраняются,
+// повтор уже сохраненного заказа пропускается, а сообщения с постоянными
+// ошибками и исчерпанными попытками уходят в DLQ с заголовками об ошибке
+func TestConsumerPipeline(t *testing.T) {
+	broker := newRecordingBroker()
+	repo := newMemoryRepository()
+	repo.unavailable = map[string]bool{"order-unavailable": true}
+
+	invalid := testOrder("order-invalid")
+	invalid.Items = nil
+
+	messages := []models.Message{
+		orderMessage(t, testOrder("order-first")),
+		{Key: []byte("broken"), Value: []byte(`{"order_uid": `)},
+		orderMessage(t, invalid),
+		orderMessage(t, testOrder("order-unavailable")),
+		orderMessage(t, testOrder("order-last")),
+		orderMessage(t, testOrder("order-first")),
+	}
+
+	sink, err := broker.Sink(testTopic)
+	if err != nil {
+		t.Fatalf("Sink: %v", err)
+	}
+	if err := sink.Publish(context.Background(), messages...); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stopped := startConsumer(t, ctx, broker, repo, testConfig())
+	waitFor(t, func() bool { return broker.committedOffset() == int64(len(messages)-1) })
+	cancel()
+	waitStopped(t, stopped)
+
+	for uid, want := range map[string]int{"order-first": 1, "order-last": 1, "order-unavailable": 0, "order-invalid": 0} {
+		if got := repo.inserts(uid); got != want {
+			t.Errorf("order %s saved %d times, want %d", uid, got, want)
+		}
+	}
+
+	// DLQ: источник сообщения и этап, на котором произошла ошибка
+	want := map[int64]string{1: "decode", 2: "validate", 3: "persist"}
+	dlq := broker.Messages(testDLQ)
+	if len(dlq) != len(want) {
+		t.Fatalf("expected %d messages in DLQ, got %d", len(want), len(dlq))
+	}
+	for _, msg := range dlq {
+		offset, err := strconv.ParseInt(header(msg, kafka.HeaderDLQSourceOffset), 10, 64)
+		if err != nil {
+			t.Fatalf("DLQ message without source offset: %v", msg.Headers)
+		}
+
+		stage, ok := want[offset]
+		if !ok {
+			t.Errorf("unexpected message from offset %d in DLQ", offset)
+			continue
+		}
+		if got := header(msg, kafka.HeaderDLQStage); got != stage {
+			t.Errorf("offset %d: stage %q, want %q", offset, got, stage)
+		}
+		if got := header(msg, kafka.HeaderDLQSourceTopic); got != testTopic {
+			t.Errorf("offset %d: source topic %q, want %q", offset, got, testTopic)
+		}
+		if header(msg, kafka.HeaderDLQError) == "" {
+			t.Errorf("offset %d: DLQ message without error", offset)
+		}
+		if string(msg.Value) != string(messages[offset].Value) {
+			t.Errorf("offset %d: DLQ message value differs from the original", offset)
+		}
+	}
+}
+
+func header(msg models.Message, key string) string {
+	for _, h := range msg.Headers {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,128 @@
+package kafka
+
+import (
+	"context"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"order-service/internal/models"
+)
+
+// Режимы упорядочивания сообщений в пуле обработчиков
+const (
+	OrderingPartition = "partition" // сообщения одной партиции обрабатываются последовательно
+	OrderingKey       = "key"       // последовательно обрабатываются только сообщения с одинаковым ключом
+)
+
+// workerPool распределяет сообщения по обработчикам так, чтобы порядок сохранялся
+// внутри партиции (или ключа), а разные партиции обрабатывались параллельно.
+// Очереди ограничены, поэтому при медленной обработке чтение из Kafka притормаживает.
+// Каждый обработчик накапливает сообщения в пачки до batchSize штук или batchTimeout
+type workerPool struct {
+	queues   []chan models.Message
+	ordering string
+	wg       sync.WaitGroup
+}
+
+func newWorkerPool(ctx context.Context, workers, queueSize, batchSize int, batchTimeout time.Duration,
+	ordering string, handle func([]models.Message)) *workerPool {
+	workers = max(workers, 1)
+	queueSize = max(queueSize, 1)
+	batchSize = max(batchSize, 1)
+
+	pool := &workerPool{
+		queues:   make([]chan models.Message, workers),
+		ordering: ordering,
+	}
+
+	for i := range pool.queues {
+		queue := make(chan models.Message, queueSize)
+		pool.queues[i] = queue
+
+		pool.wg.Add(1)
+		go func() {
+			defer pool.wg.Done()
+			runWorker(ctx, queue, batchSize, batchTimeout, handle)
+		}()
+	}
+
+	return pool
+}
+
+// runWorker собирает сообщения из очереди в пачки и передает их в handle
+func runWorker(ctx context.Context, queue <-chan models.Message, batchSize int, batchTimeout time.Duration,
+	handle func([]models.Message)) {
+	batch := make([]models.Message, 0, batchSize)
+	var timer *time.Timer
+	var timeout <-chan time.Time
+
+	flush := func() {
+		if timer != nil {
+			timer.Stop()
+			timer, timeout = nil, nil
+		}
+		if len(batch) == 0 {
+			return
+		}
+
+		// После остановки оставшиеся сообщения не обрабатываем: их офсеты
+		// не будут зафиксированы, и они придут снова после перезапуска
+		if ctx.Err() == nil {
+			handle(batch)
+		}
+		batch = make([]models.Message, 0, batchSize)
+	}
+
+	for {
+		select {
+		case msg, ok := <-queue:
+			if !ok {
+				flush()
+				return
+			}
+
+			batch = append(batch, msg)
+			if len(batch) >= batchSize {
+				flush()
+			} else if timer == nil {
+				timer = time.NewTimer(batchTimeout)
+				timeout = timer.C
+			}
+		case <-timeout:
+			timer, timeout = nil, nil
+			flush()
+		}
+	}
+}
+
+// Submit ставит сообщение в очередь обработчика. Блокируется, если очередь заполнена.
+// Возвращает false, если контекст отменен
+func (p *workerPool) Submit(ctx context.Context, msg models.Message) bool {
+	select {
+	case p.queues[p.route(msg)] <- msg:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// Stop закрывает очереди и ждет завершения обработчиков
+func (p *workerPool) Stop() {
+	for _, queue := range p.queues {
+		close(queue)
+	}
+	p.wg.Wait()
+}
+
+// route выбирает обработчик для сообщения
+func (p *workerPool) route(msg models.Message) int {
+	if p.ordering == OrderingKey && len(msg.Key) > 0 {
+		h := fnv.New32a()
+		h.Write(msg.Key)
+		return int(h.Sum32() % uint32(len(p.queues)))
+	}
+	h := fnv.New32a()
+	h.Write([]byte(msg.Topic))
+	return int((h.Sum32() + uint32(msg.Partition)) % uint32(len(p.queues)))
+}
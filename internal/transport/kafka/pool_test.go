@@ -0,0 +1,119 @@
+package kafka
+
+import (
+	"context"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"order-service/internal/models"
+)
+
+const (
+	benchPartitions = 8
+	benchLatency    = 100 * time.Microsecond // задержка записи одного сообщения (БД, брокер)
+)
+
+// BenchmarkConsume сравнивает последовательную обработку сообщений по одному
+// (чтение, обработка, фиксация офсета) с пулом обработчиков по партициям
+func BenchmarkConsume(b *testing.B) {
+	b.Run("sequential", func(b *testing.B) {
+		source := newBenchSource(b.N)
+		sink := &benchSink{latency: benchLatency}
+		ctx := context.Background()
+
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			msg, _ := source.Fetch(ctx)
+			sink.Publish(ctx, msg)
+			source.Commit(ctx, msg)
+		}
+	})
+
+	for _, workers := range []int{1, 4, 8} {
+		b.Run("pool/workers="+strconv.Itoa(workers), func(b *testing.B) {
+			source := newBenchSource(b.N)
+			sink := &benchSink{latency: benchLatency}
+			ctx := context.Background()
+
+			committer := newOffsetCommitter(source, 100, time.Second)
+			pool := newWorkerPool(ctx, workers, 100, 1, time.Millisecond, OrderingPartition,
+				func(batch []models.Message) {
+					for _, msg := range batch {
+						sink.Publish(ctx, msg)
+						committer.MarkDone(ctx, msg)
+					}
+				})
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				msg, _ := source.Fetch(ctx)
+				committer.Track(msg)
+				pool.Submit(ctx, msg)
+			}
+			pool.Stop()
+			committer.Close()
+			b.StopTimer()
+
+			if got := sink.published.Load(); got != int64(b.N) {
+				b.Fatalf("published %d messages, want %d", got, b.N)
+			}
+		})
+	}
+}
+
+// benchSource отдает n заранее подготовленных сообщений, распределенных по партициям
+type benchSource struct {
+	messages []models.Message
+	next     int
+	commits  atomic.Int64
+}
+
+func newBenchSource(n int) *benchSource {
+	messages := make([]models.Message, n)
+	for i := range messages {
+		messages[i] = models.Message{
+			Topic:     "orders",
+			Partition: i % benchPartitions,
+			Offset:    int64(i / benchPartitions),
+			Value:     []byte(`{}`),
+		}
+	}
+	return &benchSource{messages: messages}
+}
+
+func (s *benchSource) Fetch(context.Context) (models.Message, error) {
+	msg := s.messages[s.next]
+	s.next++
+	return msg, nil
+}
+
+func (s *benchSource) Commit(_ context.Context, msgs ...models.Message) error {
+	s.commits.Add(int64(len(msgs)))
+	return nil
+}
+
+func (s *benchSource) Close() error {
+	return nil
+}
+
+// benchSink имитирует запись с постоянной задержкой
+type benchSink struct {
+	latency   time.Duration
+	published atomic.Int64
+}
+
+func (s *benchSink) Publish(_ context.Context, msgs ...models.Message) error {
+	time.Sleep(s.latency)
+	s.published.Add(int64(len(msgs)))
+	return nil
+}
+
+func (s *benchSink) Send(ctx context.Context, msg models.Message) (models.Message, error) {
+	return msg, s.Publish(ctx, msg)
+}
+
+func (s *benchSink) Close() error {
+	return nil
+}
@@ -0,0 +1,181 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	"order-service/internal/codec"
+	"order-service/internal/config"
+	"order-service/internal/interfaces"
+	"order-service/internal/models"
+
+	apperrors "order-service/internal/errors" // кастомные ошибки
+)
+
+// Имена обработчиков топиков
+const (
+	HandlerOrders       = "orders"
+	HandlerOrderStatus  = "order-status"
+	HandlerPayment      = "payment"
+	HandlerCancellation = "cancellation"
+)
+
+// HeaderContentType - заголовок с форматом значения сообщения. Если его нет,
+// используется формат топика из конфигурации
+const HeaderContentType = "content-type"
+
+// HandlerFunc обрабатывает сообщение топика, значение которого закодировано в формате contentType.
+// ctx отменяется при остановке consumer'а
+type HandlerFunc func(ctx context.Context, msg models.Message, contentType string) error
+
+// Registry сопоставляет имена обработчиков из конфигурации с функциями обработки
+type Registry struct {
+	handlers map[string]HandlerFunc
+}
+
+func NewRegistry() *Registry {
+	return &Registry{handlers: make(map[string]HandlerFunc)}
+}
+
+// Register регистрирует обработчик под именем name
+func (r *Registry) Register(name string, handler HandlerFunc) {
+	r.handlers[name] = handler
+}
+
+// Handler возвращает обработчик по имени
+func (r *Registry) Handler(name string) (HandlerFunc, bool) {
+	handler, ok := r.handlers[name]
+	return handler, ok
+}
+
+// NewOrderRegistry создает реестр со стандартными обработчиками сервиса заказов
+func NewOrderRegistry(service interfaces.OrderService) *Registry {
+	registry := NewRegistry()
+
+	registry.Register(HandlerOrders, func(ctx context.Context, msg models.Message, contentType string) error {
+		return service.ProcessOrder(ctx, orderPayload(msg, contentType))
+	})
+
+	registry.Register(HandlerOrderStatus, func(ctx context.Context, msg models.Message, contentType string) error {
+		var update models.OrderStatusUpdate
+		if err := decodeEvent(contentType, msg, &update); err != nil {
+			return err
+		}
+		return service.UpdateOrderStatus(ctx, update)
+	})
+
+	registry.Register(HandlerPayment, func(ctx context.Context, msg models.Message, contentType string) error {
+		var event models.PaymentEvent
+		if err := decodeEvent(contentType, msg, &event); err != nil {
+			return err
+		}
+		return service.ApplyPaymentEvent(ctx, event)
+	})
+
+	registry.Register(HandlerCancellation, func(ctx context.Context, msg models.Message, contentType string) error {
+		var cancellation models.OrderCancellation
+		if err := decodeEvent(contentType, msg, &cancellation); err != nil {
+			return err
+		}
+		return service.CancelOrder(ctx, cancellation)
+	})
+
+	return registry
+}
+
+// orderPayload - заказ из сообщения вместе со сведениями о сообщении
+func orderPayload(msg models.Message, contentType string) models.OrderPayload {
+	return models.OrderPayload{
+		Data:        msg.Value,
+		ContentType: contentType,
+		Ingestion:   newIngestion(msg, contentType),
+	}
+}
+
+// decodeEvent декодирует событие, помечая ошибку этапом decode.
+// События передаются только в JSON
+func decodeEvent(contentType string, msg models.Message, v interface{}) error {
+	normalized, err := codec.Normalize(contentType)
+	if err == nil && normalized != codec.ContentTypeJSON {
+		err = fmt.Errorf("unsupported content type %q for events", contentType)
+	}
+	if err == nil {
+		err = json.Unmarshal(msg.Value, v)
+	}
+	if err != nil {
+		return apperrors.NewProcessingError(apperrors.StageDecode,
+			fmt.Errorf("failed to decode message from %s: %w", msg.Topic, err))
+	}
+	return nil
+}
+
+// route - подписка на топик: обработчик, формат сообщений по умолчанию и DLQ топика
+type route struct {
+	topic       string
+	handler     string
+	handle      HandlerFunc
+	contentType string
+	dlq         *DLQPublisher
+}
+
+// contentTypeOf возвращает формат сообщения из заголовка content-type или формат топика
+func (r *route) contentTypeOf(msg models.Message) string {
+	for _, h := range msg.Headers {
+		if strings.EqualFold(h.Key, HeaderContentType) {
+			return string(h.Value)
+		}
+	}
+	return r.contentType
+}
+
+// newRoutes связывает топики из конфигурации с обработчиками реестра
+func newRoutes(broker interfaces.MessageBroker, topics []config.TopicConfig, registry *Registry) (map[string]*route, error) {
+	routes := make(map[string]*route, len(topics))
+
+	for _, t := range topics {
+		handle, ok := registry.Handler(t.Handler)
+		if !ok {
+			closeRoutes(routes)
+			return nil, fmt.Errorf("topic %s: unknown handler %q", t.Topic, t.Handler)
+		}
+
+		contentType, err := codec.Normalize(t.Format)
+		if err != nil {
+			closeRoutes(routes)
+			return nil, fmt.Errorf("topic %s: unsupported format %q", t.Topic, t.Format)
+		}
+
+		r := &route{
+			topic:       t.Topic,
+			handler:     t.Handler,
+			handle:      handle,
+			contentType: contentType,
+		}
+		if t.DLQTopic != "" {
+			sink, err := broker.Sink(t.DLQTopic)
+			if err != nil {
+				closeRoutes(routes)
+				return nil, fmt.Errorf("topic %s: failed to create DLQ publisher: %w", t.Topic, err)
+			}
+			r.dlq = NewDLQPublisher(sink)
+		}
+		routes[t.Topic] = r
+	}
+
+	return routes, nil
+}
+
+// closeRoutes закрывает отправителей в DLQ
+func closeRoutes(routes map[string]*route) {
+	for _, r := range routes {
+		if r.dlq == nil {
+			continue
+		}
+		if err := r.dlq.Close(); err != nil {
+			log.Printf("Error closing DLQ publisher for %s: %v", r.topic, err)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"order-service/internal/config"
+	"order-service/internal/interfaces"
+	"order-service/internal/models"
+)
+
+// Заголовки сообщений в топиках повторной обработки
+const (
+	HeaderRetryAttempts        = "x-retry-attempts"
+	HeaderRetryNotBefore       = "x-retry-not-before"
+	HeaderRetryError           = "x-retry-error"
+	HeaderRetrySourceTopic     = "x-retry-source-topic"
+	HeaderRetrySourcePartition = "x-retry-source-partition"
+	HeaderRetrySourceOffset    = "x-retry-source-offset"
+
+	retryHeaderPrefix = "x-retry-"
+)
+
+// retryTier - топик отложенной обработки вместе с подпиской и отправителем для него
+type retryTier struct {
+	topic  string
+	delay  time.Duration
+	source interfaces.MessageSource
+	sink   interfaces.MessageSink
+}
+
+func newRetryTier(broker interfaces.MessageBroker, groupID string, tier config.RetryTier) (*retryTier, error) {
+	source, err := broker.Source(groupID, tier.Topic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to subscribe to retry topic %s: %w", tier.Topic, err)
+	}
+
+	sink, err := broker.Sink(tier.Topic)
+	if err != nil {
+		source.Close()
+		return nil, fmt.Errorf("failed to create retry topic %s publisher: %w", tier.Topic, err)
+	}
+
+	return &retryTier{
+		topic:  tier.Topic,
+		delay:  tier.Delay,
+		source: source,
+		sink:   sink,
+	}, nil
+}
+
+// publish откладывает сообщение в топик уровня с отметкой времени, раньше которого его нельзя обрабатывать
+func (t *retryTier) publish(ctx context.Context, msg models.Message, procErr error, attempts int) error {
+	topic, partition, offset := messageSource(msg)
+
+	headers := withoutHeaders(msg.Headers, retryHeaderPrefix)
+	headers = append(headers,
+		models.MessageHeader{Key: HeaderRetryAttempts, Value: []byte(strconv.Itoa(attempts))},
+		models.MessageHeader{Key: HeaderRetryNotBefore, Value: []byte(time.Now().Add(t.delay).UTC().Format(time.RFC3339Nano))},
+		models.MessageHeader{Key: HeaderRetryError, Value: []byte(procErr.Error())},
+		models.MessageHeader{Key: HeaderRetrySourceTopic, Value: []byte(topic)},
+		models.MessageHeader{Key: HeaderRetrySourcePartition, Value: []byte(strconv.Itoa(partition))},
+		models.MessageHeader{Key: HeaderRetrySourceOffset, Value: []byte(strconv.FormatInt(offset, 10))},
+	)
+
+	err := t.sink.Publish(ctx, models.Message{
+		Key:     msg.Key,
+		Value:   msg.Value,
+		Headers: headers,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to publish message to retry topic %s: %w", t.topic, err)
+	}
+
+	return nil
+}
+
+func (t *retryTier) close() error {
+	if err := t.sink.Close(); err != nil {
+		return err
+	}
+	return t.source.Close()
+}
+
+// backoff возвращает паузу перед попыткой attempt (начиная с 1): экспоненциальный рост,
+// ограниченный сверху MaxBackoff
+func backoff(policy config.RetryConfig, attempt int) time.Duration {
+	delay := policy.InitialBackoff
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= policy.MaxBackoff {
+			return policy.MaxBackoff
+		}
+	}
+	return min(delay, policy.MaxBackoff)
+}
+
+// sleepContext ждет d или отмены контекста. Возвращает false, если контекст отменен
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// retryAttempts возвращает количество уже сделанных попыток обработки сообщения
+func retryAttempts(msg models.Message) int {
+	attempts, _ := strconv.Atoi(headerValue(msg, HeaderRetryAttempts))
+	return attempts
+}
+
+// retryNotBefore возвращает время, раньше которого отложенное сообщение нельзя обрабатывать
+func retryNotBefore(msg models.Message) time.Time {
+	notBefore, err := time.Parse(time.RFC3339Nano, headerValue(msg, HeaderRetryNotBefore))
+	if err != nil {
+		return time.Time{}
+	}
+	return notBefore
+}
+
+// messageSource возвращает исходные топик, партицию и офсет сообщения,
+// даже если оно прошло через топики повторной обработки
+func messageSource(msg models.Message) (string, int, int64) {
+	topic := headerValue(msg, HeaderRetrySourceTopic)
+	if topic == "" {
+		return msg.Topic, msg.Partition, msg.Offset
+	}
+
+	partition, _ := strconv.Atoi(headerValue(msg, HeaderRetrySourcePartition))
+	offset, _ := strconv.ParseInt(headerValue(msg, HeaderRetrySourceOffset), 10, 64)
+	return topic, partition, offset
+}
+
+func headerValue(msg models.Message, key string) string {
+	for _, h := range msg.Headers {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,183 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"order-service/internal/interfaces"
+	"order-service/internal/models"
+)
+
+// Проверка соответствия интерфейсам
+var (
+	_ interfaces.MessageBroker = (*Broker)(nil)
+	_ interfaces.MessageSource = (*Source)(nil)
+	_ interfaces.MessageSink   = (*Sink)(nil)
+)
+
+// Broker - брокер сообщений в памяти процесса. У каждого топика одна партиция,
+// группы хранят подтвержденные офсеты и после переподключения продолжают с них.
+// Подходит для сквозных тестов без внешнего брокера
+type Broker struct {
+	mu        sync.Mutex
+	topics    map[string][]models.Message
+	committed map[string]map[string]int64 // группа -> топик -> следующий офсет
+	published chan struct{}               // закрывается при каждой публикации
+}
+
+func NewBroker() *Broker {
+	return &Broker{
+		topics:    make(map[string][]models.Message),
+		committed: make(map[string]map[string]int64),
+		published: make(chan struct{}),
+	}
+}
+
+func (b *Broker) Source(groupID string, topics ...string) (interfaces.MessageSource, error) {
+	if len(topics) == 0 {
+		return nil, errors.New("at least one topic is required")
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.committed[groupID] == nil {
+		b.committed[groupID] = make(map[string]int64)
+	}
+
+	cursors := make(map[string]int64, len(topics))
+	for _, topic := range topics {
+		cursors[topic] = b.committed[groupID][topic]
+	}
+
+	return &Source{
+		broker:  b,
+		groupID: groupID,
+		topics:  topics,
+		cursors: cursors,
+		done:    make(chan struct{}),
+	}, nil
+}
+
+func (b *Broker) Sink(topic string) (interfaces.MessageSink, error) {
+	return &Sink{broker: b, topic: topic}, nil
+}
+
+func (b *Broker) Close() error {
+	return nil
+}
+
+// Messages возвращает копию всех сообщений топика
+func (b *Broker) Messages(topic string) []models.Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return append([]models.Message(nil), b.topics[topic]...)
+}
+
+// publish добавляет сообщения в конец топика, будит ожидающих читателей
+// и возвращает сохраненные сообщения с офсетами
+func (b *Broker) publish(topic string, msgs []models.Message) []models.Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	stored := make([]models.Message, len(msgs))
+	for i, msg := range msgs {
+		stored[i] = models.Message{
+			Topic:   topic,
+			Offset:  int64(len(b.topics[topic])),
+			Key:     msg.Key,
+			Value:   msg.Value,
+			Headers: append([]models.MessageHeader(nil), msg.Headers...),
+			Time:    time.Now(),
+		}
+		b.topics[topic] = append(b.topics[topic], stored[i])
+	}
+
+	close(b.published)
+	b.published = make(chan struct{})
+	return stored
+}
+
+// Source - подписка группы на топики в памяти
+type Source struct {
+	broker    *Broker
+	groupID   string
+	topics    []string
+	cursors   map[string]int64 // следующий офсет для чтения по топикам
+	next      int              // топик, с которого начинается следующий поиск
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// Fetch возвращает следующее сообщение, перебирая топики по кругу, или ждет публикации
+func (s *Source) Fetch(ctx context.Context) (models.Message, error) {
+	for {
+		b := s.broker
+		b.mu.Lock()
+		for i := range s.topics {
+			topic := s.topics[(s.next+i)%len(s.topics)]
+			messages := b.topics[topic]
+			offset := s.cursors[topic]
+			if offset < int64(len(messages)) {
+				msg := messages[offset]
+				msg.HighWaterMark = int64(len(messages))
+				s.cursors[topic] = offset + 1
+				s.next = (s.next + i + 1) % len(s.topics)
+				b.mu.Unlock()
+				return msg, nil
+			}
+		}
+		published := b.published
+		b.mu.Unlock()
+
+		select {
+		case <-published:
+		case <-ctx.Done():
+			return models.Message{}, ctx.Err()
+		case <-s.done:
+			return models.Message{}, io.EOF
+		}
+	}
+}
+
+// Commit запоминает офсеты группы
+func (s *Source) Commit(_ context.Context, msgs ...models.Message) error {
+	b := s.broker
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, msg := range msgs {
+		if msg.Offset+1 > b.committed[s.groupID][msg.Topic] {
+			b.committed[s.groupID][msg.Topic] = msg.Offset + 1
+		}
+	}
+	return nil
+}
+
+func (s *Source) Close() error {
+	s.closeOnce.Do(func() { close(s.done) })
+	return nil
+}
+
+// Sink публикует сообщения в топик в памяти
+type Sink struct {
+	broker *Broker
+	topic  string
+}
+
+func (s *Sink) Publish(_ context.Context, msgs ...models.Message) error {
+	s.broker.publish(s.topic, msgs)
+	return nil
+}
+
+func (s *Sink) Send(_ context.Context, msg models.Message) (models.Message, error) {
+	return s.broker.publish(s.topic, []models.Message{msg})[0], nil
+}
+
+func (s *Sink) Close() error {
+	return nil
+}
@@ -0,0 +1,157 @@
+package nats
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+
+	"order-service/internal/config"
+	"order-service/internal/interfaces"
+)
+
+// Таймаут служебных запросов к JetStream (создание стрима, консьюмера)
+const requestTimeout = 10 * time.Second
+
+// Проверка соответствия интерфейсу
+var _ interfaces.MessageBroker = (*Broker)(nil)
+
+// Broker - брокер сообщений на NATS JetStream. Все топики хранятся в одном стриме
+// как отдельные субъекты; субъект добавляется в стрим при первом обращении
+type Broker struct {
+	conn        *nats.Conn
+	js          jetstream.JetStream
+	stream      string
+	ackWait     time.Duration
+	startOffset jetstream.DeliverPolicy
+
+	mu       sync.Mutex // защищает изменение списка субъектов стрима
+	subjects map[string]bool
+}
+
+// NewBroker подключается к NATS. name - имя клиента, которое видно в мониторинге сервера
+func NewBroker(cfg config.NATSConfig, name string) (*Broker, error) {
+	var deliver jetstream.DeliverPolicy
+	switch strings.ToLower(cfg.StartOffset) {
+	case "", "first", "earliest":
+		deliver = jetstream.DeliverAllPolicy
+	case "last", "latest":
+		deliver = jetstream.DeliverNewPolicy
+	default:
+		return nil, fmt.Errorf("unsupported start offset %q, expected first or last", cfg.StartOffset)
+	}
+
+	conn, err := nats.Connect(cfg.URL, nats.Name(name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to nats: %w", err)
+	}
+
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to create jetstream context: %w", err)
+	}
+
+	return &Broker{
+		conn:        conn,
+		js:          js,
+		stream:      cfg.Stream,
+		ackWait:     cfg.AckWait,
+		startOffset: deliver,
+		subjects:    make(map[string]bool),
+	}, nil
+}
+
+// Source создает по одному durable-консьюмеру на топик. Подтверждение сообщения
+// (AckAll) подтверждает и все предыдущие, как фиксация офсета в Kafka
+func (b *Broker) Source(groupID string, topics ...string) (interfaces.MessageSource, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	source := newSource()
+	for _, topic := range topics {
+		if err := b.ensureSubject(ctx, topic); err != nil {
+			source.Close()
+			return nil, err
+		}
+
+		consumer, err := b.js.CreateOrUpdateConsumer(ctx, b.stream, jetstream.ConsumerConfig{
+			Durable:       durableName(groupID, topic),
+			FilterSubject: topic,
+			AckPolicy:     jetstream.AckAllPolicy,
+			AckWait:       b.ackWait,
+			DeliverPolicy: b.startOffset,
+		})
+		if err != nil {
+			source.Close()
+			return nil, fmt.Errorf("failed to create consumer for %s: %w", topic, err)
+		}
+
+		if err := source.subscribe(consumer); err != nil {
+			source.Close()
+			return nil, fmt.Errorf("failed to subscribe to %s: %w", topic, err)
+		}
+	}
+
+	return source, nil
+}
+
+// Sink создает отправителя в топик
+func (b *Broker) Sink(topic string) (interfaces.MessageSink, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	if err := b.ensureSubject(ctx, topic); err != nil {
+		return nil, err
+	}
+	return &Sink{js: b.js, subject: topic}, nil
+}
+
+func (b *Broker) Close() error {
+	return b.conn.Drain()
+}
+
+// ensureSubject создает стрим или добавляет в него субъект топика
+func (b *Broker) ensureSubject(ctx context.Context, subject string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.subjects[subject] {
+		return nil
+	}
+
+	stream, err := b.js.Stream(ctx, b.stream)
+	switch {
+	case errors.Is(err, jetstream.ErrStreamNotFound):
+		_, err = b.js.CreateStream(ctx, jetstream.StreamConfig{
+			Name:     b.stream,
+			Subjects: []string{subject},
+		})
+	case err == nil:
+		cfg := stream.CachedInfo().Config
+		if !slices.Contains(cfg.Subjects, subject) {
+			cfg.Subjects = append(cfg.Subjects, subject)
+			_, err = b.js.UpdateStream(ctx, cfg)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to add subject %s to stream %s: %w", subject, b.stream, err)
+	}
+
+	b.subjects[subject] = true
+	return nil
+}
+
+// Имена durable-консьюмеров не могут содержать точки, пробелы и символы подстановки
+var invalidDurableChars = regexp.MustCompile(`[^A-Za-z0-9_-]`)
+
+func durableName(groupID, topic string) string {
+	return invalidDurableChars.ReplaceAllString(groupID+"-"+topic, "_")
+}
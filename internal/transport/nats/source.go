@@ -0,0 +1,221 @@
+package nats
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+
+	"order-service/internal/interfaces"
+	"order-service/internal/models"
+)
+
+// В NATS нет ключей сообщений, поэтому ключ передается заголовком
+const HeaderMessageKey = "x-message-key"
+
+// Проверка соответствия интерфейсам
+var (
+	_ interfaces.MessageSource = (*Source)(nil)
+	_ interfaces.MessageSink   = (*Sink)(nil)
+)
+
+// pendingKey - сообщение, которое получено, но еще не подтверждено
+type pendingKey struct {
+	topic    string
+	sequence int64
+}
+
+// Source объединяет сообщения нескольких консьюмеров в один поток.
+// Офсет сообщения - его номер в стриме, партиция всегда 0
+type Source struct {
+	msgs      chan models.Message
+	done      chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+
+	mu        sync.Mutex
+	iterators []jetstream.MessagesContext
+	pending   map[pendingKey]jetstream.Msg
+}
+
+func newSource() *Source {
+	return &Source{
+		msgs:    make(chan models.Message),
+		done:    make(chan struct{}),
+		pending: make(map[pendingKey]jetstream.Msg),
+	}
+}
+
+// subscribe запускает чтение сообщений консьюмера в общий поток
+func (s *Source) subscribe(consumer jetstream.Consumer) error {
+	iter, err := consumer.Messages()
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.iterators = append(s.iterators, iter)
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		for {
+			m, err := iter.Next()
+			if errors.Is(err, jetstream.ErrMsgIteratorClosed) {
+				return
+			}
+			if err != nil {
+				log.Printf("Error reading from nats: %v", err)
+				continue
+			}
+
+			msg, err := s.track(m)
+			if err != nil {
+				log.Printf("Error reading nats message metadata: %v", err)
+				continue
+			}
+
+			select {
+			case s.msgs <- msg:
+			case <-s.done:
+				return
+			}
+		}
+	}()
+
+	return nil
+}
+
+// track запоминает сообщение до подтверждения и преобразует его в сообщение брокера
+func (s *Source) track(m jetstream.Msg) (models.Message, error) {
+	meta, err := m.Metadata()
+	if err != nil {
+		return models.Message{}, err
+	}
+
+	msg := models.Message{
+		Topic:         m.Subject(),
+		Offset:        int64(meta.Sequence.Stream),
+		HighWaterMark: int64(meta.Sequence.Stream) + int64(meta.NumPending) + 1,
+		Value:         m.Data(),
+		Time:          meta.Timestamp,
+	}
+	for key, values := range m.Headers() {
+		for _, value := range values {
+			if key == HeaderMessageKey {
+				msg.Key = []byte(value)
+				continue
+			}
+			msg.Headers = append(msg.Headers, models.MessageHeader{Key: key, Value: []byte(value)})
+		}
+	}
+
+	s.mu.Lock()
+	s.pending[pendingKey{topic: msg.Topic, sequence: msg.Offset}] = m
+	s.mu.Unlock()
+
+	return msg, nil
+}
+
+func (s *Source) Fetch(ctx context.Context) (models.Message, error) {
+	select {
+	case msg := <-s.msgs:
+		return msg, nil
+	case <-ctx.Done():
+		return models.Message{}, ctx.Err()
+	case <-s.done:
+		return models.Message{}, io.EOF
+	}
+}
+
+// Commit подтверждает сообщения. Благодаря AckAll подтверждаются и все предыдущие
+// сообщения топика, поэтому их можно забыть
+func (s *Source) Commit(ctx context.Context, msgs ...models.Message) error {
+	for _, msg := range msgs {
+		key := pendingKey{topic: msg.Topic, sequence: msg.Offset}
+
+		s.mu.Lock()
+		m, ok := s.pending[key]
+		s.mu.Unlock()
+		if !ok {
+			continue
+		}
+
+		if err := m.DoubleAck(ctx); err != nil {
+			return fmt.Errorf("failed to ack %s sequence %d: %w", msg.Topic, msg.Offset, err)
+		}
+
+		s.mu.Lock()
+		for k := range s.pending {
+			if k.topic == key.topic && k.sequence <= key.sequence {
+				delete(s.pending, k)
+			}
+		}
+		s.mu.Unlock()
+	}
+	return nil
+}
+
+// Close останавливает чтение. Неподтвержденные сообщения будут доставлены повторно
+func (s *Source) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.done)
+
+		s.mu.Lock()
+		for _, iter := range s.iterators {
+			iter.Stop()
+		}
+		s.mu.Unlock()
+	})
+
+	s.wg.Wait()
+	return nil
+}
+
+// Sink публикует сообщения в субъект топика
+type Sink struct {
+	js      jetstream.JetStream
+	subject string
+}
+
+func (s *Sink) Publish(ctx context.Context, msgs ...models.Message) error {
+	for _, msg := range msgs {
+		if _, err := s.Send(ctx, msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Send публикует сообщение и возвращает его с номером в стриме в качестве офсета
+func (s *Sink) Send(ctx context.Context, msg models.Message) (models.Message, error) {
+	out := nats.NewMsg(s.subject)
+	out.Data = msg.Value
+	for _, h := range msg.Headers {
+		out.Header.Add(h.Key, string(h.Value))
+	}
+	if len(msg.Key) > 0 {
+		out.Header.Set(HeaderMessageKey, string(msg.Key))
+	}
+
+	ack, err := s.js.PublishMsg(ctx, out)
+	if err != nil {
+		return models.Message{}, fmt.Errorf("failed to publish to %s: %w", s.subject, err)
+	}
+
+	msg.Topic = s.subject
+	msg.Partition = 0
+	msg.Offset = int64(ack.Sequence)
+	return msg, nil
+}
+
+// Close ничего не делает: соединение принадлежит брокеру
+func (s *Sink) Close() error {
+	return nil
+}
@@ -0,0 +1,131 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"order-service/internal/models"
+)
+
+// Сколько байт ответа получателя сохраняется в журнале при ошибке
+const maxErrorBody = 512
+
+// Run доставляет события из очереди, пока не отменен контекст
+func (s *Service) Run(ctx context.Context) {
+	log.Println("Webhook dispatcher started")
+
+	for {
+		delivered := s.dispatch(ctx)
+
+		// Полная пачка - скорее всего, есть еще доставки
+		if delivered == s.cfg.BatchSize && ctx.Err() == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Println("Webhook dispatcher stopped")
+			return
+		case <-time.After(s.cfg.PollInterval):
+		}
+	}
+}
+
+// dispatch выбирает пачку доставок и отправляет их параллельно.
+// Возвращает количество обработанных доставок
+func (s *Service) dispatch(ctx context.Context) int {
+	// Доставка не должна вернуться в очередь, пока идут все попытки отправки пачки
+	lease := s.cfg.Timeout*time.Duration(s.cfg.BatchSize/max(s.cfg.Concurrency, 1)+1) + time.Minute
+
+	tasks, err := s.repo.ClaimDeliveries(ctx, s.cfg.BatchSize, lease)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("Webhook dispatcher error: %v", err)
+		}
+		return 0
+	}
+
+	sem := make(chan struct{}, max(s.cfg.Concurrency, 1))
+	var wg sync.WaitGroup
+	for _, task := range tasks {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			s.deliver(ctx, task)
+		}()
+	}
+	wg.Wait()
+
+	return len(tasks)
+}
+
+// deliver отправляет событие и записывает результат в журнал доставок
+func (s *Service) deliver(ctx context.Context, task models.WebhookTask) {
+	result := models.WebhookResult{DeliveryID: task.DeliveryID, SubscriptionID: task.SubscriptionID}
+	result.StatusCode, result.Err = s.Send(ctx, task)
+
+	// Результат записывается, даже если сервис останавливается
+	recordCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), recordTimeout)
+	defer cancel()
+
+	active, err := s.repo.RecordResult(recordCtx, result,
+		s.retryDelay(task.Attempts+1), s.cfg.MaxAttempts, s.cfg.DisableAfter)
+	switch {
+	case err != nil:
+		log.Printf("Failed to record webhook delivery %d: %v", task.DeliveryID, err)
+	case result.Err != nil && !active:
+		log.Printf("Webhook subscription %d disabled after %d consecutive failures: %v",
+			task.SubscriptionID, s.cfg.DisableAfter, result.Err)
+	case result.Err != nil:
+		log.Printf("Webhook delivery %d to %s failed (attempt %d): %v",
+			task.DeliveryID, task.URL, task.Attempts+1, result.Err)
+	}
+}
+
+// Send отправляет одно событие POST-запросом с подписью. Успешной считается доставка
+// с ответом 2xx. Возвращает код ответа (0, если ответ не получен)
+func (s *Service) Send(ctx context.Context, task models.WebhookTask) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, task.URL, bytes.NewReader(task.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "order-service-webhook")
+	req.Header.Set(HeaderEvent, task.EventType)
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(task.DeliveryID, 10))
+	req.Header.Set(HeaderSignature, Sign(task.Secret, time.Now(), task.Payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return resp.StatusCode, fmt.Errorf("receiver responded %s: %s", resp.Status, bytes.TrimSpace(body))
+	}
+
+	io.Copy(io.Discard, resp.Body)
+	return resp.StatusCode, nil
+}
+
+// retryDelay - экспоненциальная задержка перед попыткой attempts+1
+func (s *Service) retryDelay(attempts int) time.Duration {
+	delay := s.cfg.InitialBackoff
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= s.cfg.MaxBackoff {
+			return s.cfg.MaxBackoff
+		}
+	}
+	return min(delay, s.cfg.MaxBackoff)
+}
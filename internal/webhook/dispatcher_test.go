@@ -0,0 +1,340 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"order-service/internal/config"
+	"order-service/internal/models"
+
+	apperrors "order-service/internal/errors" // кастомные ошибки
+)
+
+const testPayload = `{"type":"order.created","order_uid":"b563feb7b2b84b6test"}`
+
+func TestDeliver(t *testing.T) {
+	type request struct {
+		header http.Header
+		body   []byte
+	}
+	requests := make(chan request, 1)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- request{header: r.Header, body: body}
+	}))
+	defer receiver.Close()
+
+	repo := newMemoryRepository()
+	sub := repo.subscribe(receiver.URL)
+	id := repo.enqueue(sub, testPayload)
+
+	s := NewService(repo, testConfig(), nil)
+	if n := s.dispatch(context.Background()); n != 1 {
+		t.Fatalf("dispatched %d deliveries, want 1", n)
+	}
+
+	req := <-requests
+	if string(req.body) != testPayload {
+		t.Errorf("unexpected body: %s", req.body)
+	}
+	if err := Verify(sub.Secret, req.header.Get(HeaderSignature), req.body, time.Minute); err != nil {
+		t.Errorf("invalid signature: %v", err)
+	}
+	if got := req.header.Get(HeaderEvent); got != models.EventOrderCreated {
+		t.Errorf("event header %q, want %q", got, models.EventOrderCreated)
+	}
+	if got := req.header.Get(HeaderDelivery); got != strconv.FormatInt(id, 10) {
+		t.Errorf("delivery header %q, want %d", got, id)
+	}
+
+	if d := repo.delivery(id); d.status != models.WebhookDeliveryDelivered || d.attempts != 1 {
+		t.Errorf("delivery status %s after %d attempts, want delivered after 1", d.status, d.attempts)
+	}
+}
+
+func TestDeliverRetriesServerError(t *testing.T) {
+	var mu sync.Mutex
+	calls := 0
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if calls++; calls == 1 {
+			http.Error(w, "temporarily unavailable", http.StatusServiceUnavailable)
+		}
+	}))
+	defer receiver.Close()
+
+	repo := newMemoryRepository()
+	id := repo.enqueue(repo.subscribe(receiver.URL), testPayload)
+
+	cfg := testConfig()
+	s := NewService(repo, cfg, nil)
+
+	s.dispatch(context.Background())
+	d := repo.delivery(id)
+	if d.status != models.WebhookDeliveryPending || d.lastStatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("after 5xx: status %s, code %d, want pending, 503", d.status, d.lastStatusCode)
+	}
+	if d.retryDelay != cfg.InitialBackoff {
+		t.Errorf("retry delay %s, want %s", d.retryDelay, cfg.InitialBackoff)
+	}
+
+	s.dispatch(context.Background())
+	if d := repo.delivery(id); d.status != models.WebhookDeliveryDelivered || d.attempts != 2 {
+		t.Errorf("after retry: status %s after %d attempts, want delivered after 2", d.status, d.attempts)
+	}
+}
+
+func TestDisableAfterFailures(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer receiver.Close()
+
+	repo := newMemoryRepository()
+	sub := repo.subscribe(receiver.URL)
+	for range 5 {
+		repo.enqueue(sub, testPayload)
+	}
+
+	cfg := testConfig()
+	cfg.BatchSize = 1
+	cfg.DisableAfter = 3
+	s := NewService(repo, cfg, nil)
+
+	for s.dispatch(context.Background()) > 0 {
+	}
+
+	got, err := repo.GetSubscription(context.Background(), sub.ID)
+	if err != nil {
+		t.Fatalf("GetSubscription: %v", err)
+	}
+	if got.Active || got.DisabledAt == nil {
+		t.Fatalf("subscription still active after %d failures", got.ConsecutiveFailures)
+	}
+	if got.ConsecutiveFailures != cfg.DisableAfter {
+		t.Errorf("subscription disabled after %d failures, want %d", got.ConsecutiveFailures, cfg.DisableAfter)
+	}
+	if n := repo.attempts(); n != cfg.DisableAfter {
+		t.Errorf("%d delivery attempts made, want %d", n, cfg.DisableAfter)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	s := NewService(newMemoryRepository(), config.WebhookConfig{
+		InitialBackoff: time.Second,
+		MaxBackoff:     10 * time.Second,
+	}, nil)
+
+	for attempts, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 4: 8 * time.Second, 5: 10 * time.Second, 20: 10 * time.Second} {
+		if got := s.retryDelay(attempts); got != want {
+			t.Errorf("retryDelay(%d) = %s, want %s", attempts, got, want)
+		}
+	}
+}
+
+func testConfig() config.WebhookConfig {
+	return config.WebhookConfig{
+		BatchSize:      10,
+		Concurrency:    2,
+		Timeout:        5 * time.Second,
+		MaxAttempts:    5,
+		InitialBackoff: 10 * time.Millisecond,
+		MaxBackoff:     100 * time.Millisecond,
+		DisableAfter:   20,
+		// Получатель в тестах - httptest.Server на loopback
+		AllowPrivate: true,
+	}
+}
+
+// memoryRepository - подписки и очередь доставок в памяти с теми же правилами повторов
+// и отключения подписок, что в БД. Время следующей попытки не учитывается:
+// ожидающая доставка выбирается при следующем опросе
+type memoryRepository struct {
+	mu         sync.Mutex
+	subs       map[int64]*models.WebhookSubscription
+	deliveries map[int64]*memoryDelivery
+	nextID     int64
+}
+
+type memoryDelivery struct {
+	task           models.WebhookTask
+	status         string
+	attempts       int
+	lastStatusCode int
+	retryDelay     time.Duration
+	claimed        bool
+}
+
+func newMemoryRepository() *memoryRepository {
+	return &memoryRepository{
+		subs:       make(map[int64]*models.WebhookSubscription),
+		deliveries: make(map[int64]*memoryDelivery),
+	}
+}
+
+func (r *memoryRepository) subscribe(url string) *models.WebhookSubscription {
+	sub := &models.WebhookSubscription{URL: url, EventTypes: eventTypes, Secret: "whsec_test"}
+	r.CreateSubscription(context.Background(), sub)
+	return sub
+}
+
+func (r *memoryRepository) enqueue(sub *models.WebhookSubscription, payload string) int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextID++
+	r.deliveries[r.nextID] = &memoryDelivery{
+		task: models.WebhookTask{
+			DeliveryID:     r.nextID,
+			SubscriptionID: sub.ID,
+			EventType:      models.EventOrderCreated,
+			Payload:        []byte(payload),
+		},
+		status: models.WebhookDeliveryPending,
+	}
+	return r.nextID
+}
+
+func (r *memoryRepository) delivery(id int64) memoryDelivery {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return *r.deliveries[id]
+}
+
+// attempts возвращает общее число попыток доставки
+func (r *memoryRepository) attempts() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := 0
+	for _, d := range r.deliveries {
+		n += d.attempts
+	}
+	return n
+}
+
+func (r *memoryRepository) CreateSubscription(_ context.Context, sub *models.WebhookSubscription) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextID++
+	sub.ID, sub.Active, sub.CreatedAt = r.nextID, true, time.Now()
+	stored := *sub
+	r.subs[sub.ID] = &stored
+	return nil
+}
+
+func (r *memoryRepository) GetSubscription(_ context.Context, id int64) (*models.WebhookSubscription, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	sub, ok := r.subs[id]
+	if !ok {
+		return nil, apperrors.ErrSubscriptionNotFound
+	}
+	copied := *sub
+	return &copied, nil
+}
+
+func (r *memoryRepository) ListSubscriptions(context.Context) ([]models.WebhookSubscription, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	subs := make([]models.WebhookSubscription, 0, len(r.subs))
+	for _, sub := range r.subs {
+		subs = append(subs, *sub)
+	}
+	return subs, nil
+}
+
+func (r *memoryRepository) DeleteSubscription(_ context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.subs[id]; !ok {
+		return apperrors.ErrSubscriptionNotFound
+	}
+	delete(r.subs, id)
+	return nil
+}
+
+func (r *memoryRepository) EnableSubscription(_ context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	sub, ok := r.subs[id]
+	if !ok {
+		return apperrors.ErrSubscriptionNotFound
+	}
+	sub.Active, sub.ConsecutiveFailures, sub.DisabledAt = true, 0, nil
+	return nil
+}
+
+func (r *memoryRepository) ListDeliveries(context.Context, int64, int) ([]models.WebhookDelivery, error) {
+	return nil, nil
+}
+
+func (r *memoryRepository) ClaimDeliveries(_ context.Context, limit int, _ time.Duration) ([]models.WebhookTask, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var tasks []models.WebhookTask
+	for id := int64(1); id <= r.nextID && len(tasks) < limit; id++ {
+		d, ok := r.deliveries[id]
+		if !ok || d.claimed || d.status != models.WebhookDeliveryPending {
+			continue
+		}
+		sub, ok := r.subs[d.task.SubscriptionID]
+		if !ok || !sub.Active {
+			continue
+		}
+
+		d.claimed = true
+		task := d.task
+		task.Attempts, task.URL, task.Secret = d.attempts, sub.URL, sub.Secret
+		tasks = append(tasks, task)
+	}
+	return tasks, nil
+}
+
+func (r *memoryRepository) RecordResult(_ context.Context, result models.WebhookResult,
+	retryDelay time.Duration, maxAttempts, disableAfter int) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	d := r.deliveries[result.DeliveryID]
+	d.claimed = false
+	d.attempts++
+	d.lastStatusCode = result.StatusCode
+
+	sub, ok := r.subs[result.SubscriptionID]
+	if result.Err == nil {
+		d.status = models.WebhookDeliveryDelivered
+		if ok {
+			sub.ConsecutiveFailures = 0
+		}
+		return true, nil
+	}
+
+	d.retryDelay = retryDelay
+	if d.attempts >= maxAttempts {
+		d.status = models.WebhookDeliveryFailed
+	}
+	if !ok {
+		return false, nil
+	}
+
+	sub.ConsecutiveFailures++
+	if sub.Active && sub.ConsecutiveFailures >= disableAfter {
+		now := time.Now()
+		sub.Active, sub.DisabledAt = false, &now
+	}
+	return sub.Active, nil
+}
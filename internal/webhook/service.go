@@ -0,0 +1,124 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"time"
+
+	"order-service/internal/config"
+	"order-service/internal/interfaces"
+	"order-service/internal/models"
+
+	apperrors "order-service/internal/errors" // кастомные ошибки
+)
+
+// Таймаут записи результата доставки
+const recordTimeout = 5 * time.Second
+
+// Типы событий, на которые можно подписаться
+var eventTypes = []string{models.EventOrderCreated, models.EventOrderUpdated}
+
+// Проверка соответствия интерфейсу
+var _ interfaces.WebhookManager = (*Service)(nil)
+
+// Service управляет подписками и доставляет события из очереди. Доставки ставятся
+// в очередь репозиторием заказов в транзакции изменения заказа. Очередь хранится в БД,
+// поэтому доставки переживают перезапуск и распределяются между репликами
+type Service struct {
+	repo   interfaces.WebhookRepository
+	cfg    config.WebhookConfig
+	client *http.Client
+}
+
+// NewService создает сервис webhook. client может быть nil - тогда используется
+// клиент с таймаутом из конфигурации, не подключающийся к адресам внутренней сети
+func NewService(repo interfaces.WebhookRepository, cfg config.WebhookConfig, client *http.Client) *Service {
+	if client == nil {
+		client = newClient(cfg.Timeout, cfg.AllowPrivate)
+	}
+	return &Service{repo: repo, cfg: cfg, client: client}
+}
+
+func (s *Service) Subscribe(ctx context.Context, req models.WebhookSubscriptionRequest) (*models.WebhookSubscription, error) {
+	target, err := url.Parse(req.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return nil, fmt.Errorf("%w: url must be an absolute http or https URL", apperrors.ErrInvalidSubscription)
+	}
+	if !s.cfg.AllowPrivate {
+		if err := checkHost(ctx, target.Hostname()); err != nil {
+			return nil, err
+		}
+	}
+
+	types := req.EventTypes
+	if len(types) == 0 {
+		types = eventTypes
+	}
+	for _, t := range types {
+		if !slices.Contains(eventTypes, t) {
+			return nil, fmt.Errorf("%w: unknown event type %q", apperrors.ErrInvalidSubscription, t)
+		}
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		if secret, err = newSecret(); err != nil {
+			return nil, err
+		}
+	}
+
+	sub := &models.WebhookSubscription{
+		URL:        target.String(),
+		EventTypes: slices.Compact(slices.Sorted(slices.Values(types))),
+		Secret:     secret,
+	}
+	if err := s.repo.CreateSubscription(ctx, sub); err != nil {
+		return nil, err
+	}
+
+	log.Printf("Webhook subscription %d created: %s %v", sub.ID, sub.URL, sub.EventTypes)
+	return sub, nil
+}
+
+func (s *Service) Subscription(ctx context.Context, id int64) (*models.WebhookSubscription, error) {
+	sub, err := s.repo.GetSubscription(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	sub.Secret = ""
+	return sub, nil
+}
+
+func (s *Service) Subscriptions(ctx context.Context) ([]models.WebhookSubscription, error) {
+	subs, err := s.repo.ListSubscriptions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range subs {
+		subs[i].Secret = ""
+	}
+	return subs, nil
+}
+
+func (s *Service) Unsubscribe(ctx context.Context, id int64) error {
+	return s.repo.DeleteSubscription(ctx, id)
+}
+
+// Enable включает подписку, отключенную после ошибок доставки
+func (s *Service) Enable(ctx context.Context, id int64) (*models.WebhookSubscription, error) {
+	if err := s.repo.EnableSubscription(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.Subscription(ctx, id)
+}
+
+func (s *Service) Deliveries(ctx context.Context, id int64, limit int) ([]models.WebhookDelivery, error) {
+	if _, err := s.repo.GetSubscription(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.repo.ListDeliveries(ctx, id, limit)
+}
@@ -0,0 +1,80 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"order-service/internal/models"
+
+	apperrors "order-service/internal/errors" // кастомные ошибки
+)
+
+func TestSubscribeRejectsInternalAddresses(t *testing.T) {
+	cfg := testConfig()
+	cfg.AllowPrivate = false
+	s := NewService(newMemoryRepository(), cfg, nil)
+
+	for _, url := range []string{
+		"http://127.0.0.1:8081/hooks",
+		"http://localhost/hooks",
+		"http://10.1.2.3/hooks",
+		"https://192.168.0.10/hooks",
+		"http://172.16.0.1/hooks",
+		"http://169.254.169.254/latest/meta-data",
+		"http://100.64.0.1/hooks",
+		"http://0.0.0.0/hooks",
+		"http://[::1]/hooks",
+		"http://[fe80::1]/hooks",
+		"http://[fd00::1]/hooks",
+		"http://[::ffff:127.0.0.1]/hooks",
+		"ftp://partner.example/hooks",
+	} {
+		_, err := s.Subscribe(context.Background(), models.WebhookSubscriptionRequest{URL: url})
+		if !errors.Is(err, apperrors.ErrInvalidSubscription) {
+			t.Errorf("%s: expected ErrInvalidSubscription, got %v", url, err)
+		}
+	}
+
+	sub, err := s.Subscribe(context.Background(), models.WebhookSubscriptionRequest{URL: "https://93.184.216.34/hooks"})
+	if err != nil {
+		t.Fatalf("public address rejected: %v", err)
+	}
+	if !sub.Active || sub.Secret == "" || len(sub.EventTypes) != len(eventTypes) {
+		t.Errorf("unexpected subscription: %+v", sub)
+	}
+}
+
+func TestSubscribeAllowPrivate(t *testing.T) {
+	s := NewService(newMemoryRepository(), testConfig(), nil)
+
+	if _, err := s.Subscribe(context.Background(), models.WebhookSubscriptionRequest{URL: "http://127.0.0.1:9000/hooks"}); err != nil {
+		t.Errorf("loopback rejected with AllowPrivate: %v", err)
+	}
+}
+
+// Адрес проверяется и при подключении: подписка могла быть создана, когда имя
+// разрешалось в публичный адрес
+func TestSendRejectsInternalAddresses(t *testing.T) {
+	var called atomic.Bool
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called.Store(true)
+	}))
+	defer receiver.Close()
+
+	cfg := testConfig()
+	cfg.AllowPrivate = false
+	s := NewService(newMemoryRepository(), cfg, nil)
+
+	_, err := s.Send(context.Background(), models.WebhookTask{DeliveryID: 1, URL: receiver.URL, Payload: []byte(testPayload)})
+	if err == nil || !strings.Contains(err.Error(), "is not allowed") {
+		t.Fatalf("expected delivery to a loopback address to be refused, got %v", err)
+	}
+	if called.Load() {
+		t.Error("receiver on a loopback address was called")
+	}
+}
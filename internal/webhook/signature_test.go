@@ -0,0 +1,59 @@
+package webhook_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"order-service/internal/webhook"
+)
+
+func TestSignVerify(t *testing.T) {
+	const secret = "whsec_test"
+	body := []byte(`{"type":"order.created","order_uid":"b563feb7b2b84b6test"}`)
+	header := webhook.Sign(secret, time.Now(), body)
+
+	if err := webhook.Verify(secret, header, body, time.Minute); err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+
+	tampered := append([]byte(nil), body...)
+	tampered[len(tampered)-2] = 'x'
+
+	ts, sig, _ := strings.Cut(header, ",")
+	otherTime := webhook.Sign(secret, time.Now().Add(time.Hour), body)
+	otherTs, _, _ := strings.Cut(otherTime, ",")
+
+	tests := []struct {
+		name   string
+		secret string
+		header string
+		body   []byte
+	}{
+		{"tampered body", secret, header, tampered},
+		{"wrong secret", "whsec_other", header, body},
+		{"replaced timestamp", secret, otherTs + "," + sig, body},
+		{"missing signature", secret, ts, body},
+		{"malformed header", secret, "garbage", body},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := webhook.Verify(tt.secret, tt.header, tt.body, 0); err == nil {
+				t.Errorf("expected %s to be rejected", tt.name)
+			}
+		})
+	}
+}
+
+func TestVerifyTolerance(t *testing.T) {
+	const secret = "whsec_test"
+	body := []byte(`{}`)
+	header := webhook.Sign(secret, time.Now().Add(-10*time.Minute), body)
+
+	if err := webhook.Verify(secret, header, body, 5*time.Minute); err == nil {
+		t.Error("expected old signature to be rejected")
+	}
+	if err := webhook.Verify(secret, header, body, 0); err != nil {
+		t.Errorf("signature age must not be checked with zero tolerance: %v", err)
+	}
+}
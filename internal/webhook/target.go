@@ -0,0 +1,96 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+
+	apperrors "order-service/internal/errors" // кастомные ошибки
+)
+
+// Диапазоны, которые не покрываются методами netip.Addr
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),     // "этот" сетевой узел
+	netip.MustParsePrefix("100.64.0.0/10"), // разделяемые адреса операторов (CGNAT)
+}
+
+// blockedAddr сообщает, что адрес относится к внутренней сети: loopback, частные,
+// link-local, неуказанные и multicast-адреса. На них webhook не отправляются,
+// иначе подписка позволила бы обращаться к внутренним сервисам от имени сервиса заказов
+func blockedAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast() {
+		return true
+	}
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkHost проверяет, что хост подписки не указывает на адрес внутренней сети.
+// Имя проверяется по всем адресам, в которые оно разрешается
+func checkHost(ctx context.Context, host string) error {
+	addrs := []netip.Addr{}
+	if addr, err := netip.ParseAddr(host); err == nil {
+		addrs = append(addrs, addr)
+	} else {
+		if addrs, err = net.DefaultResolver.LookupNetIP(ctx, "ip", host); err != nil {
+			return fmt.Errorf("%w: cannot resolve url host %q", apperrors.ErrInvalidSubscription, host)
+		}
+	}
+
+	for _, addr := range addrs {
+		if blockedAddr(addr) {
+			return fmt.Errorf("%w: url must not point to a loopback, private or link-local address",
+				apperrors.ErrInvalidSubscription)
+		}
+	}
+	return nil
+}
+
+// newClient создает HTTP-клиент для доставки. Адрес проверяется еще раз при подключении:
+// DNS-имя подписки могло с тех пор начать разрешаться во внутренний адрес,
+// а получатель - ответить перенаправлением на него
+func newClient(timeout time.Duration, allowPrivate bool) *http.Client {
+	if allowPrivate {
+		return &http.Client{Timeout: timeout}
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   dialControl,
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	// Запросы идут напрямую: через прокси проверялся бы адрес прокси, а не получателя
+	transport.Proxy = nil
+
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+// dialControl запрещает подключение к адресам внутренней сети
+func dialControl(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	if blockedAddr(addr) {
+		return fmt.Errorf("webhook address %s is not allowed", addr)
+	}
+	return nil
+}